@@ -256,7 +256,7 @@ func (h *AnalyticsHandler) HandleStatusChange(w http.ResponseWriter, r *http.Req
 	}
 
 	// Record status change using the service
-	err = service.RecordStatusChange(payload.TaskID, payload.PreviousStatus, payload.NewStatus, timestamp)
+	err = service.RecordStatusChange(r.Header.Get("Idempotency-Key"), payload.TaskID, payload.PreviousStatus, payload.NewStatus, timestamp)
 	if err != nil {
 		http.Error(w, "Failed to record status change: "+err.Error(), http.StatusInternalServerError)
 		return
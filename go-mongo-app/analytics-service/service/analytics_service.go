@@ -337,8 +337,31 @@ func GetUserTasksAndProject(userID string, token string) (map[string]interface{}
 	return result, nil
 }
 
-// RecordStatusChange - Zapisuje vreme provedeno u svakom statusu
-func RecordStatusChange(taskID, previousStatus, newStatus string, timestamp time.Time) error {
+// RecordStatusChange - Zapisuje vreme provedeno u svakom statusu.
+// Promena sa istim idempotencyKey se beleži samo jednom, jer task-service
+// ponavlja slanje dok ne dobije potvrdu.
+func RecordStatusChange(idempotencyKey, taskID, previousStatus, newStatus string, timestamp time.Time) error {
+	if idempotencyKey != "" {
+		processed := db.Client.Database("testdb").Collection("analytics_processed_messages")
+		_, err := processed.InsertOne(context.TODO(), bson.M{"_id": idempotencyKey, "processed_at": time.Now()})
+		if mongo.IsDuplicateKeyError(err) {
+			log.Printf("Status change %s already recorded, skipping", idempotencyKey)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := recordStatusChange(taskID, previousStatus, newStatus, timestamp); err != nil {
+			// Oslobodi ključ kako bi ponovljeni zahtev mogao da uspe
+			processed.DeleteOne(context.TODO(), bson.M{"_id": idempotencyKey})
+			return err
+		}
+		return nil
+	}
+	return recordStatusChange(taskID, previousStatus, newStatus, timestamp)
+}
+
+func recordStatusChange(taskID, previousStatus, newStatus string, timestamp time.Time) error {
 	collection := db.Client.Database("testdb").Collection("analytics")
 	// Pronađi postojeći dokument sa analizom
 	var analytics models.TaskAnalytics
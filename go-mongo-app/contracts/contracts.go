@@ -0,0 +1,107 @@
+// Package contracts opisuje poruke koje servisi razmenjuju preko NATS-a:
+// subjekte, tipove poruka sa verzijom i pomoćne funkcije koje poruku
+// proveravaju pre slanja i posle prijema. Subjekti koje koristi samo jedan
+// servis (task.events, board.*) nisu deo ugovora.
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalid označava poruku koja ne odgovara ugovoru; ponovna obrada je
+// neće popraviti.
+var ErrInvalid = errors.New("invalid message")
+
+// Message je poruka sa poznatim subjektom i verzijom šeme.
+type Message interface {
+	// Subject je NATS subjekat na koji se poruka šalje.
+	Subject() string
+	// SchemaVersion je verzija šeme koju ovaj tip piše.
+	SchemaVersion() int
+	Validate() error
+	meta() *Meta
+}
+
+// Meta je zajednički deo svake poruke. Poruke bez verzije su nastale pre
+// uvođenja ugovora i čitaju se kao verzija 1.
+type Meta struct {
+	Version int `json:"version"`
+}
+
+func (m *Meta) meta() *Meta { return m }
+
+// registry pravi praznu poruku za svaki subjekat koji ugovori pokrivaju.
+var registry = map[string]func() Message{
+	SubjectProjectMemberAdded:     func() Message { return &ProjectMemberAdded{} },
+	SubjectProjectMemberRemoved:   func() Message { return &ProjectMemberRemoved{} },
+	SubjectProjectDeleted:         func() Message { return &ProjectDeleted{} },
+	SubjectTaskMemberAdded:        func() Message { return &TaskMemberAdded{} },
+	SubjectTaskMemberRemoved:      func() Message { return &TaskMemberRemoved{} },
+	SubjectTaskStatusChanged:      func() Message { return &TaskStatusChanged{} },
+	SubjectTaskDependenciesReady:  func() Message { return &TaskDependenciesReady{} },
+	SubjectTaskAutomationNotified: func() Message { return &TaskAutomationNotified{} },
+	SubjectProjectHistory:         func() Message { return &ProjectHistory{} },
+	SubjectTaskHistory:            func() Message { return &TaskHistory{} },
+}
+
+// legacy čita poruke sa starih subjekata. Njihov sadržaj ne odgovara
+// današnjem ugovoru, pa se prevodi u današnji tip pre provere.
+var legacy = map[string]func(data []byte) (Message, error){
+	legacySubjectProjectDeleted: decodeLegacyProjectDeleted,
+}
+
+// Subjects vraća sve subjekte koje ugovori pokrivaju, uključujući stare
+// nazive koji se još čitaju.
+func Subjects() []string {
+	subjects := make([]string, 0, len(registry)+len(legacy))
+	for subject := range registry {
+		subjects = append(subjects, subject)
+	}
+	for subject := range legacy {
+		subjects = append(subjects, subject)
+	}
+	return subjects
+}
+
+// Encode proverava poruku, upisuje njenu verziju i vraća JSON za slanje.
+func Encode(m Message) ([]byte, error) {
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, m.Subject(), err)
+	}
+	m.meta().Version = m.SchemaVersion()
+	return json.Marshal(m)
+}
+
+// Decode čita poruku primljenu na subjektu subject. Greška za nepoznat
+// subjekat, neispravan JSON, noviju verziju ili nepotpunu poruku obuhvata
+// ErrInvalid.
+func Decode(subject string, data []byte) (Message, error) {
+	if decode, ok := legacy[subject]; ok {
+		m, err := decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, subject, err)
+		}
+		return m, nil
+	}
+
+	factory, ok := registry[subject]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown subject %s", ErrInvalid, subject)
+	}
+	m := factory()
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, subject, err)
+	}
+	if m.meta().Version == 0 {
+		m.meta().Version = 1
+	}
+	if m.meta().Version > m.SchemaVersion() {
+		return nil, fmt.Errorf("%w: %s: version %d is newer than supported version %d", ErrInvalid, subject, m.meta().Version, m.SchemaVersion())
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalid, subject, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,112 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	messages := []Message{
+		&ProjectMemberAdded{ProjectMember{UserID: "u1", ProjectID: "p1", ProjectName: "Apollo"}},
+		&ProjectMemberRemoved{ProjectMember{UserID: "u1", ProjectID: "p1"}},
+		&ProjectDeleted{ProjectMember{UserID: "u2", ProjectID: "p1", ProjectName: "Apollo"}},
+		&TaskMemberAdded{TaskMember{UserID: "u1", TaskID: "t1", ProjectID: "p1", TaskName: "Design"}},
+		&TaskMemberRemoved{TaskMember{UserID: "u1", TaskID: "t1"}},
+		&TaskStatusChanged{TaskMembers: TaskMembers{TaskID: "t1", MemberIDs: []string{"u1", "u2"}}, TaskStatus: "done"},
+		&TaskDependenciesReady{TaskMembers{TaskID: "t2", ProjectID: "p1", MemberIDs: []string{"u1"}}},
+		&TaskAutomationNotified{TaskMembers: TaskMembers{TaskID: "t1"}, RuleName: "escalate", Message: "Overdue"},
+		&ProjectHistory{History{ID: "k1", Type: "ProjectCreated", EventVersion: 2, ProjectID: "p1", Event: json.RawMessage(`{"title":"Apollo"}`)}},
+		&TaskHistory{History{ID: "k2", Type: "TaskStatusChanged", EventVersion: 1, Event: json.RawMessage(`{"status":"done"}`)}},
+	}
+
+	for _, m := range messages {
+		data, err := Encode(m)
+		if err != nil {
+			t.Fatalf("Encode(%s): %v", m.Subject(), err)
+		}
+		if m.meta().Version != m.SchemaVersion() {
+			t.Errorf("Encode(%s) set version %d, want %d", m.Subject(), m.meta().Version, m.SchemaVersion())
+		}
+
+		decoded, err := Decode(m.Subject(), data)
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", m.Subject(), err)
+		}
+		if !reflect.DeepEqual(decoded, m) {
+			t.Errorf("Decode(%s) = %#v, want %#v", m.Subject(), decoded, m)
+		}
+	}
+}
+
+func TestDecodeUnversionedMessageAsVersionOne(t *testing.T) {
+	m, err := Decode(SubjectTaskMemberAdded, []byte(`{"userId":"u1","taskId":"t1","taskName":"Design"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	added, ok := m.(*TaskMemberAdded)
+	if !ok {
+		t.Fatalf("Decode returned %T, want *TaskMemberAdded", m)
+	}
+	if added.Version != 1 {
+		t.Errorf("version = %d, want 1", added.Version)
+	}
+}
+
+func TestDecodeRejectsInvalidMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		data    string
+	}{
+		{"unknown subject", "project.renamed", `{"userId":"u1"}`},
+		{"malformed json", SubjectProjectMemberAdded, `{"userId":`},
+		{"newer version", SubjectProjectMemberAdded, `{"version":2,"userId":"u1","projectId":"p1"}`},
+		{"missing project", SubjectProjectDeleted, `{"userId":"u1"}`},
+		{"missing task", SubjectTaskMemberRemoved, `{"userId":"u1"}`},
+		{"empty member id", SubjectTaskStatusChanged, `{"taskId":"t1","taskStatus":"done","memberIds":["u1",""]}`},
+		{"missing status", SubjectTaskStatusChanged, `{"taskId":"t1","memberIds":["u1"]}`},
+		{"history without id", SubjectTaskHistory, `{"type":"TaskCreated"}`},
+		{"legacy without user", legacySubjectProjectDeleted, `{"projectName":"Apollo"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.subject, []byte(tt.data))
+			if !errors.Is(err, ErrInvalid) {
+				t.Errorf("Decode error = %v, want ErrInvalid", err)
+			}
+		})
+	}
+}
+
+func TestEncodeValidatesBeforeSending(t *testing.T) {
+	_, err := Encode(&TaskDependenciesReady{TaskMembers{MemberIDs: []string{"u1"}}})
+	if !errors.Is(err, ErrInvalid) {
+		t.Fatalf("Encode error = %v, want ErrInvalid", err)
+	}
+}
+
+// Stari project-service je na project.removed2 slao samo userId i projectName.
+func TestDecodeLegacyProjectDeleted(t *testing.T) {
+	m, err := Decode("project.removed2", []byte(`{"userId":"u1","projectName":"Apollo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &ProjectDeleted{ProjectMember{Meta: Meta{Version: 1}, UserID: "u1", ProjectName: "Apollo"}}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("Decode = %#v, want %#v", m, want)
+	}
+}
+
+func TestSubjectsIncludeLegacyNames(t *testing.T) {
+	subjects := Subjects()
+	sort.Strings(subjects)
+	for _, subject := range []string{SubjectProjectDeleted, legacySubjectProjectDeleted, SubjectTaskHistory} {
+		i := sort.SearchStrings(subjects, subject)
+		if i == len(subjects) || subjects[i] != subject {
+			t.Errorf("Subjects() is missing %s", subject)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// History je događaj upisan u event store, objavljen i na NATS-u da bi ga
+// pratili servisi kojima nije dovoljan pregled istorije (npr. webhook-ovi).
+// Type, EventVersion, Time i Event su isti kao u zapisu u event store-u, a ID
+// je ključ idempotentnosti tog zapisa.
+type History struct {
+	Meta
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	EventVersion int             `json:"eventVersion"`
+	Time         string          `json:"time"`
+	ProjectID    string          `json:"projectId"`
+	Event        json.RawMessage `json:"event"`
+}
+
+func (m *History) Validate() error {
+	if m.ID == "" {
+		return errors.New("id is required")
+	}
+	if m.Type == "" {
+		return errors.New("type is required")
+	}
+	return nil
+}
+
+// ProjectHistory je događaj iz istorije projekta.
+type ProjectHistory struct {
+	History
+}
+
+func (*ProjectHistory) Subject() string    { return SubjectProjectHistory }
+func (*ProjectHistory) SchemaVersion() int { return 1 }
+
+// TaskHistory je događaj iz istorije zadatka.
+type TaskHistory struct {
+	History
+}
+
+func (*TaskHistory) Subject() string    { return SubjectTaskHistory }
+func (*TaskHistory) SchemaVersion() int { return 1 }
@@ -0,0 +1,45 @@
+package contracts
+
+import (
+	"errors"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NewMsg pravi NATS poruku za slanje preko core NATS-a ili JetStream-a.
+func NewMsg(m Message) (*nats.Msg, error) {
+	data, err := Encode(m)
+	if err != nil {
+		return nil, err
+	}
+	msg := nats.NewMsg(m.Subject())
+	msg.Data = data
+	return msg, nil
+}
+
+// Publish proverava poruku i šalje je na njen subjekat.
+func Publish(nc *nats.Conn, m Message) error {
+	msg, err := NewMsg(m)
+	if err != nil {
+		return err
+	}
+	return nc.PublishMsg(msg)
+}
+
+// Subscribe prijavljuje handler na subjekat; handler dobija samo poruke
+// koje odgovaraju ugovoru, a ostale se prijavljuju preko onInvalid.
+func Subscribe(nc *nats.Conn, subject string, handler func(Message), onInvalid func(*nats.Msg, error)) (*nats.Subscription, error) {
+	if _, ok := registry[subject]; !ok {
+		return nil, errors.New("no contract for subject " + subject)
+	}
+	return nc.Subscribe(subject, func(msg *nats.Msg) {
+		m, err := Decode(msg.Subject, msg.Data)
+		if err != nil {
+			if onInvalid != nil {
+				onInvalid(msg, err)
+			}
+			return
+		}
+		handler(m)
+	})
+}
@@ -0,0 +1,66 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ProjectMember opisuje korisnika i projekat na koji se poruka odnosi.
+type ProjectMember struct {
+	Meta
+	UserID      string `json:"userId"`
+	ProjectID   string `json:"projectId"`
+	ProjectName string `json:"projectName"`
+}
+
+func (m *ProjectMember) Validate() error {
+	if m.UserID == "" {
+		return errors.New("userId is required")
+	}
+	if m.ProjectID == "" {
+		return errors.New("projectId is required")
+	}
+	return nil
+}
+
+// ProjectMemberAdded se šalje kada je korisnik dodat na projekat.
+type ProjectMemberAdded struct {
+	ProjectMember
+}
+
+func (*ProjectMemberAdded) Subject() string    { return SubjectProjectMemberAdded }
+func (*ProjectMemberAdded) SchemaVersion() int { return 1 }
+
+// ProjectMemberRemoved se šalje kada je korisnik uklonjen sa projekta.
+type ProjectMemberRemoved struct {
+	ProjectMember
+}
+
+func (*ProjectMemberRemoved) Subject() string    { return SubjectProjectMemberRemoved }
+func (*ProjectMemberRemoved) SchemaVersion() int { return 1 }
+
+// ProjectDeleted se šalje svakom članu obrisanog projekta.
+type ProjectDeleted struct {
+	ProjectMember
+}
+
+func (*ProjectDeleted) Subject() string    { return SubjectProjectDeleted }
+func (*ProjectDeleted) SchemaVersion() int { return 1 }
+
+// decodeLegacyProjectDeleted čita poruku sa subjekta project.removed2. Ona je
+// imala samo userId i projectName, pa ProjectDeleted iz nje nema projectId.
+func decodeLegacyProjectDeleted(data []byte) (Message, error) {
+	var legacy struct {
+		UserID      string `json:"userId"`
+		ProjectName string `json:"projectName"`
+	}
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+	if legacy.UserID == "" {
+		return nil, errors.New("userId is required")
+	}
+	m := &ProjectDeleted{ProjectMember{UserID: legacy.UserID, ProjectName: legacy.ProjectName}}
+	m.Version = 1
+	return m, nil
+}
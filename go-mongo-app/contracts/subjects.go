@@ -0,0 +1,21 @@
+package contracts
+
+// Subjekti poruka koje servisi razmenjuju.
+const (
+	SubjectProjectMemberAdded   = "project.joined"
+	SubjectProjectMemberRemoved = "project.removed"
+	SubjectProjectDeleted       = "project.deleted"
+
+	SubjectTaskMemberAdded        = "task.joined"
+	SubjectTaskMemberRemoved      = "task.removed"
+	SubjectTaskStatusChanged      = "task.status.update"
+	SubjectTaskDependenciesReady  = "task.dependencies.ready"
+	SubjectTaskAutomationNotified = "task.automation.notify"
+
+	SubjectProjectHistory = "project.history"
+	SubjectTaskHistory    = "task.history"
+)
+
+// legacySubjectProjectDeleted je raniji naziv za SubjectProjectDeleted. Više
+// se ne šalje, ali poruke sa njim mogu još da budu u stream-u.
+const legacySubjectProjectDeleted = "project.removed2"
@@ -0,0 +1,93 @@
+package contracts
+
+import "errors"
+
+// TaskMember opisuje korisnika i zadatak na koji se poruka odnosi.
+type TaskMember struct {
+	Meta
+	UserID    string `json:"userId"`
+	TaskID    string `json:"taskId"`
+	ProjectID string `json:"projectId"`
+	TaskName  string `json:"taskName"`
+}
+
+func (m *TaskMember) Validate() error {
+	if m.UserID == "" {
+		return errors.New("userId is required")
+	}
+	if m.TaskID == "" {
+		return errors.New("taskId is required")
+	}
+	return nil
+}
+
+// TaskMemberAdded se šalje kada je korisnik dodat na zadatak.
+type TaskMemberAdded struct {
+	TaskMember
+}
+
+func (*TaskMemberAdded) Subject() string    { return SubjectTaskMemberAdded }
+func (*TaskMemberAdded) SchemaVersion() int { return 1 }
+
+// TaskMemberRemoved se šalje kada je korisnik uklonjen sa zadatka.
+type TaskMemberRemoved struct {
+	TaskMember
+}
+
+func (*TaskMemberRemoved) Subject() string    { return SubjectTaskMemberRemoved }
+func (*TaskMemberRemoved) SchemaVersion() int { return 1 }
+
+// TaskMembers opisuje zadatak i sve njegove članove koje poruka obaveštava.
+type TaskMembers struct {
+	Meta
+	TaskID    string   `json:"taskId"`
+	ProjectID string   `json:"projectId"`
+	TaskName  string   `json:"taskName"`
+	MemberIDs []string `json:"memberIds"`
+}
+
+func (m *TaskMembers) Validate() error {
+	if m.TaskID == "" {
+		return errors.New("taskId is required")
+	}
+	for _, id := range m.MemberIDs {
+		if id == "" {
+			return errors.New("memberIds must not contain empty IDs")
+		}
+	}
+	return nil
+}
+
+// TaskStatusChanged se šalje članovima kada zadatak promeni status.
+type TaskStatusChanged struct {
+	TaskMembers
+	TaskStatus string `json:"taskStatus"`
+}
+
+func (*TaskStatusChanged) Subject() string    { return SubjectTaskStatusChanged }
+func (*TaskStatusChanged) SchemaVersion() int { return 1 }
+
+func (m *TaskStatusChanged) Validate() error {
+	if m.TaskStatus == "" {
+		return errors.New("taskStatus is required")
+	}
+	return m.TaskMembers.Validate()
+}
+
+// TaskDependenciesReady se šalje članovima kada su sve zavisnosti zadatka završene.
+type TaskDependenciesReady struct {
+	TaskMembers
+}
+
+func (*TaskDependenciesReady) Subject() string    { return SubjectTaskDependenciesReady }
+func (*TaskDependenciesReady) SchemaVersion() int { return 1 }
+
+// TaskAutomationNotified nosi obaveštenje koje šalje pravilo automatizacije.
+type TaskAutomationNotified struct {
+	TaskMembers
+	RuleName string `json:"ruleName"`
+	Message  string `json:"message"`
+}
+
+func (*TaskAutomationNotified) Subject() string    { return SubjectTaskAutomationNotified }
+func (*TaskAutomationNotified) SchemaVersion() int { return 1 }
@@ -3,7 +3,9 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"event_sourcing/models"
+	"event_sourcing/projections"
 	"event_sourcing/repository"
 	"fmt"
 	"github.com/golang-jwt/jwt"
@@ -11,7 +13,9 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type KeyAccount struct{}
@@ -20,13 +24,14 @@ type KeyRole struct{}
 
 // EventHandler processes events for both HTTP and internal event processing.
 type EventHandler struct {
-	logger *log.Logger
-	repo   *repository.ESDBClient
+	logger    *log.Logger
+	repo      *repository.ESDBClient
+	projector *projections.Projector
 }
 
-// NewEventHandler creates a new EventHandler with a given repository.
-func NewEventHandler(repo *repository.ESDBClient, logger *log.Logger) *EventHandler {
-	return &EventHandler{logger: logger, repo: repo}
+// NewEventHandler creates a new EventHandler with a given repository and projections.
+func NewEventHandler(repo *repository.ESDBClient, projector *projections.Projector, logger *log.Logger) *EventHandler {
+	return &EventHandler{logger: logger, repo: repo, projector: projector}
 }
 
 // ProcessEventHandler will handle HTTP requests to process events (POST)
@@ -34,48 +39,233 @@ func (h *EventHandler) ProcessEventHandler(w http.ResponseWriter, r *http.Reques
 	var event model.Event
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&event); err != nil {
-		http.Error(w, "Failed to decode event data", http.StatusBadRequest)
+		h.writeEventError(w, err, "Failed to decode event data", http.StatusBadRequest)
 		return
 	}
 
-	message, err := h.processEvent(event)
+	message, err := h.processEvent(event, r.Header.Get(repository.IdempotencyKeyHeader))
 	if err != nil {
-		http.Error(w, "Failed to process event", http.StatusInternalServerError)
+		h.writeEventError(w, err, "Failed to process event", http.StatusInternalServerError)
 		return
 	}
 
-	if message != "" {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(message))
-	} else {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(message))
+}
+
+// writeEventError maps schema and validation errors to 400 and everything else to the fallback.
+func (h *EventHandler) writeEventError(w http.ResponseWriter, err error, fallback string, fallbackStatus int) {
+	switch {
+	case errors.Is(err, model.ErrUnknownEventType):
 		http.Error(w, "Event type not handled", http.StatusBadRequest)
+	case errors.Is(err, model.ErrUnsupportedVersion), errors.Is(err, model.ErrInvalidEvent):
+		h.logger.Println("Rejected event:", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fallback, fallbackStatus)
 	}
 }
 
-// GetEventsHandler will handle HTTP requests to get events for a specific project (GET)
+// GetEventsHandler returns every event of a project, or a page of them when from, limit or to is given (GET)
 func (h *EventHandler) GetEventsHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract the projectID variable from the URL
-	vars := mux.Vars(r)
-	projectID := vars["projectID"]
+	projectID := mux.Vars(r)["projectID"]
+	if projectID == "" {
+		http.Error(w, "Missing projectID parameter", http.StatusBadRequest)
+		return
+	}
+
+	// Without paging parameters the whole stream is returned as an array, as before paging existed
+	if !pageRequested(r) {
+		events, err := h.repo.GetAllEventsByProjectID(projectID)
+		if err != nil {
+			http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+			return
+		}
+		if events == nil {
+			events = []model.Event{}
+		}
+		writeJSON(w, events)
+		return
+	}
+
+	from, count, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Fetch a page of events for the given project
+	page, err := h.repo.GetEventsByProjectID(projectID, from, count)
+	if err != nil {
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, page)
+}
+
+// GetTaskEventsHandler will handle HTTP requests to get a page of events for a specific task (GET)
+func (h *EventHandler) GetTaskEventsHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["taskID"]
+	if taskID == "" {
+		http.Error(w, "Missing taskID parameter", http.StatusBadRequest)
+		return
+	}
+
+	from, count, err := parsePageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := h.repo.GetEventsByTaskID(taskID, from, count)
+	if err != nil {
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, page)
+}
+
+// GetTaskHistoryHandler returns the change history of a task built from the task history projection (GET)
+func (h *EventHandler) GetTaskHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["taskID"]
+	if taskID == "" {
+		http.Error(w, "Missing taskID parameter", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, projections.Timeline(h.projector.TaskHistory(taskID)))
+}
+
+// GetTaskStateHandler reconstructs a task as of the "at" query parameter, defaulting to now (GET)
+func (h *EventHandler) GetTaskStateHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["taskID"]
+	if taskID == "" {
+		http.Error(w, "Missing taskID parameter", http.StatusBadRequest)
+		return
+	}
+
+	at, err := parseAt(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.repo.GetAllEventsByTaskID(taskID)
+	if err != nil {
+		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
+		return
+	}
+
+	task := projections.TaskAt(taskID, events, at)
+	if task == nil {
+		http.Error(w, fmt.Sprintf("Task %s did not exist at %s", taskID, at.Format(time.RFC3339)), http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, task)
+}
+
+// GetBoardStateHandler reconstructs a project board as of the "at" query parameter, defaulting to now (GET)
+func (h *EventHandler) GetBoardStateHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["projectID"]
 	if projectID == "" {
 		http.Error(w, "Missing projectID parameter", http.StatusBadRequest)
 		return
 	}
 
-	// Fetch events for the given project
-	events, err := h.repo.GetEventsByProjectID(projectID)
+	at, err := parseAt(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.repo.GetAllEventsByProjectID(projectID)
 	if err != nil {
 		http.Error(w, "Failed to retrieve events", http.StatusInternalServerError)
 		return
 	}
 
-	// Respond with a JSON array of events
+	writeJSON(w, projections.BoardAt(projectID, events, at))
+}
+
+// parseAt reads the "at" query parameter as an RFC 3339 timestamp, in UTC
+// like the event times it is compared with.
+func parseAt(r *http.Request) (time.Time, error) {
+	v := r.URL.Query().Get("at")
+	if v == "" {
+		return time.Now().UTC(), nil
+	}
+	at, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid at parameter, expected RFC 3339 timestamp: %s", v)
+	}
+	return at.UTC(), nil
+}
+
+// GetMemberTimelineHandler returns the member timeline projection (GET)
+func (h *EventHandler) GetMemberTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	memberID := mux.Vars(r)["memberID"]
+	if memberID == "" {
+		http.Error(w, "Missing memberID parameter", http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, h.projector.MemberTimeline(memberID))
+}
+
+// parsePageParams reads the from, to and limit query parameters. from and to
+// are inclusive stream positions; limit caps the page size.
+// pageRequested reports whether the request asks for an EventPage through any of
+// the from, limit or to parameters.
+func pageRequested(r *http.Request) bool {
+	query := r.URL.Query()
+	return query.Has("from") || query.Has("limit") || query.Has("to")
+}
+
+func parsePageParams(r *http.Request) (from uint64, count uint64, err error) {
+	query := r.URL.Query()
+
+	if v := query.Get("from"); v != "" {
+		if from, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid from parameter: %s", v)
+		}
+	}
+
+	var limit uint64
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid limit parameter: %s", v)
+		}
+	}
+	count = repository.ClampPageSize(limit)
+
+	if v := query.Get("to"); v != "" {
+		to, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid to parameter: %s", v)
+		}
+		if to < from {
+			return 0, 0, fmt.Errorf("to (%d) must not be before from (%d)", to, from)
+		}
+		if window := to - from + 1; window < count {
+			count = window
+		}
+	}
+
+	return from, count, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(events); err != nil {
-		http.Error(w, "Failed to encode events", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to encode response: %v", err)
 	}
 }
+
 func (h *EventHandler) GetAllEventsHandler(w http.ResponseWriter, r *http.Request) {
 	events, err := h.repo.GetAllEvents()
 	if err != nil {
@@ -101,75 +291,33 @@ func (h *EventHandler) GetAllEventsHandler(w http.ResponseWriter, r *http.Reques
 	}
 }
 
-// Optional: Filter events by ProjectID in memory.
-func FilterEventsByProjectID(events []model.Event, projectID string) []model.Event {
-	var filtered []model.Event
-	for _, event := range events {
-		if event.ProjectID == projectID {
-			filtered = append(filtered, event)
-		}
-	}
-	return filtered
+// eventMessages holds the response sent back for every stored event type.
+var eventMessages = map[model.EventType]string{
+	model.MemberAddedType:       "Successfully added member to project",
+	model.MemberRemovedType:     "Successfully removed member from project",
+	model.MemberAddedTaskType:   "Successfully added member to task",
+	model.MemberRemovedTaskType: "Successfully removed member from task",
+	model.TaskCreatedType:       "Successfully created task",
+	model.TaskStatusChangedType: "Successfully changed task status",
+	model.DocumentAddedType:     "Successfully added document",
+	model.ProjectCreatedType:    "Successfully created project",
+	model.TaskUpdatedType:       "Successfully updated task",
+	model.TaskDeletedType:       "Successfully deleted task",
 }
 
-func (h *EventHandler) processEvent(event model.Event) (string, error) {
-	var message string
-	switch event.Type {
-	case model.MemberAddedType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		message = "Successfully added member to project"
-	case model.MemberRemovedType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		message = "Successfully removed member from project"
-	case model.MemberAddedTaskType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		message = "Successfully added member to task"
-	case model.MemberRemovedTaskType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		message = "Successfully removed member from task"
-	case model.TaskCreatedType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		message = "Successfully created task"
-	case model.TaskStatusChangedType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		message = "Successfully changed task status"
-	case model.DocumentAddedType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		message = "Successfully added document"
-	case model.ProjectCreatedType:
-		if err := h.repo.StoreEvent(event); err != nil {
-			log.Printf("Failed to store event: %v", err)
-			return "", err
-		}
-		// The message will indicate that the project was created successfully
-		message = "Successfully created project"
-	default:
-		log.Printf("Unhandled event type: %s\n", event.Type)
-		return "", nil
+// processEvent validates an already decoded (and upcast) event and stores it.
+// Retries carrying the same idempotency key are stored only once.
+func (h *EventHandler) processEvent(event model.Event, idempotencyKey string) (string, error) {
+	if err := event.Validate(); err != nil {
+		return "", err
+	}
+
+	if err := h.repo.StoreEvent(event, idempotencyKey); err != nil {
+		log.Printf("Failed to store event: %v", err)
+		return "", err
 	}
 
-	return message, nil
+	return eventMessages[event.Type], nil
 }
 
 func (uh *EventHandler) MiddlewareExtractUserFromHeader(next func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
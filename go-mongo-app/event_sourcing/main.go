@@ -2,6 +2,7 @@ package main
 
 import (
 	"event_sourcing/handlers"
+	"event_sourcing/projections"
 	"event_sourcing/repository"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -50,18 +51,24 @@ func main() {
 	}
 	logger.Println("ESDBClient initialized successfully.")
 
-	// Delete all events before starting the server
-	err = esdbClient.DeleteAllEvents()
-	if err != nil {
-		logger.Fatalf("Error deleting events: %v", err)
-	} else {
-		logger.Println("All events have been deleted successfully.")
+	// Projekcije se prvo grade iz sačuvanih događaja, a zatim iz persistent subscription-a
+	projector := projections.NewProjector()
+	if err := esdbClient.ReplayEvents(projector.Apply); err != nil {
+		logger.Fatalf("Error rebuilding projections: %v", err)
 	}
+	go esdbClient.ProcessEvents(projector.Apply)
+
 	// Konfigurisanje HTTP ruta
-	eventHandler := handlers.NewEventHandler(esdbClient, logger)
+	eventHandler := handlers.NewEventHandler(esdbClient, projector, logger)
 	r := mux.NewRouter()
 	r.HandleFunc("/event/append", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.ProcessEventHandler, "Manager", "Member"))).Methods("POST")
 	r.HandleFunc("/events", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.GetAllEventsHandler, "Manager", "Member"))).Methods("GET") // Sada je kraće
+	r.HandleFunc("/events/project/{projectID}", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.GetEventsHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/events/task/{taskID}", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.GetTaskEventsHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/events/task/{taskID}/history", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.GetTaskHistoryHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/events/task/{taskID}/state", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.GetTaskStateHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/events/project/{projectID}/board", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.GetBoardStateHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/events/member/{memberID}/timeline", eventHandler.MiddlewareExtractUserFromHeader(eventHandler.RoleRequired(eventHandler.GetMemberTimelineHandler, "Manager", "Member"))).Methods("GET")
 	logger.Println("Routes configured successfully.")
 
 	// CORS konfiguracija
@@ -1,6 +1,9 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // EventType defines the type of event
 type EventType string
@@ -14,13 +17,17 @@ const (
 	TaskStatusChangedType EventType = "Task Status Changed"
 	DocumentAddedType     EventType = "Document Added"
 	ProjectCreatedType    EventType = "Project Created"
+	TaskUpdatedType       EventType = "Task Updated"
+	TaskDeletedType       EventType = "Task Deleted"
 )
 
-// Event represents a generic event with a type and time
+// Event represents a generic event with a type, schema version and time.
+// Event holds the typed payload registered for Type (see schema.go).
 type Event struct {
 	Type      EventType `json:"type"`
+	Version   int       `json:"version"`
 	Time      time.Time `json:"time"`
-	Event     any       `json:"event"`
+	Event     Payload   `json:"event"`
 	ProjectID string    `json:"projectId"`
 }
 
@@ -50,25 +57,52 @@ type MemberRemovedFromTaskEvent struct {
 
 // TaskCreatedEvent represents an event when a new task is created in a project
 type TaskCreatedEvent struct {
+	TaskID      string   `json:"taskId"`
+	ProjectID   string   `json:"projectId"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	DependsOn   []string `json:"dependsOn"`
+	Position    int      `json:"position"`
+}
+
+// TaskUpdatedEvent represents an event when fields of a task other than its status change
+type TaskUpdatedEvent struct {
+	TaskID    string        `json:"taskId"`
+	ProjectID string        `json:"projectId"`
+	Changes   []FieldChange `json:"changes"`
+	MemberID  string        `json:"memberId,omitempty"`
+}
+
+// TaskDeletedEvent represents an event when a task is removed from its project
+type TaskDeletedEvent struct {
 	TaskID    string `json:"taskId"`
 	ProjectID string `json:"projectId"`
+	MemberID  string `json:"memberId,omitempty"`
+}
+
+// FieldChange describes a single edited task field with its JSON encoded old and new values
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from,omitempty"`
+	To    json.RawMessage `json:"to"`
 }
 
 // TaskStatusChangedEvent represents an event when the status of a task changes
 type TaskStatusChangedEvent struct {
-	TaskID         string `json:"taskId"`
-	ProjectID      string `json:"projectId"`
-	PreviousStatus string `json:"previousStatus"`
-	CurrentStatus  string `json:"currentStatus"`
-	MemberID       string `json:"memberId"`
+	TaskID         string   `json:"taskId"`
+	ProjectID      string   `json:"projectId"`
+	PreviousStatus string   `json:"previousStatus"`
+	CurrentStatus  string   `json:"currentStatus"`
+	MemberIDs      []string `json:"memberIds"`
 }
 
 // DocumentAddedEvent represents an event when a document is added to a task
 type DocumentAddedEvent struct {
-	TaskID    string `json:"taskId"`
-	ProjectID string `json:"projectId"`
-	FilePath  string `json:"filePath"`
-	MemberID  string `json:"memberId"`
+	TaskID    string   `json:"taskId"`
+	ProjectID string   `json:"projectId"`
+	FilePaths []string `json:"filePaths"`
+	MemberIDs []string `json:"memberIds"`
 }
 
 // ProjectCreatedEvent represents an event when a new project is created
@@ -78,3 +112,67 @@ type ProjectCreatedEvent struct {
 	ManagerID string    `json:"managerId"`
 	CreatedAt time.Time `json:"createdAt"`
 }
+
+// EventPage is a slice of a stream read starting at From. Next is the position
+// to continue reading from and is omitted once the end of the stream is reached.
+type EventPage struct {
+	Events []RecordedEvent `json:"events"`
+	From   uint64          `json:"from"`
+	Next   *uint64         `json:"next,omitempty"`
+}
+
+// RecordedEvent is an event together with its position in the stream it was read from.
+type RecordedEvent struct {
+	Position uint64 `json:"position"`
+	Event
+}
+
+// TaskID returns the task the event refers to, or an empty string for project-level events.
+func (e Event) TaskID() string {
+	switch p := e.Event.(type) {
+	case *MemberAddedToTaskEvent:
+		return p.TaskID
+	case *MemberRemovedFromTaskEvent:
+		return p.TaskID
+	case *TaskCreatedEvent:
+		return p.TaskID
+	case *TaskStatusChangedEvent:
+		return p.TaskID
+	case *DocumentAddedEvent:
+		return p.TaskID
+	case *TaskUpdatedEvent:
+		return p.TaskID
+	case *TaskDeletedEvent:
+		return p.TaskID
+	}
+	return ""
+}
+
+// MemberIDs returns the members the event is about.
+func (e Event) MemberIDs() []string {
+	switch p := e.Event.(type) {
+	case *MemberAddedToProjectEvent:
+		return []string{p.MemberID}
+	case *MemberRemovedFromProjectEvent:
+		return []string{p.MemberID}
+	case *MemberAddedToTaskEvent:
+		return []string{p.MemberID}
+	case *MemberRemovedFromTaskEvent:
+		return []string{p.MemberID}
+	case *TaskStatusChangedEvent:
+		return p.MemberIDs
+	case *DocumentAddedEvent:
+		return p.MemberIDs
+	case *ProjectCreatedEvent:
+		return []string{p.ManagerID}
+	case *TaskUpdatedEvent:
+		if p.MemberID != "" {
+			return []string{p.MemberID}
+		}
+	case *TaskDeletedEvent:
+		if p.MemberID != "" {
+			return []string{p.MemberID}
+		}
+	}
+	return nil
+}
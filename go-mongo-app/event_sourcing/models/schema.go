@@ -0,0 +1,223 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownEventType   = errors.New("unknown event type")
+	ErrUnsupportedVersion = errors.New("unsupported event schema version")
+	ErrInvalidEvent       = errors.New("invalid event")
+)
+
+// Payload is implemented by every typed event body.
+type Payload interface {
+	Validate() error
+}
+
+// Upcaster migrates a raw payload from one schema version to the next.
+type Upcaster func(payload map[string]any) (map[string]any, error)
+
+// schema describes the current shape of an event type and how to reach it
+// from older versions. upcasters[v] migrates version v to version v+1.
+type schema struct {
+	version    int
+	newPayload func() Payload
+	upcasters  map[int]Upcaster
+}
+
+var schemas = map[EventType]schema{
+	MemberAddedType: {
+		version:    1,
+		newPayload: func() Payload { return &MemberAddedToProjectEvent{} },
+	},
+	MemberRemovedType: {
+		version:    1,
+		newPayload: func() Payload { return &MemberRemovedFromProjectEvent{} },
+	},
+	MemberAddedTaskType: {
+		version:    1,
+		newPayload: func() Payload { return &MemberAddedToTaskEvent{} },
+	},
+	MemberRemovedTaskType: {
+		version:    1,
+		newPayload: func() Payload { return &MemberRemovedFromTaskEvent{} },
+	},
+	TaskCreatedType: {
+		// version 2 added name, description, status, dependsOn and position
+		version:    2,
+		newPayload: func() Payload { return &TaskCreatedEvent{} },
+	},
+	TaskUpdatedType: {
+		version:    1,
+		newPayload: func() Payload { return &TaskUpdatedEvent{} },
+	},
+	TaskDeletedType: {
+		version:    1,
+		newPayload: func() Payload { return &TaskDeletedEvent{} },
+	},
+	TaskStatusChangedType: {
+		version:    2,
+		newPayload: func() Payload { return &TaskStatusChangedEvent{} },
+		upcasters: map[int]Upcaster{
+			1: renameToList("memberId", "memberIds"),
+		},
+	},
+	DocumentAddedType: {
+		version:    2,
+		newPayload: func() Payload { return &DocumentAddedEvent{} },
+		upcasters: map[int]Upcaster{
+			1: chain(renameToList("filePath", "filePaths"), renameToList("memberId", "memberIds")),
+		},
+	},
+	ProjectCreatedType: {
+		version:    2,
+		newPayload: func() Payload { return &ProjectCreatedEvent{} },
+		upcasters: map[int]Upcaster{
+			1: rename("title", "name"),
+		},
+	},
+}
+
+// CurrentVersion returns the schema version new events of the given type are written with.
+func CurrentVersion(t EventType) (int, bool) {
+	s, ok := schemas[t]
+	return s.version, ok
+}
+
+// DecodePayload upcasts a raw payload written with the given version to the
+// current schema and decodes it into the typed struct for the event type.
+// Events written before versioning was introduced carry no version and are treated as version 1.
+func DecodePayload(t EventType, version int, raw json.RawMessage) (Payload, int, error) {
+	s, ok := schemas[t]
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %q", ErrUnknownEventType, t)
+	}
+	if version == 0 {
+		version = 1
+	}
+	if version > s.version {
+		return nil, 0, fmt.Errorf("%w: %q version %d (latest is %d)", ErrUnsupportedVersion, t, version, s.version)
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, 0, fmt.Errorf("%w: missing event payload", ErrInvalidEvent)
+	}
+
+	if version < s.version {
+		var fields map[string]any
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		for ; version < s.version; version++ {
+			upcast, ok := s.upcasters[version]
+			if !ok {
+				continue
+			}
+			var err error
+			if fields, err = upcast(fields); err != nil {
+				return nil, 0, fmt.Errorf("upcasting %q from version %d: %w", t, version, err)
+			}
+		}
+		upcasted, err := json.Marshal(fields)
+		if err != nil {
+			return nil, 0, err
+		}
+		raw = upcasted
+	}
+
+	payload := s.newPayload()
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	return payload, version, nil
+}
+
+// UnmarshalJSON decodes the envelope and upcasts the payload to its typed, current form.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type      EventType       `json:"type"`
+		Version   int             `json:"version"`
+		Time      time.Time       `json:"time"`
+		Event     json.RawMessage `json:"event"`
+		ProjectID string          `json:"projectId"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	payload, version, err := DecodePayload(raw.Type, raw.Version, raw.Event)
+	if err != nil {
+		return err
+	}
+
+	e.Type = raw.Type
+	e.Version = version
+	e.Time = raw.Time
+	e.Event = payload
+	e.ProjectID = raw.ProjectID
+	return nil
+}
+
+// rename moves a field to a new key, leaving the payload untouched if the new key is already set.
+func rename(from, to string) Upcaster {
+	return func(payload map[string]any) (map[string]any, error) {
+		if v, ok := payload[from]; ok {
+			if _, exists := payload[to]; !exists {
+				payload[to] = v
+			}
+			delete(payload, from)
+		}
+		return payload, nil
+	}
+}
+
+// renameToList moves a field that older producers sent either as a single
+// string or as a list into a list-valued field.
+func renameToList(from, to string) Upcaster {
+	return func(payload map[string]any) (map[string]any, error) {
+		v, ok := payload[from]
+		if !ok {
+			return payload, nil
+		}
+		delete(payload, from)
+		if _, exists := payload[to]; exists {
+			return payload, nil
+		}
+
+		list := []string{}
+		switch value := v.(type) {
+		case nil:
+		case string:
+			if value != "" {
+				list = append(list, value)
+			}
+		case []any:
+			for _, item := range value {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("%w: %q must contain strings, got %T", ErrInvalidEvent, from, item)
+				}
+				list = append(list, s)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q must be a string or a list, got %T", ErrInvalidEvent, from, v)
+		}
+		payload[to] = list
+		return payload, nil
+	}
+}
+
+func chain(upcasters ...Upcaster) Upcaster {
+	return func(payload map[string]any) (map[string]any, error) {
+		var err error
+		for _, upcast := range upcasters {
+			if payload, err = upcast(payload); err != nil {
+				return nil, err
+			}
+		}
+		return payload, nil
+	}
+}
@@ -0,0 +1,166 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodePayload(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		eventType   EventType
+		version     int
+		raw         string
+		want        Payload
+		wantVersion int
+		wantErr     error
+	}{
+		{
+			name:        "status change v1 single member",
+			eventType:   TaskStatusChangedType,
+			version:     1,
+			raw:         `{"taskId":"t1","projectId":"p1","previousStatus":"pending","currentStatus":"done","memberId":"u1"}`,
+			want:        &TaskStatusChangedEvent{TaskID: "t1", ProjectID: "p1", PreviousStatus: "pending", CurrentStatus: "done", MemberIDs: []string{"u1"}},
+			wantVersion: 2,
+		},
+		{
+			name:        "status change without version is v1",
+			eventType:   TaskStatusChangedType,
+			raw:         `{"taskId":"t1","memberId":["u1","u2"]}`,
+			want:        &TaskStatusChangedEvent{TaskID: "t1", MemberIDs: []string{"u1", "u2"}},
+			wantVersion: 2,
+		},
+		{
+			name:        "status change v1 empty member",
+			eventType:   TaskStatusChangedType,
+			version:     1,
+			raw:         `{"taskId":"t1","memberId":""}`,
+			want:        &TaskStatusChangedEvent{TaskID: "t1", MemberIDs: []string{}},
+			wantVersion: 2,
+		},
+		{
+			name:        "status change v1 null member",
+			eventType:   TaskStatusChangedType,
+			version:     1,
+			raw:         `{"taskId":"t1","memberId":null}`,
+			want:        &TaskStatusChangedEvent{TaskID: "t1", MemberIDs: []string{}},
+			wantVersion: 2,
+		},
+		{
+			name:        "status change v1 keeps an existing list",
+			eventType:   TaskStatusChangedType,
+			version:     1,
+			raw:         `{"taskId":"t1","memberId":"old","memberIds":["u1"]}`,
+			want:        &TaskStatusChangedEvent{TaskID: "t1", MemberIDs: []string{"u1"}},
+			wantVersion: 2,
+		},
+		{
+			name:      "status change v1 member of wrong type",
+			eventType: TaskStatusChangedType,
+			version:   1,
+			raw:       `{"taskId":"t1","memberId":42}`,
+			wantErr:   ErrInvalidEvent,
+		},
+		{
+			name:      "status change v1 list with non string",
+			eventType: TaskStatusChangedType,
+			version:   1,
+			raw:       `{"taskId":"t1","memberId":["u1",7]}`,
+			wantErr:   ErrInvalidEvent,
+		},
+		{
+			name:        "status change current version is not upcast",
+			eventType:   TaskStatusChangedType,
+			version:     2,
+			raw:         `{"taskId":"t1","memberIds":["u1"],"memberId":"ignored"}`,
+			want:        &TaskStatusChangedEvent{TaskID: "t1", MemberIDs: []string{"u1"}},
+			wantVersion: 2,
+		},
+		{
+			name:        "document added v1 chains both renames",
+			eventType:   DocumentAddedType,
+			version:     1,
+			raw:         `{"taskId":"t1","projectId":"p1","filePath":"/docs/a.pdf","memberId":"u1"}`,
+			want:        &DocumentAddedEvent{TaskID: "t1", ProjectID: "p1", FilePaths: []string{"/docs/a.pdf"}, MemberIDs: []string{"u1"}},
+			wantVersion: 2,
+		},
+		{
+			name:        "project created v1 title becomes name",
+			eventType:   ProjectCreatedType,
+			version:     1,
+			raw:         `{"projectId":"p1","title":"Apollo","managerId":"m1","createdAt":"2024-03-01T09:30:00Z"}`,
+			want:        &ProjectCreatedEvent{ProjectID: "p1", Name: "Apollo", ManagerID: "m1", CreatedAt: created},
+			wantVersion: 2,
+		},
+		{
+			name:        "project created v1 keeps an existing name",
+			eventType:   ProjectCreatedType,
+			version:     1,
+			raw:         `{"projectId":"p1","title":"Old","name":"Apollo"}`,
+			want:        &ProjectCreatedEvent{ProjectID: "p1", Name: "Apollo"},
+			wantVersion: 2,
+		},
+		{
+			name:        "task created v1 has no upcaster",
+			eventType:   TaskCreatedType,
+			version:     1,
+			raw:         `{"taskId":"t1","projectId":"p1"}`,
+			want:        &TaskCreatedEvent{TaskID: "t1", ProjectID: "p1"},
+			wantVersion: 2,
+		},
+		{
+			name:      "newer version than supported",
+			eventType: ProjectCreatedType,
+			version:   3,
+			raw:       `{"projectId":"p1"}`,
+			wantErr:   ErrUnsupportedVersion,
+		},
+		{
+			name:      "unknown event type",
+			eventType: "Project Archived",
+			version:   1,
+			raw:       `{"projectId":"p1"}`,
+			wantErr:   ErrUnknownEventType,
+		},
+		{
+			name:      "missing payload",
+			eventType: TaskDeletedType,
+			version:   1,
+			raw:       `null`,
+			wantErr:   ErrInvalidEvent,
+		},
+		{
+			name:      "malformed payload",
+			eventType: TaskStatusChangedType,
+			version:   1,
+			raw:       `{"taskId":`,
+			wantErr:   ErrInvalidEvent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, version, err := DecodePayload(tt.eventType, tt.version, json.RawMessage(tt.raw))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if !reflect.DeepEqual(payload, tt.want) {
+				t.Errorf("payload = %+v, want %+v", payload, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package model
+
+import "fmt"
+
+var allowedTaskStatuses = map[string]bool{
+	"pending":          true,
+	"work in progress": true,
+	"done":             true,
+}
+
+// projectScoped is implemented by payloads that repeat the envelope's project ID.
+type projectScoped interface {
+	projectID() string
+}
+
+// Validate checks the envelope and the typed payload of an event before it is stored.
+func (e Event) Validate() error {
+	current, ok := CurrentVersion(e.Type)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownEventType, e.Type)
+	}
+	if e.Version != current {
+		return fmt.Errorf("%w: %q must be upcast to version %d, got %d", ErrUnsupportedVersion, e.Type, current, e.Version)
+	}
+	if e.Time.IsZero() {
+		return invalid("time is required")
+	}
+	if e.ProjectID == "" {
+		return invalid("projectId is required")
+	}
+	if e.Event == nil {
+		return invalid("event payload is required")
+	}
+	if scoped, ok := e.Event.(projectScoped); ok && scoped.projectID() != e.ProjectID {
+		return invalid("payload projectId %q does not match event projectId %q", scoped.projectID(), e.ProjectID)
+	}
+	return e.Event.Validate()
+}
+
+func (p *MemberAddedToProjectEvent) Validate() error {
+	return required("memberId", p.MemberID, "projectId", p.ProjectID)
+}
+
+func (p *MemberAddedToProjectEvent) projectID() string { return p.ProjectID }
+
+func (p *MemberRemovedFromProjectEvent) Validate() error {
+	return required("memberId", p.MemberID, "projectId", p.ProjectID)
+}
+
+func (p *MemberRemovedFromProjectEvent) projectID() string { return p.ProjectID }
+
+func (p *MemberAddedToTaskEvent) Validate() error {
+	return required("memberId", p.MemberID, "taskId", p.TaskID)
+}
+
+func (p *MemberRemovedFromTaskEvent) Validate() error {
+	return required("memberId", p.MemberID, "taskId", p.TaskID)
+}
+
+func (p *TaskCreatedEvent) Validate() error {
+	return required("taskId", p.TaskID, "projectId", p.ProjectID)
+}
+
+func (p *TaskCreatedEvent) projectID() string { return p.ProjectID }
+
+func (p *TaskUpdatedEvent) Validate() error {
+	if err := required("taskId", p.TaskID, "projectId", p.ProjectID); err != nil {
+		return err
+	}
+	if len(p.Changes) == 0 {
+		return invalid("changes must contain at least one field")
+	}
+	for _, change := range p.Changes {
+		if change.Field == "" {
+			return invalid("every change needs a field name")
+		}
+		if len(change.To) == 0 {
+			return invalid("change of %s is missing the new value", change.Field)
+		}
+	}
+	return nil
+}
+
+func (p *TaskUpdatedEvent) projectID() string { return p.ProjectID }
+
+func (p *TaskDeletedEvent) Validate() error {
+	return required("taskId", p.TaskID, "projectId", p.ProjectID)
+}
+
+func (p *TaskDeletedEvent) projectID() string { return p.ProjectID }
+
+func (p *TaskStatusChangedEvent) Validate() error {
+	if err := required("taskId", p.TaskID, "projectId", p.ProjectID); err != nil {
+		return err
+	}
+	if !allowedTaskStatuses[p.CurrentStatus] {
+		return invalid("currentStatus %q is not a valid task status", p.CurrentStatus)
+	}
+	return nil
+}
+
+func (p *TaskStatusChangedEvent) projectID() string { return p.ProjectID }
+
+func (p *DocumentAddedEvent) Validate() error {
+	if err := required("taskId", p.TaskID, "projectId", p.ProjectID); err != nil {
+		return err
+	}
+	if len(p.FilePaths) == 0 {
+		return invalid("filePaths must contain at least one path")
+	}
+	return nil
+}
+
+func (p *DocumentAddedEvent) projectID() string { return p.ProjectID }
+
+func (p *ProjectCreatedEvent) Validate() error {
+	return required("projectId", p.ProjectID, "managerId", p.ManagerID)
+}
+
+func (p *ProjectCreatedEvent) projectID() string { return p.ProjectID }
+
+// required takes name/value pairs and reports the first empty value.
+func required(pairs ...string) error {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			return invalid("%s is required", pairs[i])
+		}
+	}
+	return nil
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidEvent, fmt.Sprintf(format, args...))
+}
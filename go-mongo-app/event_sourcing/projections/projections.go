@@ -0,0 +1,84 @@
+package projections
+
+import (
+	model "event_sourcing/models"
+	"sort"
+	"sync"
+)
+
+// Projector keeps read models derived from the event stream. It is rebuilt
+// with ESDBClient.ReplayEvents whenever the service starts and then fed by
+// ESDBClient.ProcessEvents.
+type Projector struct {
+	mu             sync.RWMutex
+	applied        map[string]bool
+	taskHistory    map[string][]model.Event
+	memberTimeline map[string][]model.Event
+}
+
+// NewProjector creates an empty Projector.
+func NewProjector() *Projector {
+	return &Projector{
+		applied:        make(map[string]bool),
+		taskHistory:    make(map[string][]model.Event),
+		memberTimeline: make(map[string][]model.Event),
+	}
+}
+
+// Apply folds a single event into every projection. An event delivered both by
+// the replay and by the subscription is applied once.
+func (p *Projector) Apply(id string, event model.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.applied[id] {
+		return nil
+	}
+	p.applied[id] = true
+
+	if taskID := event.TaskID(); taskID != "" {
+		p.taskHistory[taskID] = insertByTime(p.taskHistory[taskID], event)
+	}
+
+	seen := make(map[string]bool)
+	for _, memberID := range event.MemberIDs() {
+		if memberID == "" || seen[memberID] {
+			continue
+		}
+		seen[memberID] = true
+		p.memberTimeline[memberID] = insertByTime(p.memberTimeline[memberID], event)
+	}
+	return nil
+}
+
+// TaskHistory returns every event of a task, oldest first.
+func (p *Projector) TaskHistory(taskID string) []model.Event {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return copyEvents(p.taskHistory[taskID])
+}
+
+// MemberTimeline returns every event a member took part in, oldest first.
+func (p *Projector) MemberTimeline(memberID string) []model.Event {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return copyEvents(p.memberTimeline[memberID])
+}
+
+// insertByTime keeps the slice ordered by event time; events usually arrive in
+// order so this is an append in the common case.
+func insertByTime(events []model.Event, event model.Event) []model.Event {
+	i := sort.Search(len(events), func(i int) bool {
+		return events[i].Time.After(event.Time)
+	})
+	events = append(events, model.Event{})
+	copy(events[i+1:], events[i:])
+	events[i] = event
+	return events
+}
+
+func copyEvents(events []model.Event) []model.Event {
+	out := make([]model.Event, len(events))
+	copy(out, events)
+	return out
+}
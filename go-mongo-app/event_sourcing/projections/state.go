@@ -0,0 +1,262 @@
+package projections
+
+import (
+	"encoding/json"
+	model "event_sourcing/models"
+	"sort"
+	"time"
+)
+
+// TaskState is a task as it looked at a point in time, rebuilt from its events.
+type TaskState struct {
+	TaskID      string    `json:"taskId"`
+	ProjectID   string    `json:"projectId"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Assignees   []string  `json:"assignees"`
+	Documents   []string  `json:"documents"`
+	DependsOn   []string  `json:"dependsOn"`
+	Position    int       `json:"position"`
+	Ready       bool      `json:"ready,omitempty"`
+	AtRisk      bool      `json:"atRisk,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// BoardState is a project board as it looked at a point in time.
+// Columns maps every status to the IDs of its tasks ordered by position.
+type BoardState struct {
+	ProjectID string              `json:"projectId"`
+	Name      string              `json:"name"`
+	ManagerID string              `json:"managerId"`
+	Members   []string            `json:"members"`
+	At        time.Time           `json:"at"`
+	Tasks     []*TaskState        `json:"tasks"`
+	Columns   map[string][]string `json:"columns"`
+}
+
+// TaskChange is a single entry of a task's change history.
+type TaskChange struct {
+	Time      time.Time       `json:"time"`
+	Type      model.EventType `json:"type"`
+	Field     string          `json:"field"`
+	From      any             `json:"from,omitempty"`
+	To        any             `json:"to,omitempty"`
+	MemberIDs []string        `json:"memberIds,omitempty"`
+}
+
+// TaskAt folds the events of a task up to and including at. It returns nil
+// when the task did not exist yet or was already deleted.
+func TaskAt(taskID string, events []model.Event, at time.Time) *TaskState {
+	var task *TaskState
+	for _, event := range sortedUntil(events, at) {
+		if event.TaskID() != taskID {
+			continue
+		}
+		if _, deleted := event.Event.(*model.TaskDeletedEvent); deleted {
+			task = nil
+			continue
+		}
+		task = applyToTask(task, event)
+	}
+	return task
+}
+
+// BoardAt folds the events of a project up to and including at.
+func BoardAt(projectID string, events []model.Event, at time.Time) *BoardState {
+	board := &BoardState{
+		ProjectID: projectID,
+		Members:   []string{},
+		At:        at,
+		Tasks:     []*TaskState{},
+		Columns:   map[string][]string{},
+	}
+	tasks := make(map[string]*TaskState)
+
+	for _, event := range sortedUntil(events, at) {
+		switch p := event.Event.(type) {
+		case *model.ProjectCreatedEvent:
+			board.Name = p.Name
+			board.ManagerID = p.ManagerID
+			board.Members = addUnique(board.Members, p.ManagerID)
+		case *model.MemberAddedToProjectEvent:
+			board.Members = addUnique(board.Members, p.MemberID)
+		case *model.MemberRemovedFromProjectEvent:
+			board.Members = remove(board.Members, p.MemberID)
+		case *model.TaskDeletedEvent:
+			// Deleting a task also drops it from the dependencies of the others
+			delete(tasks, p.TaskID)
+			for _, task := range tasks {
+				task.DependsOn = remove(task.DependsOn, p.TaskID)
+			}
+		default:
+			if taskID := event.TaskID(); taskID != "" {
+				tasks[taskID] = applyToTask(tasks[taskID], event)
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		board.Tasks = append(board.Tasks, task)
+	}
+	sort.Slice(board.Tasks, func(i, j int) bool {
+		if board.Tasks[i].Position != board.Tasks[j].Position {
+			return board.Tasks[i].Position < board.Tasks[j].Position
+		}
+		return board.Tasks[i].TaskID < board.Tasks[j].TaskID
+	})
+	for _, task := range board.Tasks {
+		board.Columns[task.Status] = append(board.Columns[task.Status], task.TaskID)
+	}
+	return board
+}
+
+// Timeline turns the events of a task into its change history, oldest first.
+func Timeline(events []model.Event) []TaskChange {
+	changes := []TaskChange{}
+	name := ""
+	for _, event := range sortedUntil(events, time.Time{}) {
+		change := TaskChange{Time: event.Time, Type: event.Type, MemberIDs: event.MemberIDs()}
+		switch p := event.Event.(type) {
+		case *model.TaskCreatedEvent:
+			name = p.Name
+			change.Field = "task"
+			change.To = p.Name
+		case *model.TaskDeletedEvent:
+			change.Field = "task"
+			change.From = name
+		case *model.TaskStatusChangedEvent:
+			change.Field = "status"
+			change.From = p.PreviousStatus
+			change.To = p.CurrentStatus
+		case *model.MemberAddedToTaskEvent:
+			change.Field = "assignees"
+			change.To = p.MemberID
+		case *model.MemberRemovedFromTaskEvent:
+			change.Field = "assignees"
+			change.From = p.MemberID
+		case *model.DocumentAddedEvent:
+			change.Field = "documents"
+			change.To = p.FilePaths
+		case *model.TaskUpdatedEvent:
+			for _, field := range p.Changes {
+				if field.Field == "name" {
+					_ = json.Unmarshal(field.To, &name)
+				}
+				changes = append(changes, TaskChange{
+					Time:      event.Time,
+					Type:      event.Type,
+					Field:     field.Field,
+					From:      field.From,
+					To:        field.To,
+					MemberIDs: change.MemberIDs,
+				})
+			}
+			continue
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func applyToTask(task *TaskState, event model.Event) *TaskState {
+	if task == nil {
+		task = &TaskState{
+			TaskID:    event.TaskID(),
+			ProjectID: event.ProjectID,
+			Assignees: []string{},
+			Documents: []string{},
+			DependsOn: []string{},
+			CreatedAt: event.Time,
+		}
+	}
+	task.UpdatedAt = event.Time
+
+	switch p := event.Event.(type) {
+	case *model.TaskCreatedEvent:
+		task.Name = p.Name
+		task.Description = p.Description
+		task.Status = p.Status
+		task.Position = p.Position
+		task.DependsOn = append(task.DependsOn[:0], p.DependsOn...)
+		task.CreatedAt = event.Time
+	case *model.TaskStatusChangedEvent:
+		task.Status = p.CurrentStatus
+	case *model.MemberAddedToTaskEvent:
+		task.Assignees = addUnique(task.Assignees, p.MemberID)
+	case *model.MemberRemovedFromTaskEvent:
+		task.Assignees = remove(task.Assignees, p.MemberID)
+	case *model.DocumentAddedEvent:
+		for _, path := range p.FilePaths {
+			task.Documents = addUnique(task.Documents, path)
+		}
+	case *model.TaskUpdatedEvent:
+		for _, change := range p.Changes {
+			applyFieldChange(task, change)
+		}
+	}
+	return task
+}
+
+// applyFieldChange sets a single edited field. Unknown fields and values that
+// do not decode are ignored so older readers keep working when new fields appear.
+func applyFieldChange(task *TaskState, change model.FieldChange) {
+	var target any
+	switch change.Field {
+	case "name":
+		target = &task.Name
+	case "description":
+		target = &task.Description
+	case "position":
+		target = &task.Position
+	case "dependsOn":
+		target = &task.DependsOn
+	case "ready":
+		target = &task.Ready
+	case "atRisk":
+		target = &task.AtRisk
+	default:
+		return
+	}
+	_ = json.Unmarshal(change.To, target)
+}
+
+// sortedUntil returns the events that happened at or before at, oldest first.
+// A zero at keeps every event.
+func sortedUntil(events []model.Event, at time.Time) []model.Event {
+	out := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		if at.IsZero() || !event.Time.After(at) {
+			out = append(out, event)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Time.Before(out[j].Time)
+	})
+	return out
+}
+
+func addUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func remove(list []string, value string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	return out
+}
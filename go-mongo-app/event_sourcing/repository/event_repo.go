@@ -6,6 +6,7 @@ import (
 	"errors"
 	model "event_sourcing/models"
 	"fmt"
+	"io"
 	"log"
 	"time"
 
@@ -25,11 +26,18 @@ type ESDBClient struct {
 }
 
 // NewESDBClient initializes a new ESDBClient.
+//
+// The persistent subscription group is created once and then resumes from its
+// checkpoint, so restarts do not redeliver what was already acknowledged. Only
+// domain streams (project-*) are delivered; task streams hold links only.
 func NewESDBClient(client *esdb.Client, group string) (*ESDBClient, error) {
 	opts := esdb.PersistentAllSubscriptionOptions{
 		From: esdb.Start{},
+		Filter: &esdb.SubscriptionFilter{
+			Type:     esdb.StreamFilterType,
+			Prefixes: []string{projectStreamPrefix},
+		},
 	}
-	// Attempt to create the subscription
 	err := client.CreatePersistentSubscriptionAll(context.Background(), group, opts)
 	if err != nil {
 		// persistent subscription group already exists
@@ -49,8 +57,14 @@ func NewESDBClient(client *esdb.Client, group string) (*ESDBClient, error) {
 	return esdbClient, nil
 }
 
-func (e *ESDBClient) StoreEvent(event model.Event) error {
-	id, err := uuid.NewV4()
+// StoreEvent appends the event to its project stream and, for task events,
+// links it into the task stream so both can be read independently.
+//
+// A non-empty idempotencyKey makes the event ID deterministic, so a producer
+// retrying the same append is deduplicated by EventStoreDB instead of being
+// stored twice.
+func (e *ESDBClient) StoreEvent(event model.Event, idempotencyKey string) error {
+	id, err := eventID(idempotencyKey, "")
 	if err != nil {
 		return err
 	}
@@ -59,8 +73,8 @@ func (e *ESDBClient) StoreEvent(event model.Event) error {
 		return err
 	}
 
-	// Log event storage with generic stream name
-	log.Printf("Storing event: %+v\n", event)
+	projectStream := ProjectStreamName(event.ProjectID)
+	log.Printf("Storing event in %s: %+v\n", projectStream, event)
 
 	esEvent := esdb.EventData{
 		EventID:     id,
@@ -68,56 +82,123 @@ func (e *ESDBClient) StoreEvent(event model.Event) error {
 		Data:        eventData,
 		ContentType: esdb.JsonContentType,
 	}
-	opts := esdb.AppendToStreamOptions{}
-	_, err = e.client.AppendToStream(context.Background(), "all-events", opts, esEvent)
-	return err
-}
+	result, err := e.client.AppendToStream(context.Background(), projectStream, esdb.AppendToStreamOptions{}, esEvent)
+	if err != nil {
+		return err
+	}
 
-func (repo *ESDBClient) GetAllEvents() ([]model.Event, error) {
-	var events []model.Event
+	taskID := event.TaskID()
+	if taskID == "" {
+		return nil
+	}
 
-	// Set up options for reading from the stream
-	readOpts := esdb.ReadStreamOptions{
-		From: esdb.Start{}, // Start from the beginning of the stream
+	linkID, err := eventID(idempotencyKey, TaskStreamName(taskID))
+	if err != nil {
+		return err
 	}
-	ctx := context.Background()
+	link := esdb.EventData{
+		EventID:     linkID,
+		EventType:   linkEventType,
+		Data:        []byte(fmt.Sprintf("%d@%s", result.NextExpectedVersion, projectStream)),
+		ContentType: esdb.BinaryContentType,
+	}
+	_, err = e.client.AppendToStream(context.Background(), TaskStreamName(taskID), esdb.AppendToStreamOptions{}, link)
+	if err != nil {
+		return fmt.Errorf("event stored in %s but linking to task %s failed: %w", projectStream, taskID, err)
+	}
+	return nil
+}
 
-	// Open the stream for reading
-	stream, err := repo.client.ReadStream(ctx, "all-events", readOpts, 100)
+// eventID derives the EventStoreDB event ID from an idempotency key, or
+// generates a random one when the producer did not send a key.
+func eventID(idempotencyKey, stream string) (uuid.UUID, error) {
+	if idempotencyKey == "" {
+		return uuid.NewV4()
+	}
+	return uuid.NewV5(idempotencyNamespace, idempotencyKey+"@"+stream), nil
+}
+
+// GetAllEvents reads every project stream through the $ce-project category projection.
+func (repo *ESDBClient) GetAllEvents() ([]model.Event, error) {
+	events, err := repo.readAll(projectCategoryStream)
 	if err != nil {
 		// Log the error, but do not return it as an HTTP error
 		log.Printf("Error reading stream: %v", err)
-		// Return empty events, no internal server error
-		return events, nil
 	}
-	defer stream.Close()
 
-	// Iterate through the stream
+	// Return the empty slice if no events were found, no need for error handling
+	return events, nil
+}
+
+// GetAllEventsByProjectID reads a whole project stream.
+func (repo *ESDBClient) GetAllEventsByProjectID(projectID string) ([]model.Event, error) {
+	return repo.readAll(ProjectStreamName(projectID))
+}
+
+// GetAllEventsByTaskID reads a whole task stream.
+func (repo *ESDBClient) GetAllEventsByTaskID(taskID string) ([]model.Event, error) {
+	return repo.readAll(TaskStreamName(taskID))
+}
+
+// readAll reads a stream page by page until its end.
+func (repo *ESDBClient) readAll(streamName string) ([]model.Event, error) {
+	var events []model.Event
+
+	from := uint64(0)
 	for {
-		event, err := stream.Recv()
+		page, err := repo.readPage(streamName, from, maxPageSize)
 		if err != nil {
-			if err.Error() == "EOF" {
-				break
-			}
-			log.Printf("Error receiving event: %v", err)
-			// Return empty events on error without internal server error
+			return events, err
+		}
+		for _, recorded := range page.Events {
+			events = append(events, recorded.Event)
+		}
+		if page.Next == nil {
 			return events, nil
 		}
+		from = *page.Next
+	}
+}
 
-		var e model.Event
-		if err := json.Unmarshal(event.Event.Data, &e); err != nil {
-			log.Printf("Error unmarshalling event: %v", err)
-			continue
+// ReplayEvents passes every event already stored in project streams to
+// processFn, oldest first. In-memory read models use it on start, since the
+// persistent subscription only delivers events it has not acknowledged yet.
+func (e *ESDBClient) ReplayEvents(processFn func(id string, event model.Event) error) error {
+	readOpts := esdb.ReadStreamOptions{From: esdb.Start{}, ResolveLinkTos: true}
+	stream, err := e.client.ReadStream(context.Background(), projectCategoryStream, readOpts, ^uint64(0))
+	if err != nil {
+		if errors.Is(err, esdb.ErrStreamNotFound) {
+			return nil
 		}
-		events = append(events, e)
+		return err
 	}
+	defer stream.Close()
 
-	// Return the empty slice if no events were found, no need for error handling
-	return events, nil
+	for {
+		resolved, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resolved.Event == nil {
+			continue
+		}
+		var event model.Event
+		if err := json.Unmarshal(resolved.Event.Data, &event); err != nil {
+			log.Println("Failed to deserialize event:", err)
+			continue
+		}
+		if err := processFn(resolved.Event.EventID.String(), event); err != nil {
+			return err
+		}
+	}
 }
 
-// ProcessEvents processes events using a provided function.
-func (e *ESDBClient) ProcessEvents(processFn func(event model.Event) error) {
+// ProcessEvents processes events using a provided function. The event ID is
+// passed along so processFn can skip events it already got from ReplayEvents.
+func (e *ESDBClient) ProcessEvents(processFn func(id string, event model.Event) error) {
 	for {
 		receivedEvent := e.sub.Recv()
 
@@ -129,7 +210,7 @@ func (e *ESDBClient) ProcessEvents(processFn func(event model.Event) error) {
 				e.sub.Nack(err.Error(), esdb.Nack_Park, receivedEvent.EventAppeared)
 				continue
 			}
-			err := processFn(event)
+			err := processFn(streamEvent.EventID.String(), event)
 			if err != nil {
 				log.Println("Processing error:", err)
 				e.sub.Nack(err.Error(), esdb.Nack_Retry, receivedEvent.EventAppeared)
@@ -140,7 +221,7 @@ func (e *ESDBClient) ProcessEvents(processFn func(event model.Event) error) {
 
 		if receivedEvent.SubscriptionDropped != nil {
 			log.Println("Subscription dropped:", receivedEvent.SubscriptionDropped.Error)
-			for err := e.subscribe(); err != nil; {
+			for err := e.subscribe(); err != nil; err = e.subscribe() {
 				log.Println("Reattempting subscription in 5 seconds...")
 				time.Sleep(5 * time.Second)
 			}
@@ -159,65 +240,95 @@ func (e *ESDBClient) subscribe() error {
 	return nil
 }
 
-// GetEventsByProjectID retrieves all events associated with the given project ID from EventStoreDB
-func (repo *ESDBClient) GetEventsByProjectID(projectID string) ([]model.Event, error) {
-	// Stream name could be based on the project ID. For example, "project-{projectID}"
-	streamName := fmt.Sprintf(projectID)
+// GetEventsByProjectID reads up to count events of a project stream starting at position from.
+func (repo *ESDBClient) GetEventsByProjectID(projectID string, from, count uint64) (model.EventPage, error) {
+	return repo.readPage(ProjectStreamName(projectID), from, count)
+}
 
-	// Create an empty slice to store the events
-	var events []model.Event
+// GetEventsByTaskID reads up to count events of a task stream starting at position from.
+func (repo *ESDBClient) GetEventsByTaskID(taskID string, from, count uint64) (model.EventPage, error) {
+	return repo.readPage(TaskStreamName(taskID), from, count)
+}
 
-	// Set up the options for reading events
-	readOpts := esdb.ReadStreamOptions{
-		From: esdb.Start{}, // Start from the beginning of the stream
+// readPage reads a window of a stream, resolving links so task and category
+// streams return the original events. Positions are those of the stream read.
+func (repo *ESDBClient) readPage(streamName string, from, count uint64) (model.EventPage, error) {
+	page := model.EventPage{Events: []model.RecordedEvent{}, From: from}
+	if count == 0 {
+		return page, nil
 	}
 
-	// Specify the number of events you want to read (or adjust based on your needs)
-	count := uint64(100) // Adjust this number based on your needs (or handle pagination)
-
-	// Create a context (optional timeout can be added)
-	ctx := context.Background()
+	readOpts := esdb.ReadStreamOptions{
+		From:           esdb.Revision(from),
+		ResolveLinkTos: true,
+	}
 
-	// Open the stream for reading with the specified options
-	stream, err := repo.client.ReadStream(ctx, streamName, readOpts, count)
+	// Read one event past the window to know whether there is a next page
+	stream, err := repo.client.ReadStream(context.Background(), streamName, readOpts, count+1)
 	if err != nil {
-		log.Printf("Error reading stream: %v", err)
-		return nil, err
+		if errors.Is(err, esdb.ErrStreamNotFound) {
+			return page, nil
+		}
+		log.Printf("Error reading stream %s: %v", streamName, err)
+		return page, err
 	}
 	defer stream.Close()
 
-	// Iterate over the events using Recv()
+	var read uint64
 	for {
-		// Receive the next event
-		event, err := stream.Recv()
+		resolved, err := stream.Recv()
 		if err != nil {
-			// If we get an error, handle it (e.g., no more events or another error)
-			if err.Error() == "EOF" { // End of stream
+			if errors.Is(err, io.EOF) {
 				break
 			}
 			log.Printf("Error receiving event: %v", err)
-			return nil, err
+			return page, err
+		}
+
+		position := resolved.OriginalEvent().EventNumber
+		if read == count {
+			page.Next = &position
+			break
+		}
+		read++
+
+		// Links whose target stream was deleted resolve to nothing
+		if resolved.Event == nil {
+			continue
 		}
 
-		// Unmarshal the event data into the model.Event struct
 		var e model.Event
-		if err := json.Unmarshal(event.Event.Data, &e); err != nil {
+		if err := json.Unmarshal(resolved.Event.Data, &e); err != nil {
 			log.Printf("Error unmarshalling event data: %v", err)
 			continue
 		}
-
-		// Append the event to the events slice
-		events = append(events, e)
+		page.Events = append(page.Events, model.RecordedEvent{Position: position, Event: e})
 	}
 
-	return events, nil
+	return page, nil
 }
+
+// DeleteAllEvents deletes every project and task stream, along with the legacy all-events stream.
 func (e *ESDBClient) DeleteAllEvents() error {
-	// Use the client to delete the "all-events" stream
-	_, err := e.client.DeleteStream(context.Background(), "all-events", esdb.DeleteStreamOptions{})
+	events, err := e.GetAllEvents()
 	if err != nil {
 		return fmt.Errorf("failed to delete events: %v", err)
 	}
-	log.Println("Successfully deleted all events from the 'all-events' stream")
+
+	streams := map[string]bool{legacyAllEventsStream: true}
+	for _, event := range events {
+		streams[ProjectStreamName(event.ProjectID)] = true
+		if taskID := event.TaskID(); taskID != "" {
+			streams[TaskStreamName(taskID)] = true
+		}
+	}
+
+	for streamName := range streams {
+		_, err := e.client.DeleteStream(context.Background(), streamName, esdb.DeleteStreamOptions{})
+		if err != nil && !errors.Is(err, esdb.ErrStreamNotFound) {
+			return fmt.Errorf("failed to delete events from %s: %v", streamName, err)
+		}
+	}
+	log.Printf("Successfully deleted events from %d streams", len(streams))
 	return nil
 }
@@ -0,0 +1,47 @@
+package repository
+
+import "github.com/gofrs/uuid"
+
+// idempotencyNamespace scopes the name-based event IDs derived from producer idempotency keys.
+var idempotencyNamespace = uuid.Must(uuid.FromString("6f1c7c1e-3b0e-4a4c-9a57-2d4f0e6b8a10"))
+
+// IdempotencyKeyHeader carries the producer's idempotency key on /event/append.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const (
+	projectStreamPrefix = "project-"
+	taskStreamPrefix    = "task-"
+
+	// projectCategoryStream is maintained by the $by_category system projection
+	// and links every event written to a project-* stream.
+	projectCategoryStream = "$ce-project"
+
+	// legacyAllEventsStream held every event before per-project streams were introduced.
+	legacyAllEventsStream = "all-events"
+
+	linkEventType = "$>"
+
+	DefaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// ProjectStreamName returns the stream holding every event of a project.
+func ProjectStreamName(projectID string) string {
+	return projectStreamPrefix + projectID
+}
+
+// TaskStreamName returns the stream linking every event of a task.
+func TaskStreamName(taskID string) string {
+	return taskStreamPrefix + taskID
+}
+
+// ClampPageSize keeps a requested page size within the bounds the store serves.
+func ClampPageSize(size uint64) uint64 {
+	if size == 0 {
+		return DefaultPageSize
+	}
+	if size > maxPageSize {
+		return maxPageSize
+	}
+	return size
+}
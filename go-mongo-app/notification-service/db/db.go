@@ -0,0 +1,23 @@
+package db
+
+import (
+	"context"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Client čuva red email poruka; obaveštenja su i dalje u Cassandri.
+var Client *mongo.Client
+
+func ConnectToMongo() error {
+	clientOptions := options.Client().ApplyURI(os.Getenv("MONGO_URI"))
+	var err error
+	Client, err = mongo.Connect(context.TODO(), clientOptions)
+	return err
+}
+
+func DisconnectMongo() error {
+	return Client.Disconnect(context.TODO())
+}
@@ -0,0 +1,376 @@
+package handlers
+
+import (
+	"context"
+	"contracts"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"notification-service/models"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// consumerName je ime trajnog (durable) consumer-a na svakom stream-u;
+	// sve instance servisa ga dele, pa se svaka poruka obradi jednom.
+	consumerName = "notification-service"
+	// maxDeliver je broj isporuka posle kog poruka ide u dead letter tabelu.
+	maxDeliver = 6
+	ackWait    = 30 * time.Second
+	// replayLimit ograničava broj poruka jednog zahteva za ponovnu obradu.
+	replayLimit = 1000
+	// replayBatch je najveći broj poruka koje replay dohvata odjednom.
+	replayBatch = 100
+)
+
+// notificationStreams su JetStream stream-ovi iz kojih servis čita. Prave ih
+// project-service i task-service; ovde se prave samo ako još ne postoje.
+var notificationStreams = []jetstream.StreamConfig{
+	{Name: "PROJECTS", Subjects: []string{"project.>"}, Storage: jetstream.FileStorage, MaxAge: 7 * 24 * time.Hour, Duplicates: 2 * time.Minute},
+	{Name: "TASKS", Subjects: []string{"task.>"}, Storage: jetstream.FileStorage, MaxAge: 7 * 24 * time.Hour, Duplicates: 2 * time.Minute},
+}
+
+// errMalformedEvent označava poruku koja ni posle ponovne isporuke ne može
+// da se obradi, pa odmah ide u dead letter tabelu.
+var errMalformedEvent = errors.New("malformed event")
+
+func Conn() (*nats.Conn, error) {
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		url = "nats://nats:4222"
+	}
+	return nats.Connect(url, nats.MaxReconnects(-1))
+}
+
+// NotificationListener pravi trajne consumer-e sa eksplicitnim potvrđivanjem:
+// poruke objavljene dok servis ne radi čekaju u stream-u, a neuspešna
+// obrada se ponavlja sa sve dužom pauzom.
+func (n *NotificationHandler) NotificationListener(ctx context.Context) error {
+	for _, config := range notificationStreams {
+		if _, err := n.js.Stream(ctx, config.Name); errors.Is(err, jetstream.ErrStreamNotFound) {
+			_, err = n.js.CreateStream(ctx, config)
+			if err != nil && !errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+				return fmt.Errorf("creating stream %s: %v", config.Name, err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("looking up stream %s: %v", config.Name, err)
+		}
+
+		consumer, err := n.js.CreateOrUpdateConsumer(ctx, config.Name, jetstream.ConsumerConfig{
+			Durable:        consumerName,
+			FilterSubjects: streamSubjects(config),
+			DeliverPolicy:  jetstream.DeliverAllPolicy,
+			AckPolicy:      jetstream.AckExplicitPolicy,
+			AckWait:        ackWait,
+			MaxDeliver:     maxDeliver,
+			MaxAckPending:  256,
+		})
+		if err != nil {
+			return fmt.Errorf("creating consumer on %s: %v", config.Name, err)
+		}
+
+		stream := config.Name
+		if _, err := consumer.Consume(func(msg jetstream.Msg) { n.handleMessage(stream, msg) }); err != nil {
+			return fmt.Errorf("consuming %s: %v", config.Name, err)
+		}
+		n.logger.Printf("Consuming %v from stream %s", streamSubjects(config), config.Name)
+	}
+	return nil
+}
+
+// historySubjects su kopije događaja za istoriju; obaveštenja se već prave
+// iz originalnih događaja, pa ih servis ne troši.
+var historySubjects = map[string]bool{
+	contracts.SubjectProjectHistory: true,
+	contracts.SubjectTaskHistory:    true,
+}
+
+// streamSubjects vraća subjekte koje servis obrađuje, a koje stream čuva.
+func streamSubjects(config jetstream.StreamConfig) []string {
+	prefix := strings.TrimSuffix(config.Subjects[0], ">")
+	subjects := []string{}
+	for _, subject := range contracts.Subjects() {
+		if strings.HasPrefix(subject, prefix) && !historySubjects[subject] {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Strings(subjects)
+	return subjects
+}
+
+func (n *NotificationHandler) handleMessage(stream string, msg jetstream.Msg) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		n.logger.Printf("Dropping message without JetStream metadata on %s: %v", msg.Subject(), err)
+		msg.Term()
+		return
+	}
+	eventID := messageEventID(stream, msg.Headers(), meta.Sequence.Stream)
+
+	err = n.processEvent(eventID, msg.Subject(), msg.Data())
+	switch {
+	case err == nil:
+		msg.Ack()
+	case errors.Is(err, errMalformedEvent) || meta.NumDelivered >= maxDeliver:
+		n.logger.Printf("Moving %s message %d to dead letters after %d deliveries: %v", stream, meta.Sequence.Stream, meta.NumDelivered, err)
+		letter := &models.DeadLetter{
+			Stream:     stream,
+			Sequence:   meta.Sequence.Stream,
+			EventID:    eventID,
+			Subject:    msg.Subject(),
+			Data:       string(msg.Data()),
+			Error:      err.Error(),
+			Deliveries: int(meta.NumDelivered),
+			FailedAt:   time.Now(),
+		}
+		if err := n.repo.SaveDeadLetter(letter); err != nil {
+			// Poruka ostaje u stream-u i može da se obradi ponovo preko replay-a
+			n.logger.Printf("Error saving dead letter %s/%d: %v", stream, meta.Sequence.Stream, err)
+		}
+		msg.Term()
+	default:
+		n.logger.Printf("Error processing %s message %d (delivery %d), retrying: %v", stream, meta.Sequence.Stream, meta.NumDelivered, err)
+		msg.NakWithDelay(redeliveryDelay(meta.NumDelivered))
+	}
+}
+
+// processEvent pravi obaveštenja iz poruke i isporučuje ih. Korisnik koji je
+// o poruci eventID već obavešten se preskače, pa su ponovna isporuka i
+// replay bezbedni.
+func (n *NotificationHandler) processEvent(eventID, subject string, data []byte) error {
+	message, err := contracts.Decode(subject, data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errMalformedEvent, err)
+	}
+	pending := pendingNotifications(message)
+
+	for i := range pending {
+		userID := pending[i].notification.UserID
+		processed, err := n.repo.IsEventProcessed(eventID, userID)
+		if err != nil {
+			return err
+		}
+		if processed {
+			continue
+		}
+		if err := n.notify(eventID, pending[i].eventType, pending[i].projectID, pending[i].taskID, &pending[i].notification); err != nil {
+			return err
+		}
+		if err := n.repo.MarkEventProcessed(eventID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messageEventID koristi Nats-Msg-Id koji postavlja outbox, a za poruke bez
+// njega poziciju u stream-u.
+func messageEventID(stream string, header nats.Header, sequence uint64) string {
+	if id := header.Get(nats.MsgIdHdr); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%s:%d", stream, sequence)
+}
+
+func redeliveryDelay(delivered uint64) time.Duration {
+	delay := 5 * time.Second << (delivered - 1)
+	if delay <= 0 || delay > 5*time.Minute {
+		return 5 * time.Minute
+	}
+	return delay
+}
+
+func findStream(name string) (jetstream.StreamConfig, bool) {
+	for _, config := range notificationStreams {
+		if config.Name == name {
+			return config, true
+		}
+	}
+	return jetstream.StreamConfig{}, false
+}
+
+// OperatorOnly propušta samo korisnike čiji je ID u OPERATOR_USER_IDS.
+func (n *NotificationHandler) OperatorOnly(next func(http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(KeyAccount{}).(string)
+		for _, id := range strings.Split(os.Getenv("OPERATOR_USER_IDS"), ",") {
+			if id = strings.TrimSpace(id); id != "" && id == userID {
+				next(rw, r)
+				return
+			}
+		}
+		http.Error(rw, "Operator access required", http.StatusForbidden)
+	}
+}
+
+// ListDeadLettersHandler vraća poruke koje nisu obrađene, za jedan ili sve stream-ove.
+func (n *NotificationHandler) ListDeadLettersHandler(rw http.ResponseWriter, r *http.Request) {
+	streams := []string{}
+	if stream := r.URL.Query().Get("stream"); stream != "" {
+		if _, ok := findStream(stream); !ok {
+			http.Error(rw, "Unknown stream", http.StatusBadRequest)
+			return
+		}
+		streams = append(streams, stream)
+	} else {
+		for _, config := range notificationStreams {
+			streams = append(streams, config.Name)
+		}
+	}
+
+	letters := []models.DeadLetter{}
+	for _, stream := range streams {
+		found, err := n.repo.DeadLetters(stream)
+		if err != nil {
+			http.Error(rw, "Error fetching dead letters", http.StatusInternalServerError)
+			n.logger.Printf("Error fetching dead letters of %s: %v", stream, err)
+			return
+		}
+		letters = append(letters, found...)
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(letters)
+}
+
+// RetryDeadLetterHandler ponovo obrađuje sačuvanu poruku i briše je ako uspe.
+func (n *NotificationHandler) RetryDeadLetterHandler(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	sequence, err := strconv.ParseUint(vars["sequence"], 10, 64)
+	if err != nil {
+		http.Error(rw, "Invalid sequence", http.StatusBadRequest)
+		return
+	}
+	letter, err := n.repo.GetDeadLetter(vars["stream"], sequence)
+	if err == gocql.ErrNotFound {
+		http.Error(rw, "Dead letter not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(rw, "Error fetching dead letter", http.StatusInternalServerError)
+		return
+	}
+
+	if err := n.processEvent(letter.EventID, letter.Subject, []byte(letter.Data)); err != nil {
+		http.Error(rw, "Processing failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err := n.repo.DeleteDeadLetter(letter.Stream, letter.Sequence); err != nil {
+		n.logger.Printf("Error deleting dead letter %s/%d: %v", letter.Stream, letter.Sequence, err)
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+type replayRequest struct {
+	Stream        string     `json:"stream"`
+	StartSequence uint64     `json:"startSequence"`
+	EndSequence   uint64     `json:"endSequence"`
+	StartTime     *time.Time `json:"startTime"`
+}
+
+type replayFailure struct {
+	Sequence uint64 `json:"sequence"`
+	Subject  string `json:"subject"`
+	Error    string `json:"error"`
+}
+
+type replayResult struct {
+	Processed    int             `json:"processed"`
+	Failed       []replayFailure `json:"failed"`
+	LastSequence uint64          `json:"lastSequence"`
+	Complete     bool            `json:"complete"`
+}
+
+// ReplayHandler ponovo obrađuje poruke stream-a od startSequence (ili
+// startTime) do endSequence. Već obavešteni korisnici se preskaču. Obrađuje
+// najviše replayLimit poruka; ako Complete nije true, sledeći zahtev kreće
+// od LastSequence+1.
+func (n *NotificationHandler) ReplayHandler(rw http.ResponseWriter, r *http.Request) {
+	var request replayRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(rw, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	config, ok := findStream(request.Stream)
+	if !ok {
+		http.Error(rw, "Unknown stream", http.StatusBadRequest)
+		return
+	}
+
+	consumerConfig := jetstream.OrderedConsumerConfig{FilterSubjects: streamSubjects(config), DeliverPolicy: jetstream.DeliverAllPolicy}
+	switch {
+	case request.StartSequence > 0:
+		consumerConfig.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		consumerConfig.OptStartSeq = request.StartSequence
+	case request.StartTime != nil:
+		consumerConfig.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		consumerConfig.OptStartTime = request.StartTime
+	}
+
+	// Obrada može da traje duže od WriteTimeout servera
+	http.NewResponseController(rw).SetWriteDeadline(time.Now().Add(5 * time.Minute))
+
+	consumer, err := n.js.OrderedConsumer(r.Context(), config.Name, consumerConfig)
+	if err != nil {
+		http.Error(rw, "Error creating replay consumer", http.StatusInternalServerError)
+		n.logger.Printf("Error creating replay consumer on %s: %v", config.Name, err)
+		return
+	}
+
+	result := replayResult{Failed: []replayFailure{}}
+	for result.Processed+len(result.Failed) < replayLimit && !result.Complete {
+		// Dohvata se samo onoliko poruka koliko je još dozvoljeno, pa jedan
+		// zahtev ne obrađuje više od replayLimit poruka
+		size := replayLimit - result.Processed - len(result.Failed)
+		if size > replayBatch {
+			size = replayBatch
+		}
+		batch, err := consumer.Fetch(size, jetstream.FetchMaxWait(time.Second))
+		if err != nil {
+			http.Error(rw, "Error reading stream", http.StatusInternalServerError)
+			return
+		}
+		received := 0
+		for msg := range batch.Messages() {
+			received++
+			meta, err := msg.Metadata()
+			if err != nil {
+				continue
+			}
+			sequence := meta.Sequence.Stream
+			if request.EndSequence > 0 && sequence > request.EndSequence {
+				result.Complete = true
+				break
+			}
+			result.LastSequence = sequence
+
+			eventID := messageEventID(config.Name, msg.Headers(), sequence)
+			if err := n.processEvent(eventID, msg.Subject(), msg.Data()); err != nil {
+				result.Failed = append(result.Failed, replayFailure{Sequence: sequence, Subject: msg.Subject(), Error: err.Error()})
+			} else {
+				result.Processed++
+			}
+			if meta.NumPending == 0 || sequence == request.EndSequence {
+				result.Complete = true
+				break
+			}
+		}
+		if received == 0 {
+			result.Complete = true
+		}
+	}
+	n.logger.Printf("Replayed %s up to sequence %d: %d processed, %d failed", config.Name, result.LastSequence, result.Processed, len(result.Failed))
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(result)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"contracts"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func newTestHandler() *NotificationHandler {
+	return &NotificationHandler{logger: log.New(io.Discard, "", 0)}
+}
+
+func TestStreamSubjectsSkipHistory(t *testing.T) {
+	for _, config := range notificationStreams {
+		prefix := strings.TrimSuffix(config.Subjects[0], ">")
+		subjects := streamSubjects(config)
+		if len(subjects) == 0 {
+			t.Fatalf("%s: no subjects", config.Name)
+		}
+		for _, subject := range subjects {
+			if !strings.HasPrefix(subject, prefix) {
+				t.Errorf("%s: %s is not stored in the stream", config.Name, subject)
+			}
+			if subject == contracts.SubjectProjectHistory || subject == contracts.SubjectTaskHistory {
+				t.Errorf("%s: history subject %s is consumed", config.Name, subject)
+			}
+		}
+	}
+}
+
+func TestMessageEventIDPrefersOutboxID(t *testing.T) {
+	header := nats.Header{}
+	if id := messageEventID("TASKS", header, 42); id != "TASKS:42" {
+		t.Errorf("without header id = %q, want TASKS:42", id)
+	}
+	header.Set(nats.MsgIdHdr, "task-service-abc")
+	if id := messageEventID("TASKS", header, 42); id != "task-service-abc" {
+		t.Errorf("with header id = %q, want task-service-abc", id)
+	}
+}
+
+func TestRedeliveryDelayGrowsUntilCap(t *testing.T) {
+	if d := redeliveryDelay(1); d != 5*time.Second {
+		t.Errorf("first redelivery after %v, want 5s", d)
+	}
+	if d := redeliveryDelay(3); d != 20*time.Second {
+		t.Errorf("third redelivery after %v, want 20s", d)
+	}
+	for _, delivered := range []uint64{7, 40, 100} {
+		if d := redeliveryDelay(delivered); d != 5*time.Minute {
+			t.Errorf("redeliveryDelay(%d) = %v, want the 5m cap", delivered, d)
+		}
+	}
+}
+
+// Neispravna poruka se ne ponavlja, već odmah ide u dead letter tabelu.
+func TestProcessEventMarksUndecodableMessagesMalformed(t *testing.T) {
+	n := newTestHandler()
+	err := n.processEvent("TASKS:1", contracts.SubjectTaskMemberAdded, []byte(`{"userId":`))
+	if !errors.Is(err, errMalformedEvent) {
+		t.Fatalf("err = %v, want errMalformedEvent", err)
+	}
+	err = n.processEvent("TASKS:2", "task.renamed", []byte(`{}`))
+	if !errors.Is(err, errMalformedEvent) {
+		t.Fatalf("unknown subject: err = %v, want errMalformedEvent", err)
+	}
+}
+
+type fakeMsg struct {
+	jetstream.Msg
+	terminated bool
+}
+
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return nil, jetstream.ErrNotJSMessage
+}
+func (m *fakeMsg) Subject() string { return contracts.SubjectTaskMemberAdded }
+func (m *fakeMsg) Term() error {
+	m.terminated = true
+	return nil
+}
+
+func TestHandleMessageTerminatesMessagesWithoutMetadata(t *testing.T) {
+	msg := &fakeMsg{}
+	newTestHandler().handleMessage("TASKS", msg)
+	if !msg.terminated {
+		t.Error("message without metadata was not terminated")
+	}
+}
+
+func TestReplayRejectsUnknownStream(t *testing.T) {
+	n := newTestHandler()
+	for name, body := range map[string]string{
+		"invalid body":   `{"stream":`,
+		"unknown stream": `{"stream":"USERS","startSequence":1}`,
+	} {
+		rw := httptest.NewRecorder()
+		n.ReplayHandler(rw, httptest.NewRequest("POST", "/replay", strings.NewReader(body)))
+		if rw.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", name, rw.Code)
+		}
+	}
+}
+
+func TestDeadLetterHandlersValidateInput(t *testing.T) {
+	n := newTestHandler()
+
+	rw := httptest.NewRecorder()
+	n.ListDeadLettersHandler(rw, httptest.NewRequest("GET", "/dead-letters?stream=USERS", nil))
+	if rw.Code != http.StatusBadRequest {
+		t.Errorf("list: status = %d, want 400", rw.Code)
+	}
+
+	rw = httptest.NewRecorder()
+	r := mux.SetURLVars(httptest.NewRequest("POST", "/dead-letters/TASKS/x/retry", nil), map[string]string{"stream": "TASKS", "sequence": "x"})
+	n.RetryDeadLetterHandler(rw, r)
+	if rw.Code != http.StatusBadRequest {
+		t.Errorf("retry: status = %d, want 400", rw.Code)
+	}
+}
+
+func TestOperatorOnly(t *testing.T) {
+	t.Setenv("OPERATOR_USER_IDS", "ops1, ops2")
+	handler := newTestHandler().OperatorOnly(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+
+	for userID, want := range map[string]int{"ops2": http.StatusNoContent, "u1": http.StatusForbidden, "": http.StatusForbidden} {
+		r := httptest.NewRequest("GET", "/dead-letters", nil)
+		r = r.WithContext(context.WithValue(r.Context(), KeyAccount{}, userID))
+		rw := httptest.NewRecorder()
+		handler(rw, r)
+		if rw.Code != want {
+			t.Errorf("user %q: status = %d, want %d", userID, rw.Code, want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"notification-service/mailer"
+	"notification-service/models"
+	"shared/security"
+	"sort"
+	"time"
+)
+
+// digestInterval je učestalost provere kome treba poslati pregled.
+const digestInterval = 5 * time.Minute
+
+var digestEmail = mailer.MustTemplate("digest",
+	`Taskio {{if eq .Frequency "weekly"}}weekly{{else}}daily{{end}} digest`,
+	`Hello {{.Name}},
+
+Here is what happened between {{.From.Format "Jan 2 15:04"}} and {{.To.Format "Jan 2 15:04 MST"}}.
+{{if .NewlyAssigned}}
+Newly assigned to you:
+{{range .NewlyAssigned}}  - {{.Message}}
+{{end}}{{end}}{{if .Overdue}}
+Overdue tasks:
+{{range .Overdue}}  - {{.Name}} ({{.ProjectTitle}}, due {{.DueDate}}, {{.Status}})
+{{end}}{{end}}{{range .Projects}}
+{{.Title}}:
+{{range .Items}}  - {{.Message}}
+{{end}}{{end}}
+You can change how often you get this digest in your notification preferences.
+`)
+
+// RunDigestScheduler šalje dnevne i nedeljne preglede u sat koji je korisnik
+// izabrao u svojoj vremenskoj zoni.
+func (n *NotificationHandler) RunDigestScheduler(ctx context.Context) {
+	ticker := time.NewTicker(digestInterval)
+	defer ticker.Stop()
+	for {
+		n.sendDueDigests(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *NotificationHandler) sendDueDigests(now time.Time) {
+	subscribers, err := n.repo.DigestSubscribers()
+	if err != nil {
+		return
+	}
+	for _, subscriber := range subscribers {
+		lastSent, err := n.repo.LastDigestSent(subscriber.UserID)
+		if err != nil {
+			n.logger.Printf("Error reading last digest of user %s: %v", subscriber.UserID, err)
+			continue
+		}
+		var since time.Time
+		if lastSent != nil {
+			since = *lastSent
+		}
+		if !subscriber.Digest.Due(since, now) {
+			continue
+		}
+		if lastSent == nil {
+			since = now.Add(-subscriber.Digest.Period())
+		}
+
+		// Pregled se prvo zauzima, da ga druga instanca ne pošalje istovremeno,
+		// a zauzeće se vraća ako slanje ne uspe
+		claimed, err := n.repo.ClaimDigest(subscriber.UserID, lastSent, now)
+		if err != nil || !claimed {
+			continue
+		}
+		digest := subscriber.Digest
+		if err := n.sendDigest(subscriber.UserID, &digest, since, now); err != nil {
+			n.logger.Printf("Error sending digest to user %s: %v", subscriber.UserID, err)
+			if err := n.repo.ReleaseDigest(subscriber.UserID, lastSent, now); err != nil {
+				n.logger.Printf("Error releasing digest of user %s: %v", subscriber.UserID, err)
+			}
+		}
+	}
+}
+
+// buildDigest skuplja nepročitana obaveštenja i obaveštenja iz reda za
+// pregled, grupisana po projektu, zajedno sa novim i zakasnelim zadacima.
+func (n *NotificationHandler) buildDigest(userID string, settings *models.DigestSettings, since, until time.Time) (*models.Digest, error) {
+	items, err := n.repo.FetchDigestItems(userID, since, until)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	digest := &models.Digest{
+		UserID:        userID,
+		Frequency:     settings.Frequency,
+		From:          since.In(location),
+		To:            until.In(location),
+		Projects:      []models.DigestProject{},
+		NewlyAssigned: []models.DigestItem{},
+		Overdue:       []models.OverdueTask{},
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	projects := map[string]int{}
+	seen := map[string]bool{}
+	for _, item := range items {
+		// Isto obaveštenje može biti i u aplikaciji i u redu za pregled
+		key := item.ProjectID + "|" + item.TaskID + "|" + item.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		entry := models.DigestItem{Message: item.Message, EventType: item.EventType, TaskID: item.TaskID, CreatedAt: item.CreatedAt.In(location)}
+		if item.EventType == models.EventTaskAdded {
+			digest.NewlyAssigned = append(digest.NewlyAssigned, entry)
+			continue
+		}
+		i, ok := projects[item.ProjectID]
+		if !ok {
+			i = len(digest.Projects)
+			projects[item.ProjectID] = i
+			digest.Projects = append(digest.Projects, models.DigestProject{ProjectID: item.ProjectID, Title: projectTitle(item.ProjectID)})
+		}
+		digest.Projects[i].Items = append(digest.Projects[i].Items, entry)
+	}
+
+	overdue, err := fetchOverdueTasks(userID)
+	if err != nil {
+		// Pregled se šalje i kada task-service nije dostupan
+		n.logger.Printf("Error fetching overdue tasks for user %s: %v", userID, err)
+	} else {
+		digest.Overdue = overdue
+	}
+	return digest, nil
+}
+
+func (n *NotificationHandler) sendDigest(userID string, settings *models.DigestSettings, since, until time.Time) error {
+	digest, err := n.buildDigest(userID, settings, since, until)
+	if err != nil {
+		return err
+	}
+	if digest.Empty() {
+		return n.repo.ClearDigestQueue(userID, until)
+	}
+	if !n.mail.Configured() {
+		return fmt.Errorf("email is not configured")
+	}
+
+	user, err := fetchUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return fmt.Errorf("user %s has no email address", userID)
+	}
+	digest.Name = user.Name
+	if err := n.mail.Send(user.Email, digestEmail, digest); err != nil {
+		return err
+	}
+	return n.repo.ClearDigestQueue(userID, until)
+}
+
+// digestSettings vraća podešavanja pregleda korisnika; bez njih se koristi dnevni pregled.
+func (n *NotificationHandler) digestSettings(userID string) (*models.DigestSettings, error) {
+	prefs, err := n.repo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs.Digest.Enabled() {
+		return prefs.Digest, nil
+	}
+	return &models.DigestSettings{Frequency: models.DigestDaily}, nil
+}
+
+// PreviewDigestHandler vraća pregled koji bi prijavljeni korisnik sada dobio.
+func (n *NotificationHandler) PreviewDigestHandler(rw http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	settings, err := n.digestSettings(userID)
+	if err != nil {
+		http.Error(rw, "Error fetching notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-settings.Period())
+	if lastSent, err := n.repo.LastDigestSent(userID); err == nil && lastSent != nil {
+		since = *lastSent
+	}
+	digest, err := n.buildDigest(userID, settings, since, now)
+	if err != nil {
+		http.Error(rw, "Error building digest", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(digest)
+}
+
+// SendDigestHandler odmah šalje pregled prijavljenom korisniku, na primer
+// za proveru sa lokalnim SMTP serverom. Sledeći zakazani pregled kreće od sada.
+func (n *NotificationHandler) SendDigestHandler(rw http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	settings, err := n.digestSettings(userID)
+	if err != nil {
+		http.Error(rw, "Error fetching notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	lastSent, err := n.repo.LastDigestSent(userID)
+	if err != nil {
+		http.Error(rw, "Error reading last digest", http.StatusInternalServerError)
+		return
+	}
+	since := now.Add(-settings.Period())
+	if lastSent != nil {
+		since = *lastSent
+	}
+	claimed, err := n.repo.ClaimDigest(userID, lastSent, now)
+	if err != nil {
+		http.Error(rw, "Error recording digest", http.StatusInternalServerError)
+		return
+	}
+	if !claimed {
+		http.Error(rw, "A digest is already being sent", http.StatusConflict)
+		return
+	}
+
+	if err := n.sendDigest(userID, settings, since, now); err != nil {
+		if err := n.repo.ReleaseDigest(userID, lastSent, now); err != nil {
+			n.logger.Printf("Error releasing digest of user %s: %v", userID, err)
+		}
+		http.Error(rw, "Error sending digest: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// fetchOverdueTasks dohvata zakasnele zadatke korisnika iz task-service.
+func fetchOverdueTasks(userID string) ([]models.OverdueTask, error) {
+	token, err := security.ServiceToken("notification-service")
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://task-service:8080/tasks/users/%s/overdue", userID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch overdue tasks from task-service: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch overdue tasks, status: %d", resp.StatusCode)
+	}
+
+	var tasks []models.OverdueTask
+	if err := json.NewDecoder(resp.Body).Decode(&tasks); err != nil {
+		return nil, fmt.Errorf("failed to decode overdue tasks: %v", err)
+	}
+	return tasks, nil
+}
+
+// projectTitle dohvata naziv projekta iz project-service. Obaveštenja bez
+// projekta, ili kada servis nije dostupan, idu pod opšti naslov.
+func projectTitle(projectID string) string {
+	if projectID == "" {
+		return "General"
+	}
+	token, err := security.ServiceToken("notification-service")
+	if err != nil {
+		return "Project " + projectID
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://project-service:8080/projects/%s", projectID), nil)
+	if err != nil {
+		return "Project " + projectID
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "Project " + projectID
+	}
+	defer resp.Body.Close()
+
+	var project struct {
+		Title string `json:"title"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&project) != nil || project.Title == "" {
+		return "Project " + projectID
+	}
+	return project.Title
+}
@@ -2,17 +2,21 @@ package handlers
 
 import (
 	"context"
+	"contracts"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/gocql/gocql"
 	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
-	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"log"
 	"net/http"
+	"notification-service/mailer"
 	"notification-service/models"
 	"notification-service/repoNotification"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -26,10 +30,13 @@ type KeyNotification struct{}
 type NotificationHandler struct {
 	logger *log.Logger
 	repo   *repoNotification.NotificationRepo
+	hub    *notificationHub
+	mail   *mailer.Mailer
+	js     jetstream.JetStream
 }
 
-func NewNotificationHandler(l *log.Logger, r *repoNotification.NotificationRepo) *NotificationHandler {
-	return &NotificationHandler{logger: l, repo: r}
+func NewNotificationHandler(l *log.Logger, r *repoNotification.NotificationRepo, js jetstream.JetStream) *NotificationHandler {
+	return &NotificationHandler{logger: l, repo: r, hub: newNotificationHub(), mail: mailer.FromEnv(), js: js}
 }
 
 func (n *NotificationHandler) FetchNotificationByID(rw http.ResponseWriter, h *http.Request) {
@@ -64,13 +71,21 @@ func (n *NotificationHandler) FetchNotificationsByUser(rw http.ResponseWriter, h
 
 	n.logger.Println("User ID:", userID)
 
-	notifications, err := n.repo.FetchByUserID(userID)
+	pageSize, pageState, err := parsePageParams(h)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	archived := h.URL.Query().Get("archived") == "true"
+	notifications, next, err := n.repo.FetchByUserID(userID, archived, pageSize, pageState)
 	if err != nil {
 		http.Error(rw, "Error fetching notifications", http.StatusInternalServerError)
 		n.logger.Println("Error fetching notifications:", err)
 		return
 	}
 
+	setNextPage(rw, next)
 	rw.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(rw).Encode(notifications)
 	if err != nil {
@@ -80,13 +95,20 @@ func (n *NotificationHandler) FetchNotificationsByUser(rw http.ResponseWriter, h
 }
 
 func (n *NotificationHandler) FetchAllNotifications(rw http.ResponseWriter, r *http.Request) {
-	notifications, err := n.repo.FetchAllNotifications()
+	pageSize, pageState, err := parsePageParams(r)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	notifications, next, err := n.repo.FetchAllNotifications(pageSize, pageState)
 	if err != nil {
 		http.Error(rw, "Error fetching all notifications", http.StatusInternalServerError)
 		n.logger.Println("Error fetching all notifications:", err)
 		return
 	}
 
+	setNextPage(rw, next)
 	rw.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(rw).Encode(notifications)
 	if err != nil {
@@ -95,6 +117,38 @@ func (n *NotificationHandler) FetchAllNotifications(rw http.ResponseWriter, r *h
 	}
 }
 
+// parsePageParams čita veličinu strane (limit) i stanje strane (page) koje je
+// vratio prethodni odgovor u zaglavlju X-Next-Page.
+func parsePageParams(r *http.Request) (int, []byte, error) {
+	query := r.URL.Query()
+
+	pageSize := 0
+	if v := query.Get("limit"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid limit parameter: %s", v)
+		}
+		pageSize = size
+	}
+
+	var pageState []byte
+	if v := query.Get("page"); v != "" {
+		state, err := base64.RawURLEncoding.DecodeString(v)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid page parameter: %s", v)
+		}
+		pageState = state
+	}
+	return repoNotification.ClampPageSize(pageSize), pageState, nil
+}
+
+// setNextPage upisuje stanje sledeće strane; zaglavlja nema na poslednjoj strani.
+func setNextPage(rw http.ResponseWriter, next []byte) {
+	if next != nil {
+		rw.Header().Set("X-Next-Page", base64.RawURLEncoding.EncodeToString(next))
+	}
+}
+
 func (n *NotificationHandler) CreateNotification(rw http.ResponseWriter, h *http.Request) {
 	var notification models.Notification
 
@@ -111,7 +165,7 @@ func (n *NotificationHandler) CreateNotification(rw http.ResponseWriter, h *http
 		return
 	}
 
-	err = n.repo.Create(&notification)
+	err = n.deliver(&notification)
 	if err != nil {
 		http.Error(rw, "Failed to create notification", http.StatusInternalServerError)
 		n.logger.Print("Error inserting notification:", err)
@@ -170,6 +224,7 @@ func (n *NotificationHandler) UpdateNotification(rw http.ResponseWriter, h *http
 		n.logger.Println("Error updating notification status:", err)
 		return
 	}
+	n.pushUnreadCount(userID)
 
 	rw.WriteHeader(http.StatusNoContent)
 }
@@ -190,250 +245,167 @@ func (n *NotificationHandler) MarkNotificationsAsRead(rw http.ResponseWriter, r
 		n.logger.Printf("Error marking notifications as read for user %s: %v", userID, err)
 		return
 	}
+	n.pushUnreadCount(userID)
 
 	rw.WriteHeader(http.StatusNoContent)
 }
 
-func Conn() (*nats.Conn, error) {
-	conn, err := nats.Connect("nats://nats:4222")
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
+// BulkNotificationsHandler označava kao pročitana, arhivira ili briše
+// izabrana obaveštenja prijavljenog korisnika.
+func (n *NotificationHandler) BulkNotificationsHandler(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(KeyAccount{}).(string)
+	if !ok || userID == "" {
+		http.Error(rw, "User id not found in context", http.StatusUnauthorized)
+		return
 	}
-	return conn, nil
-}
 
-func (n *NotificationHandler) NotificationListener() {
-	n.logger.Println("method started")
-	nc, err := Conn()
-	if err != nil {
-		log.Fatal("Error connecting to NATS:", err)
+	var req models.BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(rw, "Unable to decode JSON", http.StatusBadRequest)
+		return
 	}
-	defer nc.Close()
-
-	subjectJoined := "project.joined"
-	_, err = nc.Subscribe(subjectJoined, func(msg *nats.Msg) {
-		fmt.Printf("User received notification: %s\n", string(msg.Data))
-
-		var data struct {
-			UserID      string `json:"userId"`
-			ProjectName string `json:"projectName"`
-		}
-
-		err := json.Unmarshal(msg.Data, &data)
-		if err != nil {
-			log.Println("Error unmarshalling message:", err)
-			return
-		}
-
-		fmt.Printf("User ID: %s, Project Name: %s\n", data.UserID, data.ProjectName)
-
-		message := fmt.Sprintf("You have been added to the \"%s\" project", strings.Title(data.ProjectName))
-
-		notification := models.Notification{
-			UserID:    data.UserID,
-			Message:   message,
-			CreatedAt: time.Now(),
-			Status:    models.Unread,
-		}
-
-		err = n.repo.Create(&notification)
-		if err != nil {
-			n.logger.Print("Error inserting notification:", err)
-			return
-		}
-	})
-
-	if err != nil {
-		log.Println("Error subscribing to NATS subject:", err)
+	if err := req.Validate(); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	taskJoined := "task.joined"
-	_, err = nc.Subscribe(taskJoined, func(msg *nats.Msg) {
-		fmt.Printf("User received notification: %s\n", string(msg.Data))
-
-		var data struct {
-			UserID   string `json:"userId"`
-			TaskName string `json:"taskName"`
-		}
-
-		err := json.Unmarshal(msg.Data, &data)
-		if err != nil {
-			log.Println("Error unmarshalling message:", err)
-			return
-		}
-
-		fmt.Printf("User ID: %s, Task Name: %s\n", data.UserID, data.TaskName)
-
-		message := fmt.Sprintf("You have been added to the \"%s\" task", strings.Title(data.TaskName))
-
-		notification := models.Notification{
-			UserID:    data.UserID,
-			Message:   message,
-			CreatedAt: time.Now(),
-			Status:    models.Unread,
-		}
-
-		err = n.repo.Create(&notification)
-		if err != nil {
-			n.logger.Print("Error inserting notification:", err)
-			return
-		}
-	})
-
-	if err != nil {
-		log.Println("Error subscribing to NATS subject:", err)
+	if err := n.applyAction(userID, req.Action, req.Notifications); err != nil {
+		http.Error(rw, "Failed to update notifications", http.StatusInternalServerError)
+		return
 	}
+	rw.WriteHeader(http.StatusNoContent)
+}
 
-	subjectRemoved := "project.removed"
-	_, err = nc.Subscribe(subjectRemoved, func(msg *nats.Msg) {
-		fmt.Printf("User received removal notification: %s\n", string(msg.Data))
-
-		var data struct {
-			UserID      string `json:"userId"`
-			ProjectName string `json:"projectName"`
-		}
-
-		err := json.Unmarshal(msg.Data, &data)
-		if err != nil {
-			log.Println("Error unmarshalling message:", err)
-			return
-		}
-
-		fmt.Printf("User ID: %s, Project Name: %s\n", data.UserID, data.ProjectName)
-
-		message := fmt.Sprintf("You have been removed from the \"%s\" project", strings.Title(data.ProjectName))
+// ArchiveNotification arhivira jedno obaveštenje; created_at se šalje u telu
+// zahteva, kao i kod izmene statusa.
+func (n *NotificationHandler) ArchiveNotification(rw http.ResponseWriter, r *http.Request) {
+	n.singleAction(rw, r, models.ActionArchive)
+}
 
-		notification := models.Notification{
-			UserID:    data.UserID,
-			Message:   message,
-			CreatedAt: time.Now(),
-			Status:    models.Unread,
-		}
+// DeleteNotification briše jedno obaveštenje; created_at se šalje kao parametar upita.
+func (n *NotificationHandler) DeleteNotification(rw http.ResponseWriter, r *http.Request) {
+	n.singleAction(rw, r, models.ActionDelete)
+}
 
-		err = n.repo.Create(&notification)
-		if err != nil {
-			n.logger.Print("Error inserting notification:", err)
-			return
-		}
-	})
+func (n *NotificationHandler) singleAction(rw http.ResponseWriter, r *http.Request, action string) {
+	userID, ok := r.Context().Value(KeyAccount{}).(string)
+	if !ok || userID == "" {
+		http.Error(rw, "User id not found in context", http.StatusUnauthorized)
+		return
+	}
 
+	notificationID, err := gocql.ParseUUID(mux.Vars(r)["id"])
 	if err != nil {
-		log.Println("Error subscribing to NATS subject:", err)
+		http.Error(rw, "Invalid UUID format", http.StatusBadRequest)
+		return
+	}
+	ref := models.NotificationRef{ID: notificationID}
+	if value := r.URL.Query().Get("created_at"); value != "" {
+		ref.CreatedAt, err = time.Parse(time.RFC3339Nano, value)
+	} else if r.ContentLength != 0 {
+		err = json.NewDecoder(r.Body).Decode(&ref)
+		ref.ID = notificationID
+	}
+	if err != nil || ref.CreatedAt.IsZero() {
+		http.Error(rw, "created_at is required", http.StatusBadRequest)
+		return
 	}
 
-	subject2Removed := "project.removed2"
-	_, err = nc.Subscribe(subject2Removed, func(msg *nats.Msg) {
-		fmt.Printf("User received removal notification: %s\n", string(msg.Data))
-
-		var data struct {
-			UserID      string `json:"userId"`
-			ProjectName string `json:"projectName"`
-		}
-
-		err := json.Unmarshal(msg.Data, &data)
-		if err != nil {
-			log.Println("Error unmarshalling message:", err)
-			return
-		}
-
-		fmt.Printf("User ID: %s, Project Name: %s\n", data.UserID, data.ProjectName)
-
-		message := fmt.Sprintf("The project '%s' has been deleted, and you have been removed from it.", strings.Title(data.ProjectName))
-
-		notification := models.Notification{
-			UserID:    data.UserID,
-			Message:   message,
-			CreatedAt: time.Now(),
-			Status:    models.Unread,
-		}
-
-		err = n.repo.Create(&notification)
-		if err != nil {
-			n.logger.Print("Error inserting notification:", err)
-			return
-		}
-	})
+	if err := n.applyAction(userID, action, []models.NotificationRef{ref}); err != nil {
+		http.Error(rw, "Failed to update notification", http.StatusInternalServerError)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
 
+// applyAction izvršava akciju i javlja otvorenim tokovima korisnika koja su
+// obaveštenja uklonjena iz liste i koliko je ostalo nepročitanih.
+func (n *NotificationHandler) applyAction(userID, action string, refs []models.NotificationRef) error {
+	var err error
+	switch action {
+	case models.ActionRead:
+		err = n.repo.MarkAsRead(userID, refs)
+	case models.ActionArchive:
+		err = n.repo.Archive(userID, refs)
+	case models.ActionDelete:
+		err = n.repo.Delete(userID, refs)
+	default:
+		err = fmt.Errorf("invalid action: %s", action)
+	}
 	if err != nil {
-		log.Println("Error subscribing to NATS subject:", err)
+		n.logger.Printf("Error applying %s to notifications of user %s: %v", action, userID, err)
+		return err
 	}
 
-	taskRemoved := "task.removed"
-	_, err = nc.Subscribe(taskRemoved, func(msg *nats.Msg) {
-		fmt.Printf("User received removal notification: %s\n", string(msg.Data))
-
-		var data struct {
-			UserID   string `json:"userId"`
-			TaskName string `json:"taskName"`
+	if action != models.ActionRead && n.hub.connected(userID) {
+		ids := make([]gocql.UUID, 0, len(refs))
+		for _, ref := range refs {
+			ids = append(ids, ref.ID)
 		}
+		data, _ := json.Marshal(map[string]interface{}{"action": action, "ids": ids})
+		n.hub.publish(userID, streamEvent{Name: "removed", Data: data})
+	}
+	n.pushUnreadCount(userID)
+	return nil
+}
 
-		err := json.Unmarshal(msg.Data, &data)
-		if err != nil {
-			log.Println("Error unmarshalling message:", err)
-			return
-		}
-
-		fmt.Printf("User ID: %s, Task Name: %s\n", data.UserID, data.TaskName)
-
-		message := fmt.Sprintf("You have been removed from the \"%s\" task", strings.Title(data.TaskName))
+// pendingNotification je obaveštenje napravljeno iz NATS poruke, pre isporuke.
+type pendingNotification struct {
+	eventType    string
+	projectID    string
+	taskID       string
+	notification models.Notification
+}
 
-		notification := models.Notification{
-			UserID:    data.UserID,
+func newPending(eventType, projectID, taskID, userID, message string) pendingNotification {
+	return pendingNotification{
+		eventType: eventType,
+		projectID: projectID,
+		taskID:    taskID,
+		notification: models.Notification{
+			UserID:    userID,
 			Message:   message,
 			CreatedAt: time.Now(),
 			Status:    models.Unread,
-		}
-
-		err = n.repo.Create(&notification)
-		if err != nil {
-			n.logger.Print("Error inserting notification:", err)
-			return
-		}
-	})
-	if err != nil {
-		log.Println("Error subscribing to NATS subject:", err)
+		},
 	}
+}
 
-	statusUpdate := "task.status.update"
-	_, err = nc.Subscribe(statusUpdate, func(msg *nats.Msg) {
-		fmt.Printf("User received notification: %s\n", string(msg.Data))
-
-		var update struct {
-			TaskName   string   `json:"taskName"`
-			TaskStatus string   `json:"taskStatus"`
-			MemberIds  []string `json:"memberIds"`
+// pendingNotifications pretvara poruku iz paketa contracts u obaveštenja za korisnike.
+func pendingNotifications(message contracts.Message) []pendingNotification {
+	pending := []pendingNotification{}
+	switch m := message.(type) {
+	case *contracts.ProjectMemberAdded:
+		text := fmt.Sprintf("You have been added to the \"%s\" project", strings.Title(m.ProjectName))
+		pending = append(pending, newPending(models.EventProjectAdded, m.ProjectID, "", m.UserID, text))
+	case *contracts.ProjectMemberRemoved:
+		text := fmt.Sprintf("You have been removed from the \"%s\" project", strings.Title(m.ProjectName))
+		pending = append(pending, newPending(models.EventProjectRemoved, m.ProjectID, "", m.UserID, text))
+	case *contracts.ProjectDeleted:
+		text := fmt.Sprintf("The project '%s' has been deleted, and you have been removed from it.", strings.Title(m.ProjectName))
+		pending = append(pending, newPending(models.EventProjectDeleted, m.ProjectID, "", m.UserID, text))
+	case *contracts.TaskMemberAdded:
+		text := fmt.Sprintf("You have been added to the \"%s\" task", strings.Title(m.TaskName))
+		pending = append(pending, newPending(models.EventTaskAdded, m.ProjectID, m.TaskID, m.UserID, text))
+	case *contracts.TaskMemberRemoved:
+		text := fmt.Sprintf("You have been removed from the \"%s\" task", strings.Title(m.TaskName))
+		pending = append(pending, newPending(models.EventTaskRemoved, m.ProjectID, m.TaskID, m.UserID, text))
+	case *contracts.TaskStatusChanged:
+		text := fmt.Sprintf("The status of the \"%s\" task has been changed to \"%s\"", strings.Title(m.TaskName), strings.Title(m.TaskStatus))
+		for _, memberID := range m.MemberIDs {
+			pending = append(pending, newPending(models.EventTaskStatus, m.ProjectID, m.TaskID, memberID, text))
 		}
-
-		if err := json.Unmarshal(msg.Data, &update); err != nil {
-			n.logger.Printf("Error unmarshalling task status update message: %v", err)
-			return
+	case *contracts.TaskDependenciesReady:
+		text := fmt.Sprintf("All dependencies of the \"%s\" task are done, it is ready to start", strings.Title(m.TaskName))
+		for _, memberID := range m.MemberIDs {
+			pending = append(pending, newPending(models.EventTaskReady, m.ProjectID, m.TaskID, memberID, text))
 		}
-		fmt.Printf("Received status update for Task %s: %s\n", update.TaskName, update.TaskStatus)
-
-		message := fmt.Sprintf("The status of the \"%s\" task has been changed to \"%s\"", strings.Title(update.TaskName), strings.Title(update.TaskStatus))
-
-		for _, memberID := range update.MemberIds {
-			notification := models.Notification{
-				UserID:    memberID,
-				Message:   message,
-				CreatedAt: time.Now(),
-				Status:    models.Unread,
-			}
-
-			if err := n.repo.Create(&notification); err != nil {
-				n.logger.Printf("Error inserting notification for user %s: %v", memberID, err)
-				continue
-			}
-
-			n.logger.Printf("Notification sent to user %s\n", memberID)
+	case *contracts.TaskAutomationNotified:
+		for _, memberID := range m.MemberIDs {
+			pending = append(pending, newPending(models.EventAutomationNotify, m.ProjectID, m.TaskID, memberID, m.Message))
 		}
-	})
-	if err != nil {
-		log.Println("Error subscribing to NATS subject:", err)
 	}
-
-	select {}
+	return pending
 }
 
 func (uh *NotificationHandler) MiddlewareExtractUserFromHeader(next func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"notification-service/mailer"
+	"notification-service/models"
+	"time"
+)
+
+var notificationEmail = mailer.MustTemplate("notification",
+	"Taskio: {{.Message}}",
+	"Hello {{.Name}},\n\n{{.Message}}\n\nYou can change which notifications you receive by email in your notification preferences.\n")
+
+// GetPreferencesHandler vraća podešavanja obaveštenja prijavljenog korisnika.
+func (n *NotificationHandler) GetPreferencesHandler(rw http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+
+	prefs, err := n.repo.GetPreferences(userID)
+	if err != nil {
+		http.Error(rw, "Error fetching notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(prefs)
+}
+
+// UpdatePreferencesHandler prepisuje podešavanja obaveštenja prijavljenog korisnika.
+func (n *NotificationHandler) UpdatePreferencesHandler(rw http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+
+	prefs := models.DefaultPreferences(userID)
+	if err := json.NewDecoder(r.Body).Decode(prefs); err != nil {
+		http.Error(rw, "Unable to decode json", http.StatusBadRequest)
+		return
+	}
+	prefs.UserID = userID
+	if prefs.Types == nil {
+		prefs.Types = map[string][]string{}
+	}
+	if err := prefs.Validate(); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := n.repo.SavePreferences(prefs); err != nil {
+		http.Error(rw, "Error saving notification preferences", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(prefs)
+}
+
+// notify isporučuje obaveštenje kanalima iz podešavanja korisnika. eventID je
+// ključ događaja iz kog je obaveštenje nastalo, pa ponovna obrada događaja
+// ne šalje isti email dva puta.
+func (n *NotificationHandler) notify(eventID, eventType, projectID, taskID string, notification *models.Notification) error {
+	notification.EventType = eventType
+	notification.ProjectID = projectID
+	notification.TaskID = taskID
+
+	prefs, err := n.repo.GetPreferences(notification.UserID)
+	if err != nil {
+		// Bez podešavanja obaveštenje ide bar u aplikaciju
+		n.logger.Printf("Using default preferences for user %s: %v", notification.UserID, err)
+		prefs = models.DefaultPreferences(notification.UserID)
+	}
+
+	for _, channel := range prefs.Channels(eventType, projectID, taskID, time.Now()) {
+		switch channel {
+		case models.ChannelInApp:
+			if err := n.deliver(notification); err != nil {
+				n.logger.Printf("Error inserting notification for user %s: %v", notification.UserID, err)
+				return err
+			}
+		case models.ChannelEmail:
+			if err := n.queueEmail(eventID, notification.UserID, notification.Message); err != nil {
+				n.logger.Printf("Error queueing email for user %s: %v", notification.UserID, err)
+				return err
+			}
+		case models.ChannelDigest:
+			if err := n.repo.EnqueueDigest(notification); err != nil {
+				n.logger.Printf("Error queueing digest for user %s: %v", notification.UserID, err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CreateMailQueueIndexes pravi indekse reda email poruka.
+func (n *NotificationHandler) CreateMailQueueIndexes(ctx context.Context) error {
+	return n.mail.CreateIndexes(ctx)
+}
+
+// RunMailQueue šalje email poruke iz reda dok se ctx ne završi.
+func (n *NotificationHandler) RunMailQueue(ctx context.Context) {
+	n.mail.Run(ctx)
+}
+
+// queueEmail stavlja email o obaveštenju u trajni red poruka sa ključem
+// eventID:userID.
+func (n *NotificationHandler) queueEmail(eventID, userID, message string) error {
+	if !n.mail.Configured() {
+		n.logger.Printf("Email is not configured, email to user %s skipped", userID)
+		return nil
+	}
+	user, err := fetchUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+
+	data := map[string]string{"Name": user.Name, "Message": message}
+	return n.mail.Enqueue(context.Background(), eventID+":"+userID, user.Email, notificationEmail, data)
+}
+
+type recipient struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// fetchUser dohvata email i ime korisnika iz user-service.
+func fetchUser(userID string) (*recipient, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://user-service:8080/users/%s", userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user from user-service: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch user, status: %d", resp.StatusCode)
+	}
+
+	var user recipient
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode user: %v", err)
+	}
+	return &user, nil
+}
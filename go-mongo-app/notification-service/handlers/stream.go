@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"notification-service/models"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// streamBuffer je broj događaja koji mogu da čekaju na sporog klijenta.
+// Klijent koji zaostane više od toga biva isključen i nastavlja od
+// poslednjeg primljenog Last-Event-ID.
+const streamBuffer = 32
+
+const streamHeartbeat = 25 * time.Second
+
+type streamEvent struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+// notificationHub drži otvorene tokove po korisniku.
+type notificationHub struct {
+	mu      sync.Mutex
+	clients map[string]map[chan streamEvent]struct{}
+}
+
+func newNotificationHub() *notificationHub {
+	return &notificationHub{clients: make(map[string]map[chan streamEvent]struct{})}
+}
+
+func (h *notificationHub) subscribe(userID string) chan streamEvent {
+	ch := make(chan streamEvent, streamBuffer)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[userID] == nil {
+		h.clients[userID] = make(map[chan streamEvent]struct{})
+	}
+	h.clients[userID][ch] = struct{}{}
+	return ch
+}
+
+func (h *notificationHub) unsubscribe(userID string, ch chan streamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[userID][ch]; ok {
+		delete(h.clients[userID], ch)
+		close(ch)
+	}
+	if len(h.clients[userID]) == 0 {
+		delete(h.clients, userID)
+	}
+}
+
+func (h *notificationHub) connected(userID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients[userID]) > 0
+}
+
+func (h *notificationHub) publish(userID string, event streamEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients[userID] {
+		select {
+		case ch <- event:
+		default:
+			// Spor klijent: zatvaramo tok, a on se ponovo povezuje sa Last-Event-ID
+			delete(h.clients[userID], ch)
+			close(ch)
+		}
+	}
+}
+
+// notificationEventID spaja vreme nastanka i ID obaveštenja, tako da se iz
+// Last-Event-ID može nastaviti upit nad particijom korisnika.
+func notificationEventID(notification *models.Notification) string {
+	return fmt.Sprintf("%d_%s", notification.CreatedAt.UnixMilli(), notification.ID)
+}
+
+func parseNotificationEventID(eventID string) (time.Time, gocql.UUID, error) {
+	millis, id, found := strings.Cut(eventID, "_")
+	if !found {
+		return time.Time{}, gocql.UUID{}, fmt.Errorf("invalid event id %q", eventID)
+	}
+	ms, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return time.Time{}, gocql.UUID{}, fmt.Errorf("invalid event id %q", eventID)
+	}
+	uuid, err := gocql.ParseUUID(id)
+	if err != nil {
+		return time.Time{}, gocql.UUID{}, fmt.Errorf("invalid event id %q", eventID)
+	}
+	return time.UnixMilli(ms), uuid, nil
+}
+
+// deliver upisuje obaveštenje i odmah ga šalje povezanim klijentima korisnika.
+func (n *NotificationHandler) deliver(notification *models.Notification) error {
+	if err := n.repo.Create(notification); err != nil {
+		return err
+	}
+	n.pushNotification(notification)
+	return nil
+}
+
+func (n *NotificationHandler) pushNotification(notification *models.Notification) {
+	if !n.hub.connected(notification.UserID) {
+		return
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		n.logger.Println("Error encoding notification for stream:", err)
+		return
+	}
+	n.hub.publish(notification.UserID, streamEvent{ID: notificationEventID(notification), Name: "notification", Data: data})
+	n.pushUnreadCount(notification.UserID)
+}
+
+func (n *NotificationHandler) pushUnreadCount(userID string) {
+	if !n.hub.connected(userID) {
+		return
+	}
+	event, err := n.unreadEvent(userID)
+	if err != nil {
+		return
+	}
+	n.hub.publish(userID, event)
+}
+
+func (n *NotificationHandler) unreadEvent(userID string) (streamEvent, error) {
+	count, err := n.repo.CountUnread(userID)
+	if err != nil {
+		return streamEvent{}, err
+	}
+	data, _ := json.Marshal(map[string]int{"unread": count})
+	return streamEvent{Name: "unread", Data: data}, nil
+}
+
+// MiddlewareStreamAuth dozvoljava da se token pošalje i kao access_token
+// parametar, jer EventSource u pregledaču ne može da postavi zaglavlje.
+func (n *NotificationHandler) MiddlewareStreamAuth(next func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	extract := n.MiddlewareExtractUserFromHeader(next)
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if token := r.URL.Query().Get("access_token"); token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		extract(rw, r)
+	}
+}
+
+// StreamNotifications šalje nova obaveštenja i broj nepročitanih kao
+// Server-Sent Events. Posle prekida klijent šalje Last-Event-ID (ili
+// lastEventId parametar) i dobija sva obaveštenja nastala posle njega.
+func (n *NotificationHandler) StreamNotifications(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value(KeyAccount{}).(string)
+	if !ok || userID == "" {
+		http.Error(rw, "User id not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var since time.Time
+	var lastID gocql.UUID
+	if lastEventID != "" {
+		var err error
+		since, lastID, err = parseNotificationEventID(lastEventID)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(rw)
+	// Tok traje duže od WriteTimeout servera
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		http.Error(rw, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Pretplata pre čitanja propuštenih, da se ništa ne izgubi između
+	events := n.hub.subscribe(userID)
+	defer n.hub.unsubscribe(userID, events)
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+	fmt.Fprint(rw, "retry: 5000\n\n")
+
+	sent := make(map[string]bool)
+	if lastEventID != "" {
+		missed, err := n.repo.FetchSince(userID, since)
+		if err != nil {
+			n.logger.Printf("Error fetching missed notifications for user %s: %v", userID, err)
+			return
+		}
+		for _, notification := range missed {
+			if notification.ID == lastID {
+				continue
+			}
+			data, _ := json.Marshal(notification)
+			id := notificationEventID(notification)
+			writeStreamEvent(rw, streamEvent{ID: id, Name: "notification", Data: data})
+			sent[id] = true
+		}
+	}
+	if event, err := n.unreadEvent(userID); err == nil {
+		writeStreamEvent(rw, event)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			if event.ID != "" && sent[event.ID] {
+				continue
+			}
+			writeStreamEvent(rw, event)
+		case <-heartbeat.C:
+			fmt.Fprint(rw, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(rw http.ResponseWriter, event streamEvent) {
+	if event.ID != "" {
+		fmt.Fprintf(rw, "id: %s\n", event.ID)
+	}
+	fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event.Name, event.Data)
+}
@@ -0,0 +1,104 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"notification-service/db"
+	"shared/mail"
+	"shared/mailqueue"
+	"text/template"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mailer šalje poruke iz šablona preko zajedničkog transporta, pa poruke
+// imaju ista zaglavlja i kodiranje kao poruke user-service-a. Poruke o
+// obaveštenjima idu kroz red u bazi, pa preživljavaju restart servisa.
+type Mailer struct {
+	config    mail.EmailConfig
+	transport mail.Transport
+	queue     *mailqueue.Queue
+}
+
+// FromEnv čita SMTP podešavanja i MAIL_TRANSPORT istih promenljivih kao user-service.
+func FromEnv() *Mailer {
+	config := mail.ConfigFromEnv()
+	transport := mail.TransportFromEnv(config)
+	return &Mailer{config: config, transport: transport, queue: mailqueue.New(mailQueue, transport)}
+}
+
+func mailQueue() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("notification_mail_queue")
+}
+
+// CreateIndexes pravi indekse reda poruka.
+func (m *Mailer) CreateIndexes(ctx context.Context) error {
+	return m.queue.CreateIndexes(ctx)
+}
+
+// Run šalje poruke iz reda dok se ctx ne završi.
+func (m *Mailer) Run(ctx context.Context) {
+	m.queue.Run(ctx)
+}
+
+// Configured proverava da li poruka ima kuda da ode: u fajl ili na SMTP server.
+func (m *Mailer) Configured() bool {
+	if _, ok := m.transport.(mail.FileTransport); ok {
+		return true
+	}
+	return m.config.Configured()
+}
+
+// Template je naslov i telo poruke kao text/template.
+type Template struct {
+	name    string
+	subject *template.Template
+	body    *template.Template
+}
+
+func MustTemplate(name, subject, body string) *Template {
+	return &Template{
+		name:    name,
+		subject: template.Must(template.New(name + ".subject").Parse(subject)),
+		body:    template.Must(template.New(name + ".body").Parse(body)),
+	}
+}
+
+func (t *Template) Render(data interface{}) (subject, body string, err error) {
+	var s, b bytes.Buffer
+	if err := t.subject.Execute(&s, data); err != nil {
+		return "", "", err
+	}
+	if err := t.body.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	return s.String(), b.String(), nil
+}
+
+// Send renderuje šablon i šalje poruku jednom.
+func (m *Mailer) Send(to string, tmpl *Template, data interface{}) error {
+	message, err := m.message(to, tmpl, data)
+	if err != nil {
+		return err
+	}
+	return m.transport.Send(message)
+}
+
+// Enqueue renderuje šablon i stavlja poruku u red. Poruka sa istim ključem
+// key se stavlja samo jednom.
+func (m *Mailer) Enqueue(ctx context.Context, key, to string, tmpl *Template, data interface{}) error {
+	message, err := m.message(to, tmpl, data)
+	if err != nil {
+		return err
+	}
+	return m.queue.EnqueueOnce(ctx, key, tmpl.name, "", message)
+}
+
+func (m *Mailer) message(to string, tmpl *Template, data interface{}) (*mail.Message, error) {
+	subject, body, err := tmpl.Render(data)
+	if err != nil {
+		return nil, fmt.Errorf("rendering email: %v", err)
+	}
+	return &mail.Message{From: m.config.From, To: to, Subject: subject, Text: body}, nil
+}
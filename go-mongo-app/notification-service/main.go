@@ -4,14 +4,17 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"notification-service/db"
 	"notification-service/handlers"
 	"notification-service/repoNotification"
 	"os"
 	"os/signal"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/gocql/gocql"
 	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go/jetstream"
 )
 
 func main() {
@@ -29,6 +32,12 @@ func main() {
 	}
 	defer store.CloseSession()
 
+	// Red email poruka je u MongoDB, kao u user-service
+	if err := db.ConnectToMongo(); err != nil {
+		logger.Fatalf("Error connecting to MongoDB: %v", err)
+	}
+	defer db.DisconnectMongo()
+
 	cluster := gocql.NewCluster("cassandra")
 	cluster.Keyspace = "notifications"
 	cluster.Consistency = gocql.Quorum
@@ -43,29 +52,66 @@ func main() {
 		logger.Fatalf("Error ensuring keyspace and table: %v", err)
 	}
 
-	if err := clearNotifications(session, logger); err != nil {
-		logger.Fatalf("Error clearing notifications: %v", err)
+	if err := store.CreateTables(); err != nil {
+		logger.Fatalf("Error creating notification tables: %v", err)
+	}
+	if err := store.MigrateLegacyNotifications(); err != nil {
+		logger.Fatalf("Error migrating notifications: %v", err)
+	}
+	if err := store.CreatePreferenceTables(); err != nil {
+		logger.Fatalf("Error creating notification preference tables: %v", err)
 	}
 
-	store.CreateTables()
+	if err := store.CreateEventTables(); err != nil {
+		logger.Fatalf("Error creating notification event tables: %v", err)
+	}
 
-	notificationHandler := handlers.NewNotificationHandler(logger, store)
+	// Obaveštenja se brišu posle NOTIFICATION_RETENTION_DAYS dana
+	if err := store.CreateGroupingTables(); err != nil {
+		logger.Fatalf("Error creating notification grouping tables: %v", err)
+	}
 
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				logger.Println("Recovered in NotificationListener:", r)
-			}
-		}()
-		notificationHandler.NotificationListener()
-		logger.Println("NotificationListener invoked successfully")
-	}()
+	nc, err := handlers.Conn()
+	if err != nil {
+		logger.Fatalf("Error connecting to NATS: %v", err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		logger.Fatalf("Error creating JetStream context: %v", err)
+	}
+
+	notificationHandler := handlers.NewNotificationHandler(logger, store, js)
+
+	if err := notificationHandler.CreateMailQueueIndexes(context.Background()); err != nil {
+		logger.Fatalf("Error creating mail queue indexes: %v", err)
+	}
+	go notificationHandler.RunMailQueue(context.Background())
+
+	// Obaveštenja stižu iz JetStream stream-ova preko trajnih consumer-a
+	if err := notificationHandler.NotificationListener(context.Background()); err != nil {
+		logger.Fatalf("Error starting NATS consumers: %v", err)
+	}
+
+	// Pregledi obaveštenja se šalju emailom u sat koji je korisnik izabrao
+	go notificationHandler.RunDigestScheduler(context.Background())
 
 	// Set up HTTP router
 	r := mux.NewRouter()
+	r.HandleFunc("/notifications/stream", notificationHandler.MiddlewareStreamAuth(notificationHandler.RoleRequired(notificationHandler.StreamNotifications, "Member", "Manager"))).Methods("GET")
+	r.HandleFunc("/notifications/preferences", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.GetPreferencesHandler, "Member", "Manager"))).Methods("GET")
+	r.HandleFunc("/notifications/preferences", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.UpdatePreferencesHandler, "Member", "Manager"))).Methods("PUT")
+	r.HandleFunc("/notifications/digest/preview", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.PreviewDigestHandler, "Member", "Manager"))).Methods("GET")
+	r.HandleFunc("/notifications/digest/send", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.SendDigestHandler, "Member", "Manager"))).Methods("POST")
+	r.HandleFunc("/notifications/admin/dead-letters", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.OperatorOnly(notificationHandler.ListDeadLettersHandler), "Manager"))).Methods("GET")
+	r.HandleFunc("/notifications/admin/dead-letters/{stream}/{sequence}/retry", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.OperatorOnly(notificationHandler.RetryDeadLetterHandler), "Manager"))).Methods("POST")
+	r.HandleFunc("/notifications/admin/replay", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.OperatorOnly(notificationHandler.ReplayHandler), "Manager"))).Methods("POST")
 	r.HandleFunc("/notifications/user/{id}", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.FetchNotificationsByUser, "Member", "Manager"))).Methods("GET", "OPTIONS")
 	r.HandleFunc("/notifications", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.CreateNotification, "Member"))).Methods("POST")
 	r.HandleFunc("/notifications/all", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.FetchAllNotifications, "Member"))).Methods("GET")
+	r.HandleFunc("/notifications/bulk", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.BulkNotificationsHandler, "Member", "Manager"))).Methods("POST")
+	r.HandleFunc("/notifications/{id}/archive", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.ArchiveNotification, "Member", "Manager"))).Methods("PUT")
+	r.HandleFunc("/notifications/{id}", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.DeleteNotification, "Member", "Manager"))).Methods("DELETE")
 	r.HandleFunc("/notifications/{id}/mark", notificationHandler.MiddlewareExtractUserFromHeader(notificationHandler.RoleRequired(notificationHandler.MarkNotificationsAsRead, "Member"))).Methods("PUT", "OPTIONS")
 
 	// Apply CORS middleware
@@ -105,6 +151,7 @@ func CORS(next http.Handler) http.Handler {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Next-Page")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -112,13 +159,3 @@ func CORS(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
-
-func clearNotifications(session *gocql.Session, logger *log.Logger) error {
-	logger.Println("Clearing notifications table...")
-	err := session.Query("TRUNCATE notifications.notifications").Exec()
-	if err != nil {
-		return err
-	}
-	logger.Println("Notifications table cleared.")
-	return nil
-}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// DeadLetter je NATS poruka koju servis nije uspeo da obradi ni posle svih
+// ponovnih isporuka. Čuva se da bi operater mogao da je pregleda i ponovi.
+type DeadLetter struct {
+	Stream     string    `json:"stream"`
+	Sequence   uint64    `json:"sequence"`
+	EventID    string    `json:"eventId"`
+	Subject    string    `json:"subject"`
+	Data       string    `json:"data"`
+	Error      string    `json:"error"`
+	Deliveries int       `json:"deliveries"`
+	FailedAt   time.Time `json:"failedAt"`
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestSettings određuju kada korisnik dobija pregled obaveštenja emailom.
+// Hour je sat slanja u vremenskoj zoni korisnika, a Weekday dan za nedeljni pregled.
+type DigestSettings struct {
+	Frequency string `json:"frequency"`
+	Hour      int    `json:"hour"`
+	Weekday   string `json:"weekday,omitempty"`
+	Timezone  string `json:"timezone,omitempty"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (d *DigestSettings) Validate() error {
+	switch d.Frequency {
+	case DigestOff, DigestDaily:
+	case DigestWeekly:
+		if _, ok := weekdays[strings.ToLower(d.Weekday)]; !ok {
+			return fmt.Errorf("weekly digest requires a weekday")
+		}
+	default:
+		return fmt.Errorf("digest.frequency must be one of off, daily, weekly")
+	}
+	if d.Hour < 0 || d.Hour > 23 {
+		return fmt.Errorf("digest.hour must be between 0 and 23")
+	}
+	if _, err := time.LoadLocation(d.Timezone); err != nil {
+		return fmt.Errorf("invalid digest.timezone: %s", d.Timezone)
+	}
+	return nil
+}
+
+func (d *DigestSettings) Enabled() bool {
+	return d != nil && (d.Frequency == DigestDaily || d.Frequency == DigestWeekly)
+}
+
+// Period je dužina perioda koji pregled pokriva.
+func (d *DigestSettings) Period() time.Duration {
+	if d.Frequency == DigestWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// LastScheduled vraća poslednji zakazani trenutak slanja koji nije posle now.
+func (d *DigestSettings) LastScheduled(now time.Time) time.Time {
+	location, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := now.In(location)
+	at := time.Date(local.Year(), local.Month(), local.Day(), d.Hour, 0, 0, 0, location)
+	if at.After(local) {
+		at = at.AddDate(0, 0, -1)
+	}
+	if d.Frequency == DigestWeekly {
+		weekday := weekdays[strings.ToLower(d.Weekday)]
+		for at.Weekday() != weekday {
+			at = at.AddDate(0, 0, -1)
+		}
+	}
+	return at
+}
+
+// Due govori da li je pregled trebalo poslati posle lastSent.
+func (d *DigestSettings) Due(lastSent, now time.Time) bool {
+	return d.Enabled() && d.LastScheduled(now).After(lastSent)
+}
+
+// OverdueTask je nezavršen zadatak čiji je rok prošao.
+type OverdueTask struct {
+	TaskID       string `json:"taskId"`
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	ProjectID    string `json:"projectId"`
+	ProjectTitle string `json:"projectTitle"`
+	DueDate      string `json:"dueDate"`
+}
+
+type DigestItem struct {
+	Message   string    `json:"message"`
+	EventType string    `json:"event_type,omitempty"`
+	TaskID    string    `json:"task_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DigestProject struct {
+	ProjectID string       `json:"project_id"`
+	Title     string       `json:"title"`
+	Items     []DigestItem `json:"items"`
+}
+
+// Digest je sadržaj jednog pregleda obaveštenja.
+type Digest struct {
+	UserID        string          `json:"user_id"`
+	Name          string          `json:"name"`
+	Frequency     string          `json:"frequency"`
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	Projects      []DigestProject `json:"projects"`
+	NewlyAssigned []DigestItem    `json:"newly_assigned"`
+	Overdue       []OverdueTask   `json:"overdue"`
+}
+
+func (d *Digest) Empty() bool {
+	return len(d.Projects) == 0 && len(d.NewlyAssigned) == 0 && len(d.Overdue) == 0
+}
@@ -22,6 +22,54 @@ type Notification struct {
 	CreatedAt time.Time          `json:"created_at"`
 	IsActive  bool               `json:"is_active"`
 	Status    NotificationStatus `json:"status"`
+	EventType string             `json:"event_type,omitempty"`
+	ProjectID string             `json:"project_id,omitempty"`
+	TaskID    string             `json:"task_id,omitempty"`
+	// Count je broj sažetih obaveštenja o istom zadatku, a Replaces ID
+	// ranijeg obaveštenja koje ovo zamenjuje u listi.
+	Count    int         `json:"count,omitempty"`
+	Archived bool        `json:"archived"`
+	Replaces *gocql.UUID `json:"replaces,omitempty"`
+}
+
+// NotificationRef određuje jedno obaveštenje u particiji korisnika.
+type NotificationRef struct {
+	ID        gocql.UUID `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Akcije nad više obaveštenja odjednom.
+const (
+	ActionRead    = "read"
+	ActionArchive = "archive"
+	ActionDelete  = "delete"
+)
+
+// BulkRequest je akcija nad izabranim obaveštenjima korisnika.
+type BulkRequest struct {
+	Action        string            `json:"action"`
+	Notifications []NotificationRef `json:"notifications"`
+}
+
+// MaxBulkNotifications ograničava broj obaveštenja u jednom zahtevu.
+const MaxBulkNotifications = 500
+
+func (b *BulkRequest) Validate() error {
+	if b.Action != ActionRead && b.Action != ActionArchive && b.Action != ActionDelete {
+		return fmt.Errorf("invalid action: %s", b.Action)
+	}
+	if len(b.Notifications) == 0 {
+		return fmt.Errorf("notifications cannot be empty")
+	}
+	if len(b.Notifications) > MaxBulkNotifications {
+		return fmt.Errorf("at most %d notifications can be changed at once", MaxBulkNotifications)
+	}
+	for _, ref := range b.Notifications {
+		if ref.CreatedAt.IsZero() {
+			return fmt.Errorf("created_at is required for notification %s", ref.ID)
+		}
+	}
+	return nil
 }
 
 type Notifications []*Notification
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Kanali kojima obaveštenje može da stigne do korisnika.
+const (
+	ChannelInApp  = "in_app"
+	ChannelEmail  = "email"
+	ChannelDigest = "digest"
+)
+
+// Vrste obaveštenja, po jedna za svaki NATS subjekat koji servis obrađuje.
+const (
+	EventProjectAdded     = "project_added"
+	EventProjectRemoved   = "project_removed"
+	EventProjectDeleted   = "project_deleted"
+	EventTaskAdded        = "task_added"
+	EventTaskRemoved      = "task_removed"
+	EventTaskStatus       = "task_status"
+	EventTaskReady        = "task_ready"
+	EventAutomationNotify = "automation"
+)
+
+var EventTypes = []string{
+	EventProjectAdded,
+	EventProjectRemoved,
+	EventProjectDeleted,
+	EventTaskAdded,
+	EventTaskRemoved,
+	EventTaskStatus,
+	EventTaskReady,
+	EventAutomationNotify,
+}
+
+// QuietHours je vreme u kom se ne šalju email poruke; one se tada čuvaju
+// za pregled (digest). Start posle End znači da period prelazi ponoć.
+type QuietHours struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// NotificationPreferences su podešavanja obaveštenja jednog korisnika.
+// Types određuje kanale po vrsti obaveštenja; prazna lista isključuje tu
+// vrstu, a vrsta koje nema u mapi koristi DefaultChannels.
+type NotificationPreferences struct {
+	UserID          string              `json:"user_id"`
+	DefaultChannels []string            `json:"default_channels"`
+	Types           map[string][]string `json:"types"`
+	QuietHours      *QuietHours         `json:"quiet_hours,omitempty"`
+	MutedProjects   []string            `json:"muted_projects"`
+	MutedTasks      []string            `json:"muted_tasks"`
+	Digest          *DigestSettings     `json:"digest,omitempty"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+}
+
+// DefaultPreferences važe za korisnika koji nije sačuvao podešavanja.
+func DefaultPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		UserID:          userID,
+		DefaultChannels: []string{ChannelInApp},
+		Types:           map[string][]string{},
+		MutedProjects:   []string{},
+		MutedTasks:      []string{},
+	}
+}
+
+func (p *NotificationPreferences) Validate() error {
+	if err := validateChannels(p.DefaultChannels); err != nil {
+		return err
+	}
+	for eventType, channels := range p.Types {
+		if !isEventType(eventType) {
+			return fmt.Errorf("unknown notification type: %s", eventType)
+		}
+		if err := validateChannels(channels); err != nil {
+			return err
+		}
+	}
+	if p.Digest != nil {
+		if err := p.Digest.Validate(); err != nil {
+			return err
+		}
+	}
+	if p.QuietHours != nil {
+		if _, err := time.Parse("15:04", p.QuietHours.Start); err != nil {
+			return fmt.Errorf("quiet_hours.start must be HH:MM")
+		}
+		if _, err := time.Parse("15:04", p.QuietHours.End); err != nil {
+			return fmt.Errorf("quiet_hours.end must be HH:MM")
+		}
+		if _, err := time.LoadLocation(p.QuietHours.Timezone); err != nil {
+			return fmt.Errorf("invalid quiet_hours.timezone: %s", p.QuietHours.Timezone)
+		}
+	}
+	return nil
+}
+
+// Channels vraća kanale kojima se obaveštenje date vrste isporučuje u
+// trenutku now. Utišan projekat ili zadatak ne dobija ništa, a tokom tihih
+// sati email prelazi u digest. Bez uključenog pregleda digest se isporučuje
+// u aplikaciju, da obaveštenje ne bi ostalo nepročitano u redu.
+func (p *NotificationPreferences) Channels(eventType, projectID, taskID string, now time.Time) []string {
+	if (projectID != "" && contains(p.MutedProjects, projectID)) || (taskID != "" && contains(p.MutedTasks, taskID)) {
+		return nil
+	}
+
+	channels, ok := p.Types[eventType]
+	if !ok {
+		channels = p.DefaultChannels
+	}
+	quiet := p.InQuietHours(now)
+
+	out := []string{}
+	for _, channel := range channels {
+		if channel == ChannelEmail && quiet {
+			channel = ChannelDigest
+		}
+		if channel == ChannelDigest && !p.Digest.Enabled() {
+			channel = ChannelInApp
+		}
+		if !contains(out, channel) {
+			out = append(out, channel)
+		}
+	}
+	return out
+}
+
+func (p *NotificationPreferences) InQuietHours(now time.Time) bool {
+	if p.QuietHours == nil {
+		return false
+	}
+	location, err := time.LoadLocation(p.QuietHours.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err1 := time.Parse("15:04", p.QuietHours.Start)
+	end, err2 := time.Parse("15:04", p.QuietHours.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from <= to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+func validateChannels(channels []string) error {
+	for _, channel := range channels {
+		if channel != ChannelInApp && channel != ChannelEmail && channel != ChannelDigest {
+			return fmt.Errorf("unknown channel: %s", channel)
+		}
+	}
+	return nil
+}
+
+func isEventType(eventType string) bool {
+	return contains(EventTypes, eventType)
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
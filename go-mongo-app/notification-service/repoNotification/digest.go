@@ -0,0 +1,139 @@
+package repoNotification
+
+import (
+	"notification-service/models"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// DigestSubscriber je korisnik sa uključenim pregledom obaveštenja.
+type DigestSubscriber struct {
+	UserID string
+	Digest models.DigestSettings
+}
+
+// DigestSubscribers vraća korisnike koji primaju dnevni ili nedeljni pregled.
+func (repo *NotificationRepo) DigestSubscribers() ([]DigestSubscriber, error) {
+	var subscribers []DigestSubscriber
+
+	iter := repo.session.Query(`
+		SELECT user_id, digest_frequency, digest_hour, digest_weekday, digest_timezone
+		FROM notification_preferences`).Iter()
+
+	for {
+		var s DigestSubscriber
+		if !iter.Scan(&s.UserID, &s.Digest.Frequency, &s.Digest.Hour, &s.Digest.Weekday, &s.Digest.Timezone) {
+			break
+		}
+		if s.Digest.Enabled() {
+			subscribers = append(subscribers, s)
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		repo.logger.Println("Error fetching digest subscribers:", err)
+		return nil, err
+	}
+	return subscribers, nil
+}
+
+// LastDigestSent vraća vreme poslednjeg pregleda; nil ako pregled još nije poslat.
+func (repo *NotificationRepo) LastDigestSent(userID string) (*time.Time, error) {
+	var lastSent time.Time
+	err := repo.session.Query(`SELECT last_sent_at FROM notification_digests WHERE user_id = ?`, userID).
+		Consistency(gocql.Quorum).Scan(&lastSent)
+	if err == gocql.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lastSent, nil
+}
+
+// ClaimDigest beleži slanje pregleda samo ako se poslednje slanje nije
+// promenilo od čitanja, pa više instanci servisa ne šalje isti pregled.
+func (repo *NotificationRepo) ClaimDigest(userID string, previous *time.Time, sentAt time.Time) (bool, error) {
+	var query *gocql.Query
+	if previous == nil {
+		query = repo.session.Query(`INSERT INTO notification_digests (user_id, last_sent_at) VALUES (?, ?) IF NOT EXISTS`, userID, sentAt)
+	} else {
+		query = repo.session.Query(`UPDATE notification_digests SET last_sent_at = ? WHERE user_id = ? IF last_sent_at = ?`, sentAt, userID, *previous)
+	}
+	applied, err := query.SerialConsistency(gocql.Serial).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		repo.logger.Println("Error claiming digest:", err)
+		return false, err
+	}
+	return applied, nil
+}
+
+// ReleaseDigest vraća poslednje slanje na previous kada zauzet pregled nije
+// poslat, da ga sledeća provera pošalje ponovo. Ništa ne menja ako je u
+// međuvremenu zabeleženo novo slanje.
+func (repo *NotificationRepo) ReleaseDigest(userID string, previous *time.Time, claimedAt time.Time) error {
+	var query *gocql.Query
+	if previous == nil {
+		query = repo.session.Query(`DELETE FROM notification_digests WHERE user_id = ? IF last_sent_at = ?`, userID, claimedAt)
+	} else {
+		query = repo.session.Query(`UPDATE notification_digests SET last_sent_at = ? WHERE user_id = ? IF last_sent_at = ?`, *previous, userID, claimedAt)
+	}
+	if _, err := query.SerialConsistency(gocql.Serial).MapScanCAS(map[string]interface{}{}); err != nil {
+		repo.logger.Println("Error releasing digest:", err)
+		return err
+	}
+	return nil
+}
+
+// FetchDigestItems vraća nepročitana obaveštenja nastala posle since i
+// obaveštenja iz reda za pregled, sve do until.
+func (repo *NotificationRepo) FetchDigestItems(userID string, since, until time.Time) ([]*models.Notification, error) {
+	var items []*models.Notification
+
+	iter := repo.session.Query(`
+		SELECT message, created_at, status, event_type, project_id, task_id
+		FROM `+tableByUser+`
+		WHERE user_id = ? AND created_at > ? AND created_at <= ?`, userID, since, until).Iter()
+	for {
+		notification := models.Notification{UserID: userID}
+		if !iter.Scan(&notification.Message, &notification.CreatedAt, &notification.Status, &notification.EventType, &notification.ProjectID, &notification.TaskID) {
+			break
+		}
+		if notification.Status == models.Unread {
+			items = append(items, &notification)
+		}
+	}
+	if err := iter.Close(); err != nil {
+		repo.logger.Println("Error fetching unread notifications for digest:", err)
+		return nil, err
+	}
+
+	iter = repo.session.Query(`
+		SELECT message, created_at, event_type, project_id, task_id
+		FROM notification_digest_queue
+		WHERE user_id = ? AND created_at <= ?`, userID, until).Iter()
+	for {
+		notification := models.Notification{UserID: userID, Status: models.Unread}
+		if !iter.Scan(&notification.Message, &notification.CreatedAt, &notification.EventType, &notification.ProjectID, &notification.TaskID) {
+			break
+		}
+		items = append(items, &notification)
+	}
+	if err := iter.Close(); err != nil {
+		repo.logger.Println("Error fetching digest queue:", err)
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// ClearDigestQueue briše obaveštenja iz reda koja su ušla u poslati pregled.
+func (repo *NotificationRepo) ClearDigestQueue(userID string, until time.Time) error {
+	err := repo.session.Query(`DELETE FROM notification_digest_queue WHERE user_id = ? AND created_at <= ?`, userID, until).Exec()
+	if err != nil {
+		repo.logger.Println("Error clearing digest queue:", err)
+		return err
+	}
+	return nil
+}
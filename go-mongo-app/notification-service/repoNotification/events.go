@@ -0,0 +1,90 @@
+package repoNotification
+
+import (
+	"notification-service/models"
+	"strconv"
+)
+
+// processedEventTTL je duže od MaxAge stream-ova, pa ponovljena poruka iz
+// stream-a uvek nađe zapis da je već obrađena.
+const processedEventTTL = 14 * 24 * 60 * 60
+
+// CreateEventTables pravi tabelu obrađenih NATS poruka i tabelu poruka koje
+// nisu mogle da se obrade (dead letter).
+func (repo *NotificationRepo) CreateEventTables() error {
+	err := repo.session.Query(`CREATE TABLE IF NOT EXISTS notification_processed_events (
+		event_id TEXT,
+		user_id TEXT,
+		PRIMARY KEY (event_id, user_id)
+	) WITH default_time_to_live = ` + strconv.Itoa(processedEventTTL)).Exec()
+	if err != nil {
+		repo.logger.Println("Error creating notification_processed_events table:", err)
+		return err
+	}
+
+	err = repo.session.Query(`CREATE TABLE IF NOT EXISTS notification_dead_letters (
+		stream TEXT,
+		sequence BIGINT,
+		event_id TEXT,
+		subject TEXT,
+		data TEXT,
+		error TEXT,
+		deliveries INT,
+		failed_at TIMESTAMP,
+		PRIMARY KEY (stream, sequence)
+	)`).Exec()
+	if err != nil {
+		repo.logger.Println("Error creating notification_dead_letters table:", err)
+	}
+	return err
+}
+
+// IsEventProcessed proverava da li je korisnik već obavešten o poruci eventID.
+func (repo *NotificationRepo) IsEventProcessed(eventID, userID string) (bool, error) {
+	var count int
+	err := repo.session.Query(`SELECT count(*) FROM notification_processed_events WHERE event_id = ? AND user_id = ?`,
+		eventID, userID).Scan(&count)
+	return count > 0, err
+}
+
+func (repo *NotificationRepo) MarkEventProcessed(eventID, userID string) error {
+	return repo.session.Query(`INSERT INTO notification_processed_events (event_id, user_id) VALUES (?, ?)`,
+		eventID, userID).Exec()
+}
+
+func (repo *NotificationRepo) SaveDeadLetter(letter *models.DeadLetter) error {
+	return repo.session.Query(`INSERT INTO notification_dead_letters
+		(stream, sequence, event_id, subject, data, error, deliveries, failed_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		letter.Stream, int64(letter.Sequence), letter.EventID, letter.Subject, letter.Data, letter.Error, letter.Deliveries, letter.FailedAt).Exec()
+}
+
+// DeadLetters vraća neobrađene poruke stream-a, od najstarije.
+func (repo *NotificationRepo) DeadLetters(stream string) ([]models.DeadLetter, error) {
+	iter := repo.session.Query(`SELECT sequence, event_id, subject, data, error, deliveries, failed_at
+		FROM notification_dead_letters WHERE stream = ?`, stream).Iter()
+
+	letters := []models.DeadLetter{}
+	var sequence int64
+	letter := models.DeadLetter{Stream: stream}
+	for iter.Scan(&sequence, &letter.EventID, &letter.Subject, &letter.Data, &letter.Error, &letter.Deliveries, &letter.FailedAt) {
+		letter.Sequence = uint64(sequence)
+		letters = append(letters, letter)
+	}
+	return letters, iter.Close()
+}
+
+func (repo *NotificationRepo) GetDeadLetter(stream string, sequence uint64) (*models.DeadLetter, error) {
+	letter := models.DeadLetter{Stream: stream, Sequence: sequence}
+	err := repo.session.Query(`SELECT event_id, subject, data, error, deliveries, failed_at
+		FROM notification_dead_letters WHERE stream = ? AND sequence = ?`, stream, int64(sequence)).
+		Scan(&letter.EventID, &letter.Subject, &letter.Data, &letter.Error, &letter.Deliveries, &letter.FailedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &letter, nil
+}
+
+func (repo *NotificationRepo) DeleteDeadLetter(stream string, sequence uint64) error {
+	return repo.session.Query(`DELETE FROM notification_dead_letters WHERE stream = ? AND sequence = ?`,
+		stream, int64(sequence)).Exec()
+}
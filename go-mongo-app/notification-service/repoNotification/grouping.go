@@ -0,0 +1,285 @@
+package repoNotification
+
+import (
+	"fmt"
+	"notification-service/models"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// defaultRetentionDays je koliko dugo se čuvaju obaveštenja ako
+// NOTIFICATION_RETENTION_DAYS nije postavljen. Nula isključuje brisanje.
+const defaultRetentionDays = 90
+
+func retentionFromEnv() time.Duration {
+	days := defaultRetentionDays
+	if value := os.Getenv("NOTIFICATION_RETENTION_DAYS"); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// CreateGroupingTables pravi tabelu grupa sažetih obaveštenja i postavlja rok
+// čuvanja obaveštenja.
+func (repo *NotificationRepo) CreateGroupingTables() error {
+	err := repo.session.Query(`CREATE TABLE IF NOT EXISTS notification_groups (
+		user_id TEXT,
+		group_key TEXT,
+		created_at TIMESTAMP,
+		id UUID,
+		count INT,
+		PRIMARY KEY (user_id, group_key)
+	)`).Exec()
+	if err != nil {
+		repo.logger.Println("Error creating notification_groups table:", err)
+		return err
+	}
+
+	ttl := int(repo.retention.Seconds())
+	for _, table := range []string{tableByUser, tableArchived, tableByID, "notification_groups"} {
+		if err := repo.session.Query(fmt.Sprintf("ALTER TABLE %s WITH default_time_to_live = %d", table, ttl)).Exec(); err != nil {
+			repo.logger.Printf("Error setting retention on %s: %v", table, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// ttlFor vraća preostalo vreme čuvanja obaveštenja nastalog u createdAt.
+// Izmene statusa koriste isti rok, da pročitano obaveštenje ne bi živelo
+// duže od reda u koji je upisano.
+func (repo *NotificationRepo) ttlFor(createdAt time.Time) int {
+	if repo.retention == 0 {
+		return 0
+	}
+	remaining := int(time.Until(createdAt.Add(repo.retention)).Seconds())
+	if remaining < 1 {
+		return 1
+	}
+	return remaining
+}
+
+// groupKey određuje koja se obaveštenja sažimaju: ista vrsta obaveštenja o
+// istom zadatku. Obaveštenja bez zadatka se ne sažimaju.
+func groupKey(notification *models.Notification) string {
+	if notification.TaskID == "" || notification.EventType == "" {
+		return ""
+	}
+	return notification.TaskID + ":" + notification.EventType
+}
+
+// groupAttempts ograničava ponovne pokušaje kada grupu istovremeno menja
+// drugo obaveštenje.
+const groupAttempts = 5
+
+// claimGroup upisuje novo obaveštenje kao poslednje u grupi key i vraća
+// nepročitano obaveštenje koje ono zamenjuje, zajedno sa brojem sažetih u
+// njemu. Red grupe se menja lakom transakcijom (LWT) uslovljenom prethodnim
+// stanjem, pa dva istovremena obaveštenja ne mogu da zamene isto prethodno.
+func (repo *NotificationRepo) claimGroup(notification *models.Notification, key string, ttl int) (*models.NotificationRef, int, error) {
+	for attempt := 0; attempt < groupAttempts; attempt++ {
+		current, previous, count, err := repo.openGroup(notification.UserID, key)
+		if err != nil {
+			return nil, 0, err
+		}
+		newCount := 1
+		if previous != nil {
+			newCount = count + 1
+		}
+
+		var query *gocql.Query
+		if current == nil {
+			query = repo.session.Query(`INSERT INTO notification_groups (user_id, group_key, created_at, id, count) VALUES (?, ?, ?, ?, ?) IF NOT EXISTS USING TTL ?`,
+				notification.UserID, key, notification.CreatedAt, notification.ID, newCount, ttl)
+		} else {
+			query = repo.session.Query(`UPDATE notification_groups USING TTL ? SET created_at = ?, id = ?, count = ? WHERE user_id = ? AND group_key = ? IF id = ?`,
+				ttl, notification.CreatedAt, notification.ID, newCount, notification.UserID, key, *current)
+		}
+		applied, err := query.SerialConsistency(gocql.Serial).MapScanCAS(map[string]interface{}{})
+		if err != nil {
+			return nil, 0, err
+		}
+		if applied {
+			return previous, count, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("notification group %s of user %s is changing too often", key, notification.UserID)
+}
+
+// openGroup vraća ID poslednjeg obaveštenja grupe (nil ako grupa ne postoji),
+// to obaveštenje ako je još nepročitano i broj obaveštenja sažetih u njemu.
+func (repo *NotificationRepo) openGroup(userID, key string) (*gocql.UUID, *models.NotificationRef, int, error) {
+	var ref models.NotificationRef
+	var count int
+	err := repo.session.Query(`SELECT created_at, id, count FROM notification_groups WHERE user_id = ? AND group_key = ?`,
+		userID, key).Scan(&ref.CreatedAt, &ref.ID, &count)
+	if err == gocql.ErrNotFound {
+		return nil, nil, 0, nil
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	current := ref.ID
+
+	// Arhivirano obaveštenje više nije u listi korisnika, pa se ne nalazi
+	var status string
+	err = repo.session.Query(`SELECT status FROM `+tableByUser+` WHERE user_id = ? AND created_at = ? AND id = ?`,
+		userID, ref.CreatedAt, ref.ID).Scan(&status)
+	if err == gocql.ErrNotFound {
+		return &current, nil, 0, nil
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if models.NotificationStatus(status) != models.Unread {
+		return &current, nil, 0, nil
+	}
+	if count < 1 {
+		count = 1
+	}
+	return &current, &ref, count, nil
+}
+
+// batchSize je broj obaveštenja u jednoj seriji izmena.
+const batchSize = 50
+
+// fetchRefs vraća obaveštenja iz tabele table koja još postoje, da izmena ne
+// bi upisala red za obrisano ili isteklo obaveštenje.
+func (repo *NotificationRepo) fetchRefs(table, userID string, refs []models.NotificationRef) ([]*models.Notification, error) {
+	wanted := make(map[gocql.UUID]bool)
+	for _, ref := range refs {
+		wanted[ref.ID] = true
+	}
+
+	found := []*models.Notification{}
+	for start := 0; start < len(refs); start += batchSize {
+		end := start + batchSize
+		if end > len(refs) {
+			end = len(refs)
+		}
+		times := []time.Time{}
+		seen := make(map[int64]bool)
+		for _, ref := range refs[start:end] {
+			if millis := ref.CreatedAt.UnixMilli(); !seen[millis] {
+				seen[millis] = true
+				times = append(times, ref.CreatedAt)
+			}
+		}
+
+		iter := repo.session.Query(`SELECT `+notificationColumns+` FROM `+table+` WHERE user_id = ? AND created_at IN ?`, userID, times).Iter()
+		for {
+			notification, ok := scanNotification(iter)
+			if !ok {
+				break
+			}
+			if wanted[notification.ID] {
+				delete(wanted, notification.ID)
+				found = append(found, notification)
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+func refsOf(notifications []*models.Notification) []models.NotificationRef {
+	refs := make([]models.NotificationRef, 0, len(notifications))
+	for _, notification := range notifications {
+		refs = append(refs, models.NotificationRef{ID: notification.ID, CreatedAt: notification.CreatedAt})
+	}
+	return refs
+}
+
+func (repo *NotificationRepo) executeInBatches(kind gocql.BatchType, refs []models.NotificationRef, add func(*gocql.Batch, models.NotificationRef)) error {
+	for start := 0; start < len(refs); start += batchSize {
+		end := start + batchSize
+		if end > len(refs) {
+			end = len(refs)
+		}
+		batch := repo.session.NewBatch(kind)
+		for _, ref := range refs[start:end] {
+			add(batch, ref)
+		}
+		if err := repo.session.ExecuteBatch(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarkAsRead označava izabrana obaveštenja korisnika kao pročitana.
+func (repo *NotificationRepo) MarkAsRead(userID string, refs []models.NotificationRef) error {
+	stored, err := repo.fetchRefs(tableByUser, userID, refs)
+	if err == nil {
+		err = repo.executeInBatches(gocql.UnloggedBatch, refsOf(stored), func(batch *gocql.Batch, ref models.NotificationRef) {
+			batch.Query(`UPDATE `+tableByUser+` USING TTL ? SET status = ? WHERE user_id = ? AND created_at = ? AND id = ?`,
+				repo.ttlFor(ref.CreatedAt), string(models.Read), userID, ref.CreatedAt, ref.ID)
+		})
+	}
+	if err != nil {
+		repo.logger.Println("Error marking notifications as read:", err)
+	}
+	return err
+}
+
+// Archive premešta obaveštenja iz liste korisnika u arhivu. Arhivirano
+// obaveštenje je ujedno i pročitano, pa se ne računa u nepročitana ni u pregled.
+func (repo *NotificationRepo) Archive(userID string, refs []models.NotificationRef) error {
+	stored, err := repo.fetchRefs(tableByUser, userID, refs)
+	if err != nil {
+		repo.logger.Println("Error archiving notifications:", err)
+		return err
+	}
+
+	for start := 0; start < len(stored); start += batchSize {
+		end := start + batchSize
+		if end > len(stored) {
+			end = len(stored)
+		}
+		batch := repo.session.NewBatch(gocql.LoggedBatch)
+		for _, n := range stored[start:end] {
+			ttl := repo.ttlFor(n.CreatedAt)
+			batch.Query(`INSERT INTO `+tableArchived+` (`+notificationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`,
+				n.ID, n.UserID, n.Message, n.CreatedAt, string(models.Read), n.EventType, n.ProjectID, n.TaskID, n.Count, ttl)
+			batch.Query(`DELETE FROM `+tableByUser+` WHERE user_id = ? AND created_at = ? AND id = ?`, userID, n.CreatedAt, n.ID)
+			batch.Query(`UPDATE `+tableByID+` USING TTL ? SET archived = true WHERE id = ?`, ttl, n.ID)
+		}
+		if err := repo.session.ExecuteBatch(batch); err != nil {
+			repo.logger.Println("Error archiving notifications:", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete trajno briše obaveštenja korisnika, iz liste ili iz arhive.
+func (repo *NotificationRepo) Delete(userID string, refs []models.NotificationRef) error {
+	active, err := repo.fetchRefs(tableByUser, userID, refs)
+	var archived []*models.Notification
+	if err == nil {
+		archived, err = repo.fetchRefs(tableArchived, userID, refs)
+	}
+	for _, table := range []struct {
+		name          string
+		notifications []*models.Notification
+	}{{tableByUser, active}, {tableArchived, archived}} {
+		if err != nil {
+			break
+		}
+		err = repo.executeInBatches(gocql.LoggedBatch, refsOf(table.notifications), func(batch *gocql.Batch, ref models.NotificationRef) {
+			batch.Query(`DELETE FROM `+table.name+` WHERE user_id = ? AND created_at = ? AND id = ?`, userID, ref.CreatedAt, ref.ID)
+			batch.Query(`DELETE FROM `+tableByID+` WHERE id = ?`, ref.ID)
+		})
+	}
+	if err != nil {
+		repo.logger.Println("Error deleting notifications:", err)
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package repoNotification
+
+import (
+	"notification-service/models"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// legacyMigration je naziv prelaska sa tabele notifications.notifications na
+// tabele po upitima.
+const legacyMigration = "notifications_by_user_v1"
+
+// MigrateLegacyNotifications prepisuje obaveštenja iz stare tabele
+// notifications u nove tabele. Prelazak se
+// beleži u notification_schema_migrations i izvršava samo jednom; stara
+// tabela se samo čita i može se obrisati ručno.
+func (repo *NotificationRepo) MigrateLegacyNotifications() error {
+	err := repo.session.Query(`CREATE TABLE IF NOT EXISTS notification_schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at TIMESTAMP
+	)`).Exec()
+	if err != nil {
+		repo.logger.Println("Error creating notification_schema_migrations table:", err)
+		return err
+	}
+
+	var applied int
+	if err := repo.session.Query(`SELECT count(*) FROM notification_schema_migrations WHERE name = ?`, legacyMigration).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	var tables int
+	err = repo.session.Query(`SELECT count(*) FROM system_schema.tables
+		WHERE keyspace_name = 'notifications' AND table_name = 'notifications'`).Scan(&tables)
+	if err != nil {
+		return err
+	}
+	if tables > 0 {
+		if err := repo.copyLegacyNotifications(); err != nil {
+			repo.logger.Println("Error migrating legacy notifications:", err)
+			return err
+		}
+	}
+
+	return repo.session.Query(`INSERT INTO notification_schema_migrations (name, applied_at) VALUES (?, ?)`,
+		legacyMigration, time.Now()).Exec()
+}
+
+// tableColumns vraća nazive kolona tabele iz keyspace-a notifications.
+func (repo *NotificationRepo) tableColumns(table string) (map[string]bool, error) {
+	columns := map[string]bool{}
+	iter := repo.session.Query(`SELECT column_name FROM system_schema.columns
+		WHERE keyspace_name = 'notifications' AND table_name = ?`, table).Iter()
+	var column string
+	for iter.Scan(&column) {
+		columns[column] = true
+	}
+	return columns, iter.Close()
+}
+
+func (repo *NotificationRepo) copyLegacyNotifications() error {
+	// Starije verzije tabele nemaju sve kolone; čitaju se samo postojeće, a
+	// tabela se ne menja
+	columns, err := repo.tableColumns("notifications")
+	if err != nil {
+		return err
+	}
+
+	copied, skipped := 0, 0
+
+	iter := repo.session.Query(`SELECT ` + strings.Join(legacyColumns(columns), ", ") + ` FROM notifications`).PageSize(500).Iter()
+	for {
+		row := map[string]interface{}{}
+		if !iter.MapScan(row) {
+			break
+		}
+		n := legacyNotification(row)
+		if repo.retention > 0 && time.Since(n.CreatedAt) >= repo.retention {
+			skipped++
+			continue
+		}
+
+		table := tableByUser
+		if n.Archived {
+			table = tableArchived
+		}
+		ttl := repo.ttlFor(n.CreatedAt)
+
+		batch := repo.session.NewBatch(gocql.LoggedBatch)
+		batch.Query(`INSERT INTO `+table+` (`+notificationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`,
+			n.ID, n.UserID, n.Message, n.CreatedAt, string(n.Status), n.EventType, n.ProjectID, n.TaskID, n.Count, ttl)
+		batch.Query(`INSERT INTO `+tableByID+` (id, user_id, created_at, archived) VALUES (?, ?, ?, ?) USING TTL ?`,
+			n.ID, n.UserID, n.CreatedAt, n.Archived, ttl)
+		if err := repo.session.ExecuteBatch(batch); err != nil {
+			iter.Close()
+			return err
+		}
+		copied++
+	}
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	repo.logger.Printf("Migrated %d notifications to %s (%d past retention skipped)", copied, tableByUser, skipped)
+	return nil
+}
+
+// legacyColumns vraća kolone stare tabele koje se prepisuju, od onih koje
+// tabela ima.
+func legacyColumns(columns map[string]bool) []string {
+	selected := []string{}
+	for _, column := range []string{"id", "user_id", "message", "created_at", "status", "event_type", "project_id", "task_id", "count", "archived"} {
+		if columns[column] {
+			selected = append(selected, column)
+		}
+	}
+	return selected
+}
+
+// legacyNotification pravi obaveštenje od reda stare tabele. Kolone koje
+// red nema ostaju prazne, a obaveštenje bez broja se računa kao jedno.
+func legacyNotification(row map[string]interface{}) *models.Notification {
+	n := &models.Notification{}
+	n.ID, _ = row["id"].(gocql.UUID)
+	n.UserID, _ = row["user_id"].(string)
+	n.Message, _ = row["message"].(string)
+	n.CreatedAt, _ = row["created_at"].(time.Time)
+	status, _ := row["status"].(string)
+	n.Status = models.NotificationStatus(status)
+	n.EventType, _ = row["event_type"].(string)
+	n.ProjectID, _ = row["project_id"].(string)
+	n.TaskID, _ = row["task_id"].(string)
+	n.Count, _ = row["count"].(int)
+	n.Archived, _ = row["archived"].(bool)
+	if n.Count < 1 {
+		n.Count = 1
+	}
+	return n
+}
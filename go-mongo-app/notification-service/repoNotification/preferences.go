@@ -0,0 +1,168 @@
+package repoNotification
+
+import (
+	"fmt"
+	"notification-service/models"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// CreatePreferenceTables pravi tabele podešavanja, reda za digest i poslatih
+// pregleda, i dodaje kolone koje su nastale posle prvih tabela.
+func (repo *NotificationRepo) CreatePreferenceTables() error {
+	err := repo.session.Query(`CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id TEXT PRIMARY KEY,
+		default_channels LIST<TEXT>,
+		types MAP<TEXT, FROZEN<LIST<TEXT>>>,
+		quiet_start TEXT,
+		quiet_end TEXT,
+		quiet_timezone TEXT,
+		muted_projects SET<TEXT>,
+		muted_tasks SET<TEXT>,
+		updated_at TIMESTAMP
+	)`).Exec()
+	if err != nil {
+		repo.logger.Println("Error creating notification_preferences table:", err)
+		return err
+	}
+
+	err = repo.session.Query(`CREATE TABLE IF NOT EXISTS notification_digest_queue (
+		user_id TEXT,
+		created_at TIMESTAMP,
+		id UUID,
+		event_type TEXT,
+		message TEXT,
+		PRIMARY KEY (user_id, created_at, id)
+	) WITH CLUSTERING ORDER BY (created_at ASC)`).Exec()
+	if err != nil {
+		repo.logger.Println("Error creating notification_digest_queue table:", err)
+		return err
+	}
+
+	err = repo.session.Query(`CREATE TABLE IF NOT EXISTS notification_digests (
+		user_id TEXT PRIMARY KEY,
+		last_sent_at TIMESTAMP
+	)`).Exec()
+	if err != nil {
+		repo.logger.Println("Error creating notification_digests table:", err)
+		return err
+	}
+
+	columns := []struct{ table, column, kind string }{
+		{"notification_digest_queue", "project_id", "TEXT"},
+		{"notification_digest_queue", "task_id", "TEXT"},
+		{"notification_preferences", "digest_frequency", "TEXT"},
+		{"notification_preferences", "digest_hour", "INT"},
+		{"notification_preferences", "digest_weekday", "TEXT"},
+		{"notification_preferences", "digest_timezone", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := repo.addColumnIfMissing(c.table, c.column, c.kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *NotificationRepo) addColumnIfMissing(table, column, kind string) error {
+	var count int
+	err := repo.session.Query(`SELECT count(*) FROM system_schema.columns
+		WHERE keyspace_name = 'notifications' AND table_name = ? AND column_name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	if err := repo.session.Query(fmt.Sprintf("ALTER TABLE %s ADD %s %s", table, column, kind)).Exec(); err != nil {
+		repo.logger.Printf("Error adding column %s.%s: %v", table, column, err)
+		return err
+	}
+	return nil
+}
+
+// GetPreferences vraća podešavanja korisnika, ili podrazumevana ako ih nije sačuvao.
+func (repo *NotificationRepo) GetPreferences(userID string) (*models.NotificationPreferences, error) {
+	prefs := models.DefaultPreferences(userID)
+	var quietStart, quietEnd, quietTimezone string
+	var types map[string][]string
+	var defaultChannels, mutedProjects, mutedTasks []string
+	var updatedAt time.Time
+	var digest models.DigestSettings
+
+	err := repo.session.Query(`
+		SELECT default_channels, types, quiet_start, quiet_end, quiet_timezone, muted_projects, muted_tasks, updated_at,
+			digest_frequency, digest_hour, digest_weekday, digest_timezone
+		FROM notification_preferences WHERE user_id = ?`, userID).Scan(
+		&defaultChannels, &types, &quietStart, &quietEnd, &quietTimezone, &mutedProjects, &mutedTasks, &updatedAt,
+		&digest.Frequency, &digest.Hour, &digest.Weekday, &digest.Timezone)
+	if err == gocql.ErrNotFound {
+		return prefs, nil
+	}
+	if err != nil {
+		repo.logger.Println("Error fetching notification preferences:", err)
+		return nil, err
+	}
+
+	prefs.DefaultChannels = nonNil(defaultChannels)
+	if types != nil {
+		prefs.Types = types
+	}
+	if quietStart != "" {
+		prefs.QuietHours = &models.QuietHours{Start: quietStart, End: quietEnd, Timezone: quietTimezone}
+	}
+	prefs.MutedProjects = nonNil(mutedProjects)
+	prefs.MutedTasks = nonNil(mutedTasks)
+	if digest.Frequency != "" {
+		prefs.Digest = &digest
+	}
+	prefs.UpdatedAt = updatedAt
+	return prefs, nil
+}
+
+// SavePreferences prepisuje podešavanja korisnika.
+func (repo *NotificationRepo) SavePreferences(prefs *models.NotificationPreferences) error {
+	var quietStart, quietEnd, quietTimezone string
+	if prefs.QuietHours != nil {
+		quietStart, quietEnd, quietTimezone = prefs.QuietHours.Start, prefs.QuietHours.End, prefs.QuietHours.Timezone
+	}
+	var digest models.DigestSettings
+	if prefs.Digest != nil {
+		digest = *prefs.Digest
+	}
+	prefs.UpdatedAt = time.Now()
+
+	err := repo.session.Query(`
+		INSERT INTO notification_preferences (user_id, default_channels, types, quiet_start, quiet_end, quiet_timezone, muted_projects, muted_tasks, updated_at,
+			digest_frequency, digest_hour, digest_weekday, digest_timezone)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		prefs.UserID, prefs.DefaultChannels, prefs.Types, quietStart, quietEnd, quietTimezone, prefs.MutedProjects, prefs.MutedTasks, prefs.UpdatedAt,
+		digest.Frequency, digest.Hour, digest.Weekday, digest.Timezone).Exec()
+	if err != nil {
+		repo.logger.Println("Error saving notification preferences:", err)
+		return err
+	}
+	return nil
+}
+
+// EnqueueDigest čuva obaveštenje koje će korisnik dobiti u pregledu.
+func (repo *NotificationRepo) EnqueueDigest(notification *models.Notification) error {
+	id, _ := gocql.RandomUUID()
+	err := repo.session.Query(`
+		INSERT INTO notification_digest_queue (user_id, created_at, id, event_type, message, project_id, task_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		notification.UserID, time.Now(), id, notification.EventType, notification.Message, notification.ProjectID, notification.TaskID).Exec()
+	if err != nil {
+		repo.logger.Println("Error queueing digest notification:", err)
+		return err
+	}
+	return nil
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -10,9 +10,28 @@ import (
 	"time"
 )
 
+// Obaveštenja su raspoređena po upitima koje servis izvršava:
+//   - notifications_by_user: lista korisnika, najnovija prva
+//   - notifications_archived_by_user: arhivirana obaveštenja korisnika
+//   - notifications_by_id: nalaženje particije i reda po ID-u obaveštenja
+const (
+	tableByUser   = "notifications_by_user"
+	tableArchived = "notifications_archived_by_user"
+	tableByID     = "notifications_by_id"
+)
+
+const notificationColumns = "id, user_id, message, created_at, status, event_type, project_id, task_id, count"
+
+// DefaultPageSize i MaxPageSize ograničavaju broj obaveštenja na jednoj strani.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
 type NotificationRepo struct {
-	session *gocql.Session
-	logger  *log.Logger
+	session   *gocql.Session
+	logger    *log.Logger
+	retention time.Duration
 }
 
 func New(logger *log.Logger) (*NotificationRepo, error) {
@@ -59,30 +78,12 @@ func New(logger *log.Logger) (*NotificationRepo, error) {
 	}
 
 	return &NotificationRepo{
-		session: session,
-		logger:  logger,
+		session:   session,
+		logger:    logger,
+		retention: retentionFromEnv(),
 	}, nil
 }
 
-func (repo *NotificationRepo) FetchNotificationsByUser(userID gocql.UUID) ([]models.Notification, error) {
-	var notifications []models.Notification
-
-	iter := repo.session.Query(`
-		SELECT id, message, created_at, status FROM notifications WHERE user_id = ?`, userID).Iter()
-
-	var notification models.Notification
-	for iter.Scan(&notification.ID, &notification.Message, &notification.CreatedAt, &notification.Status) {
-		notifications = append(notifications, notification)
-	}
-
-	if err := iter.Close(); err != nil {
-		repo.logger.Println("Error fetching notifications:", err)
-		return nil, err
-	}
-
-	return notifications, nil
-}
-
 func (repo *NotificationRepo) DropKeyspace() error {
 	dropKeyspaceQuery := fmt.Sprintf("DROP KEYSPACE IF EXISTS %s", "notifications")
 	if err := repo.session.Query(dropKeyspaceQuery).Exec(); err != nil {
@@ -93,103 +94,228 @@ func (repo *NotificationRepo) DropKeyspace() error {
 	return nil
 }
 
-func (repo *NotificationRepo) CreateTables() {
+// CreateTables pravi tabele obaveštenja. Aktivna i arhivirana obaveštenja
+// imaju istu šemu, pa se arhiviranje svodi na premeštanje reda.
+func (repo *NotificationRepo) CreateTables() error {
+	for _, table := range []string{tableByUser, tableArchived} {
+		err := repo.session.Query(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			user_id TEXT,
+			created_at TIMESTAMP,
+			id UUID,
+			message TEXT,
+			status TEXT,
+			event_type TEXT,
+			project_id TEXT,
+			task_id TEXT,
+			count INT,
+			PRIMARY KEY (user_id, created_at, id)
+		) WITH CLUSTERING ORDER BY (created_at DESC, id ASC)`, table)).Exec()
+		if err != nil {
+			repo.logger.Printf("Error creating %s table: %v", table, err)
+			return err
+		}
+	}
 
-	err := repo.session.Query(`CREATE TABLE IF NOT EXISTS notifications (
+	err := repo.session.Query(`CREATE TABLE IF NOT EXISTS ` + tableByID + ` (
+		id UUID PRIMARY KEY,
 		user_id TEXT,
 		created_at TIMESTAMP,
-		id UUID,
-		message TEXT,
-		status TEXT,
-		PRIMARY KEY (user_id, created_at, id)
-	) WITH CLUSTERING ORDER BY (created_at DESC);`).Exec()
+		archived BOOLEAN
+	)`).Exec()
 	if err != nil {
-		repo.logger.Println("Error creating notifications table with clustering:", err)
-		return
+		repo.logger.Println("Error creating notifications_by_id table:", err)
+		return err
 	}
-
+	return nil
 }
 
+// Create upisuje obaveštenje. Ako korisnik već ima nepročitano obaveštenje
+// iste vrste o istom zadatku, novo ga zamenjuje i povećava broj sažetih.
 func (repo NotificationRepo) Create(notification *models.Notification) error {
 
 	notification.CreatedAt = time.Now()
 
 	notification.ID, _ = gocql.RandomUUID()
+	notification.Count = 1
 
-	err := repo.session.Query(
-		`INSERT INTO notifications (id, user_id, message, created_at, status)
-	VALUES (?, ?, ?, ?, ?)`,
-		notification.ID, notification.UserID, notification.Message, notification.CreatedAt, notification.Status).Exec()
+	ttl := repo.ttlFor(notification.CreatedAt)
 
+	batch := repo.session.NewBatch(gocql.LoggedBatch)
+	key := groupKey(notification)
+	if key != "" {
+		previous, count, err := repo.claimGroup(notification, key, ttl)
+		if err != nil {
+			repo.logger.Println("Error updating notification group:", err)
+			return err
+		}
+		if previous != nil {
+			notification.Count = count + 1
+			notification.Replaces = &previous.ID
+			batch.Query(`DELETE FROM `+tableByUser+` WHERE user_id = ? AND created_at = ? AND id = ?`,
+				notification.UserID, previous.CreatedAt, previous.ID)
+			batch.Query(`DELETE FROM `+tableByID+` WHERE id = ?`, previous.ID)
+		}
+	}
+	batch.Query(`INSERT INTO `+tableByUser+` (`+notificationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) USING TTL ?`,
+		notification.ID, notification.UserID, notification.Message, notification.CreatedAt, notification.Status,
+		notification.EventType, notification.ProjectID, notification.TaskID, notification.Count, ttl)
+	batch.Query(`INSERT INTO `+tableByID+` (id, user_id, created_at, archived) VALUES (?, ?, ?, false) USING TTL ?`,
+		notification.ID, notification.UserID, notification.CreatedAt, ttl)
+
+	err := repo.session.ExecuteBatch(batch)
 	if err != nil {
 		repo.logger.Println("Error inserting notification:", err)
-		return err
 	}
-	return nil
+	return err
 }
 
 func (repo *NotificationRepo) CloseSession() {
 	repo.session.Close()
 }
 
-func (repo *NotificationRepo) FetchByUserID(userID string) ([]*models.Notification, error) {
-	var notifications []*models.Notification
+func scanNotification(iter *gocql.Iter) (*models.Notification, bool) {
+	var notification models.Notification
+	if !iter.Scan(&notification.ID, &notification.UserID, &notification.Message, &notification.CreatedAt, &notification.Status,
+		&notification.EventType, &notification.ProjectID, &notification.TaskID, &notification.Count) {
+		return nil, false
+	}
+	return &notification, true
+}
 
-	iter := repo.session.Query(`
-        SELECT id, user_id, message, created_at, status
-        FROM notifications 
-        WHERE user_id = ? 
-        ORDER BY created_at DESC`, userID).Iter()
+// ClampPageSize drži traženu veličinu strane u dozvoljenim granicama.
+func ClampPageSize(size int) int {
+	if size <= 0 {
+		return DefaultPageSize
+	}
+	if size > MaxPageSize {
+		return MaxPageSize
+	}
+	return size
+}
 
+// fetchPage čita jednu stranu upita i vraća stanje za sledeću stranu, ili
+// nil kada strana nije poslednja.
+func (repo *NotificationRepo) fetchPage(query *gocql.Query, pageSize int, pageState []byte, archived bool) ([]*models.Notification, []byte, error) {
+	// Uz PageState drajver ne učitava sam sledeću stranu
+	iter := query.PageSize(ClampPageSize(pageSize)).PageState(pageState).Iter()
+	next := iter.PageState()
+	notifications := []*models.Notification{}
 	for {
-		var notification models.Notification
-		if !iter.Scan(&notification.ID, &notification.UserID, &notification.Message, &notification.CreatedAt, &notification.Status) {
+		notification, ok := scanNotification(iter)
+		if !ok {
 			break
 		}
-		notifications = append(notifications, &notification)
+		notification.Archived = archived
+		notifications = append(notifications, notification)
 	}
-
 	if err := iter.Close(); err != nil {
-		repo.logger.Println("Error closing iterator:", err)
-		return nil, err
+		return nil, nil, err
+	}
+	if len(next) == 0 {
+		next = nil
 	}
+	return notifications, next, nil
+}
 
-	return notifications, nil
+// FetchByUserID vraća jednu stranu obaveštenja korisnika, najnovija prva,
+// ili arhiviranih kada je archived postavljen.
+func (repo *NotificationRepo) FetchByUserID(userID string, archived bool, pageSize int, pageState []byte) ([]*models.Notification, []byte, error) {
+	table := tableByUser
+	if archived {
+		table = tableArchived
+	}
+	query := repo.session.Query(`SELECT `+notificationColumns+` FROM `+table+` WHERE user_id = ?`, userID)
+	notifications, next, err := repo.fetchPage(query, pageSize, pageState, archived)
+	if err != nil {
+		repo.logger.Println("Error fetching notifications:", err)
+		return nil, nil, err
+	}
+	return notifications, next, nil
 }
 
-func (repo *NotificationRepo) FetchAllNotifications() ([]models.Notification, error) {
-	var notifications []models.Notification
+// FetchSince vraća obaveštenja korisnika nastala u trenutku since ili kasnije,
+// od najstarijeg ka najnovijem. Koristi se za nastavak toka posle ponovnog povezivanja.
+func (repo *NotificationRepo) FetchSince(userID string, since time.Time) ([]*models.Notification, error) {
+	var notifications []*models.Notification
 
-	iter := repo.session.Query(`
-		SELECT id, user_id, message, created_at, status FROM notifications`).Iter()
+	iter := repo.session.Query(`SELECT `+notificationColumns+` FROM `+tableByUser+`
+        WHERE user_id = ? AND created_at >= ?
+        ORDER BY created_at ASC`, userID, since).Iter()
 
-	var notification models.Notification
-	for iter.Scan(&notification.ID, &notification.UserID, &notification.Message, &notification.CreatedAt, &notification.Status) {
+	for {
+		notification, ok := scanNotification(iter)
+		if !ok {
+			break
+		}
 		notifications = append(notifications, notification)
 	}
 
 	if err := iter.Close(); err != nil {
-		repo.logger.Println("Error fetching all notifications:", err)
+		repo.logger.Println("Error fetching notifications since:", err)
 		return nil, err
 	}
 
 	return notifications, nil
 }
 
-func (repo *NotificationRepo) FetchByID(id gocql.UUID) (*models.Notification, error) {
-	var notification models.Notification
-	err := repo.session.Query(`
-		SELECT id, user_id, message, created_at, status
-		FROM notifications WHERE id = ?`, id).Consistency(gocql.One).Scan(
-		&notification.ID, &notification.UserID, &notification.Message, &notification.CreatedAt, &notification.Status)
+// CountUnread vraća broj nepročitanih obaveštenja korisnika. Broj se računa
+// iz liste korisnika, pa uvek odgovara obaveštenjima koja korisnik vidi;
+// particija je ograničena rokom čuvanja obaveštenja.
+func (repo *NotificationRepo) CountUnread(userID string) (int, error) {
+	iter := repo.session.Query(`SELECT status FROM `+tableByUser+` WHERE user_id = ?`, userID).Iter()
+	count := 0
+	var status string
+	for iter.Scan(&status) {
+		if models.NotificationStatus(status) == models.Unread {
+			count++
+		}
+	}
+	if err := iter.Close(); err != nil {
+		repo.logger.Println("Error counting unread notifications:", err)
+		return 0, err
+	}
+	return count, nil
+}
 
+// FetchAllNotifications vraća jednu stranu obaveštenja svih korisnika.
+func (repo *NotificationRepo) FetchAllNotifications(pageSize int, pageState []byte) ([]*models.Notification, []byte, error) {
+	query := repo.session.Query(`SELECT ` + notificationColumns + ` FROM ` + tableByUser)
+	notifications, next, err := repo.fetchPage(query, pageSize, pageState, false)
 	if err != nil {
-		if err == gocql.ErrNotFound {
-			return nil, fmt.Errorf("notification with ID %v not found", id)
-		}
+		repo.logger.Println("Error fetching all notifications:", err)
+		return nil, nil, err
+	}
+	return notifications, next, nil
+}
+
+func (repo *NotificationRepo) FetchByID(id gocql.UUID) (*models.Notification, error) {
+	var userID string
+	var createdAt time.Time
+	var archived bool
+	err := repo.session.Query(`SELECT user_id, created_at, archived FROM `+tableByID+` WHERE id = ?`, id).Scan(&userID, &createdAt, &archived)
+	if err == gocql.ErrNotFound {
+		return nil, fmt.Errorf("notification with ID %v not found", id)
+	}
+	if err != nil {
+		repo.logger.Println("Error fetching notification:", err)
+		return nil, err
+	}
+
+	table := tableByUser
+	if archived {
+		table = tableArchived
+	}
+	iter := repo.session.Query(`SELECT `+notificationColumns+` FROM `+table+` WHERE user_id = ? AND created_at = ? AND id = ?`,
+		userID, createdAt, id).Iter()
+	notification, found := scanNotification(iter)
+	if err := iter.Close(); err != nil {
 		repo.logger.Println("Error fetching notification:", err)
 		return nil, err
 	}
+	if !found {
+		return nil, fmt.Errorf("notification with ID %v not found", id)
+	}
+	notification.Archived = archived
 
 	location, err := time.LoadLocation("Europe/Budapest")
 	if err != nil {
@@ -199,64 +325,63 @@ func (repo *NotificationRepo) FetchByID(id gocql.UUID) (*models.Notification, er
 
 	notification.CreatedAt = notification.CreatedAt.In(location)
 
-	return &notification, nil
+	return notification, nil
 }
 
+// MarkAllAsRead označava sva obaveštenja korisnika kao pročitana. Čita se
+// samo particija korisnika.
 func (r *NotificationRepo) MarkAllAsRead(userID string) error {
-	var notificationIDs []struct {
-		ID        gocql.UUID `json:"id"`
-		CreatedAt time.Time  `json:"created_at"`
-	}
+	var unread []models.NotificationRef
 
-	iter := r.session.Query("SELECT id, created_at FROM notifications WHERE user_id = ? AND status = ? ALLOW FILTERING", userID, "unread").Iter()
+	iter := r.session.Query(`SELECT created_at, id, status FROM `+tableByUser+` WHERE user_id = ?`, userID).Iter()
 	for {
-		var id gocql.UUID
-		var createdAt time.Time
-		if !iter.Scan(&id, &createdAt) {
+		var ref models.NotificationRef
+		var status string
+		if !iter.Scan(&ref.CreatedAt, &ref.ID, &status) {
 			break
 		}
-		notificationIDs = append(notificationIDs, struct {
-			ID        gocql.UUID `json:"id"`
-			CreatedAt time.Time  `json:"created_at"`
-		}{
-			ID:        id,
-			CreatedAt: createdAt,
-		})
+		if models.NotificationStatus(status) == models.Unread {
+			unread = append(unread, ref)
+		}
 	}
 
 	if err := iter.Close(); err != nil {
 		return fmt.Errorf("failed to fetch unread notifications for user %s: %w", userID, err)
 	}
 
-	if len(notificationIDs) == 0 {
-		return nil
-	}
-
-	for _, notification := range notificationIDs {
-		err := r.session.Query("UPDATE notifications SET status = ? WHERE user_id = ? AND created_at = ? AND id = ?",
-			"read", userID, notification.CreatedAt, notification.ID).Exec()
-		if err != nil {
-			return fmt.Errorf("failed to update notification %s for user %s: %w", notification.ID, userID, err)
-		}
+	err := r.executeInBatches(gocql.UnloggedBatch, unread, func(batch *gocql.Batch, ref models.NotificationRef) {
+		batch.Query(`UPDATE `+tableByUser+` USING TTL ? SET status = ? WHERE user_id = ? AND created_at = ? AND id = ?`,
+			r.ttlFor(ref.CreatedAt), string(models.Read), userID, ref.CreatedAt, ref.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark notifications as read for user %s: %w", userID, err)
 	}
-
 	return nil
 }
 
 func (repo *NotificationRepo) UpdateStatus(createdAt time.Time, userID string, id gocql.UUID, status models.NotificationStatus) error {
-	err := repo.session.Query(`
-        UPDATE notifications 
+	stored, err := repo.fetchRefs(tableByUser, userID, []models.NotificationRef{{ID: id, CreatedAt: createdAt}})
+	if err != nil {
+		repo.logger.Println("Error updating notification status:", err)
+		return err
+	}
+	if len(stored) == 0 {
+		return fmt.Errorf("notification with ID %v not found", id)
+	}
+
+	err = repo.session.Query(`
+        UPDATE `+tableByUser+` USING TTL ?
         SET status = ? 
         WHERE user_id = ? AND created_at = ? AND id = ?`,
-		status, userID, createdAt, id).Exec()
+		repo.ttlFor(stored[0].CreatedAt), status, userID, stored[0].CreatedAt, id).Exec()
 
 	if err != nil {
 		repo.logger.Println("Error updating notification status:", err)
-		return err
 	}
-	return nil
+	return err
 }
 
+// EnsureKeyspaceAndTable pravi keyspace obaveštenja; tabele pravi CreateTables.
 func EnsureKeyspaceAndTable(session *gocql.Session, logger *log.Logger) error {
 	var keyspaceCount int
 	err := session.Query("SELECT count(*) FROM system_schema.keyspaces WHERE keyspace_name = 'notifications'").Scan(&keyspaceCount)
@@ -273,26 +398,5 @@ func EnsureKeyspaceAndTable(session *gocql.Session, logger *log.Logger) error {
 		}
 	}
 
-	var tableCount int
-	err = session.Query("SELECT count(*) FROM system_schema.tables WHERE keyspace_name = 'notifications' AND table_name = 'notifications'").Scan(&tableCount)
-	if err != nil {
-		return err
-	}
-
-	if tableCount == 0 {
-		logger.Println("Creating table 'notifications.notifications'...")
-		createTableQuery := `CREATE TABLE IF NOT EXISTS notifications.notifications (
-			user_id TEXT,
-			created_at TIMESTAMP,
-			id UUID,
-			message TEXT,
-			status TEXT,
-			PRIMARY KEY (user_id, created_at, id)
-		) WITH CLUSTERING ORDER BY (created_at DESC)`
-		if err := session.Query(createTableQuery).Exec(); err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
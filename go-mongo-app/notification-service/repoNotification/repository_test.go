@@ -0,0 +1,110 @@
+package repoNotification
+
+import (
+	"notification-service/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Najstarija tabela nema kolone za sažimanje i arhivu.
+func TestLegacyColumnsOfOldestTable(t *testing.T) {
+	columns := map[string]bool{"id": true, "user_id": true, "message": true, "created_at": true, "status": true, "is_active": true}
+	want := []string{"id", "user_id", "message", "created_at", "status"}
+	if got := legacyColumns(columns); !reflect.DeepEqual(got, want) {
+		t.Errorf("legacyColumns = %v, want %v", got, want)
+	}
+}
+
+func TestLegacyNotification(t *testing.T) {
+	id := gocql.TimeUUID()
+	createdAt := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	full := legacyNotification(map[string]interface{}{
+		"id": id, "user_id": "u1", "message": "Assigned", "created_at": createdAt, "status": "read",
+		"event_type": "task.member.added", "project_id": "p1", "task_id": "t1", "count": 3, "archived": true,
+	})
+	want := &models.Notification{
+		ID: id, UserID: "u1", Message: "Assigned", CreatedAt: createdAt, Status: models.Read,
+		EventType: "task.member.added", ProjectID: "p1", TaskID: "t1", Count: 3, Archived: true,
+	}
+	if !reflect.DeepEqual(full, want) {
+		t.Errorf("full row = %+v, want %+v", full, want)
+	}
+
+	sparse := legacyNotification(map[string]interface{}{"id": id, "user_id": "u1", "status": "unread", "created_at": createdAt})
+	if sparse.Count != 1 || sparse.Archived || sparse.TaskID != "" || sparse.Status != models.Unread {
+		t.Errorf("sparse row = %+v", sparse)
+	}
+}
+
+func TestTTLFollowsRetention(t *testing.T) {
+	repo := &NotificationRepo{retention: 24 * time.Hour}
+
+	if ttl := repo.ttlFor(time.Now()); ttl < 24*60*60-5 || ttl > 24*60*60 {
+		t.Errorf("new notification ttl = %d", ttl)
+	}
+	if ttl := repo.ttlFor(time.Now().Add(-12 * time.Hour)); ttl > 12*60*60 {
+		t.Errorf("half expired ttl = %d, want at most 12h", ttl)
+	}
+	// TTL 0 bi obaveštenje zadržao zauvek
+	if ttl := repo.ttlFor(time.Now().Add(-48 * time.Hour)); ttl != 1 {
+		t.Errorf("expired ttl = %d, want 1", ttl)
+	}
+
+	repo.retention = 0
+	if ttl := repo.ttlFor(time.Now().Add(-48 * time.Hour)); ttl != 0 {
+		t.Errorf("ttl without retention = %d, want 0", ttl)
+	}
+}
+
+func TestRetentionFromEnv(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":     defaultRetentionDays * 24 * time.Hour,
+		"7":    7 * 24 * time.Hour,
+		"0":    0,
+		"-1":   defaultRetentionDays * 24 * time.Hour,
+		"week": defaultRetentionDays * 24 * time.Hour,
+	} {
+		t.Setenv("NOTIFICATION_RETENTION_DAYS", value)
+		if got := retentionFromEnv(); got != want {
+			t.Errorf("NOTIFICATION_RETENTION_DAYS=%q: retention = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestClampPageSize(t *testing.T) {
+	if got := ClampPageSize(0); got != DefaultPageSize {
+		t.Errorf("ClampPageSize(0) = %d", got)
+	}
+	if got := ClampPageSize(-5); got != DefaultPageSize {
+		t.Errorf("ClampPageSize(-5) = %d", got)
+	}
+	if got := ClampPageSize(20); got != 20 {
+		t.Errorf("ClampPageSize(20) = %d", got)
+	}
+	if got := ClampPageSize(MaxPageSize + 1); got != MaxPageSize {
+		t.Errorf("ClampPageSize(%d) = %d", MaxPageSize+1, got)
+	}
+}
+
+func TestGroupKey(t *testing.T) {
+	if key := groupKey(&models.Notification{TaskID: "t1", EventType: "task.status.changed"}); key != "t1:task.status.changed" {
+		t.Errorf("groupKey = %q", key)
+	}
+	if key := groupKey(&models.Notification{ProjectID: "p1", EventType: "project.deleted"}); key != "" {
+		t.Errorf("project notification grouped under %q", key)
+	}
+}
+
+func TestRefsOfKeepsPartitionOrder(t *testing.T) {
+	first, second := gocql.TimeUUID(), gocql.TimeUUID()
+	now := time.Now()
+	refs := refsOf([]*models.Notification{{ID: first, CreatedAt: now}, {ID: second, CreatedAt: now.Add(-time.Minute)}})
+	want := []models.NotificationRef{{ID: first, CreatedAt: now}, {ID: second, CreatedAt: now.Add(-time.Minute)}}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("refsOf = %v, want %v", refs, want)
+	}
+}
@@ -49,7 +49,13 @@ func InsertInitialProjects() {
 	}
 }
 
+// ClearProjects briše kolekciju samo kada je CLEAR_ON_START=true, npr. za lokalno
+// testiranje; inače bi svaki restart obrisao podatke koje graf i outbox čuvaju.
 func ClearProjects() {
+	if os.Getenv("CLEAR_ON_START") != "true" {
+		return
+	}
+
 	collection := db.Client.Database("testdb").Collection("projects")
 	_, err := collection.DeleteMany(context.TODO(), bson.D{})
 	if err != nil {
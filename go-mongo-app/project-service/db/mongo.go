@@ -35,3 +35,19 @@ type ProjectRepo struct {
 func NewProjectRepo(client *mongo.Client) *ProjectRepo {
 	return &ProjectRepo{cli: client}
 }
+
+// WithTransaction runs fn inside a Mongo transaction. Every write made through
+// sessCtx, including outbox messages, is committed or rolled back together.
+// Transactions require MongoDB to run as a replica set.
+func WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
@@ -3,11 +3,12 @@ module project-service
 go 1.20
 
 require (
+	contracts v0.0.0
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/gorilla/mux v1.8.1
 	github.com/rs/cors v1.11.1
 	go.mongodb.org/mongo-driver v1.17.1
-
+	shared v0.0.0
 )
 
 require (
@@ -29,3 +30,7 @@ require (
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
 )
+
+replace contracts => ../contracts
+
+replace shared => ../shared
@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/nats-io/nats.go"
@@ -13,8 +13,8 @@ import (
 	"project-service/db"
 	"project-service/models"
 	"project-service/service"
+	"shared/saga"
 	"strings"
-	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -157,46 +157,7 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Generisanje događaja za kreirani projekat
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
-
-	event := map[string]interface{}{
-		"type": "Project Created",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"projectId": projectID, // Ispravno korišćenje generisanog ID-a
-			"managerId": managerID,
-			"title":     project.Title, // Ispravno polje
-		},
-		"projectId": projectID,
-	}
-
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
-		h.logger.Println("No Authorization header:", authHeader)
-		return
-	}
-
-	// Expect the format "Bearer <token>"
-	token := ""
-
-	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
-		token = authHeader[7:]
-	} else {
-		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-		h.logger.Println("Invalid Authorization header format:", authHeader)
-		return
-	}
-
-	// Slanje događaja u bazu
-	if err := h.sendEventToDatabase(event, token); err != nil {
-		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
-		return
-	}
-
-	h.logger.Println("Project created and event sent:", projectID)
+	h.logger.Println("Project created:", projectID)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -241,132 +202,11 @@ func (p *ProjectHandler) AddUsersToProject(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	project, err := service.GetProjectByID(projectID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	nc, err := Conn()
-	if err != nil {
-		http.Error(w, "Failed to connect to message broker", http.StatusInternalServerError)
-		p.logger.Println("Error connecting to NATS:", err)
-		return
-	}
-	defer nc.Close()
-
-	for _, uid := range requestBody.UserIDs {
-		subject := "project.joined"
-
-		message := struct {
-			UserID      string `json:"userId"`
-			ProjectName string `json:"projectName"`
-		}{
-			UserID:      uid,
-			ProjectName: project.Title,
-		}
-
-		jsonMessage, err := json.Marshal(message)
-		if err != nil {
-			log.Println("Error marshalling message:", err)
-			continue
-		}
-
-		err = nc.Publish(subject, jsonMessage)
-		if err != nil {
-			log.Println("Error publishing message to NATS:", err)
-		}
-
-		currentTime := time.Now().Add(1 * time.Hour)
-		formattedTime := currentTime.Format(time.RFC3339)
-
-		event := map[string]interface{}{
-			"type": "Member Added to Project",
-			"time": formattedTime,
-			"event": map[string]interface{}{
-				"memberId":  uid,
-				"projectId": projectID,
-			},
-			"projectId": projectID,
-		}
-
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "No Authorization header found", http.StatusUnauthorized)
-			p.logger.Println("No Authorization header:", authHeader)
-			return
-		}
-
-		// Expect the format "Bearer <token>"
-		token := ""
-
-		if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
-			token = authHeader[7:]
-		} else {
-			http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-			p.logger.Println("Invalid Authorization header format:", authHeader)
-			return
-		}
-
-		if err := p.sendEventToDatabase(event, token); err != nil {
-			http.Error(w, "Error sending event to analytics service", http.StatusInternalServerError)
-			return
-		}
-
-	}
-	p.logger.Println("a message has been sent")
+	p.logger.Println("Users added to project:", projectID)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Users successfully added to project"))
 }
-func (p *ProjectHandler) sendEventToDatabase(event interface{}, token string) error {
-	analyticsServiceURL := fmt.Sprintf("http://event_sourcing:8080/event/append")
-
-	// Marshal the event into JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		log.Printf("Error marshalling event: %v", err)
-		return err
-	}
-
-	// Create a new POST request with the event data
-	req, err := http.NewRequest("POST", analyticsServiceURL, bytes.NewBuffer(eventData))
-	if err != nil {
-		log.Printf("Error creating request: %v", err)
-		return err
-	}
-
-	// Set the appropriate headers for the request
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token)) // Dodaj token u Authorization header
-
-	// Use the default HTTP client (without TLS)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Printf("Error sending request to analytics service: %v", err)
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Check the response status code
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Failed to send event to analytics service: %s", resp.Status)
-		return fmt.Errorf("failed to send event to analytics service: %s", resp.Status)
-	}
-
-	return nil
-}
-
-func Conn() (*nats.Conn, error) {
-	connection := os.Getenv("NATS_URL")
-	conn, err := nats.Connect(connection)
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	return conn, nil
-}
-
 func (h *ProjectHandler) GetProjectByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	projectID := vars["projectId"]
@@ -399,96 +239,12 @@ func (p *ProjectHandler) RemoveUsersFromProject(w http.ResponseWriter, r *http.R
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	project, err := service.GetProjectByID(projectID)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	for _, uid := range requestBody.UserIDs {
-
-		subject := "project.removed"
-		message := struct {
-			UserID      string `json:"userId"`
-			ProjectName string `json:"projectName"`
-		}{
-			UserID:      uid,
-			ProjectName: project.Title,
-		}
-
-		if err := p.sendNotification(subject, message); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		currentTime := time.Now().Add(1 * time.Hour)
-		formattedTime := currentTime.Format(time.RFC3339)
-
-		event := map[string]interface{}{
-			"type": "Member Removed from Project",
-			"time": formattedTime,
-			"event": map[string]interface{}{
-				"memberId":  uid,
-				"projectId": projectID,
-			},
-			"projectId": projectID,
-		}
-
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "No Authorization header found", http.StatusUnauthorized)
-			p.logger.Println("No Authorization header:", authHeader)
-			return
-		}
-
-		// Expect the format "Bearer <token>"
-		token := ""
-
-		if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
-			token = authHeader[7:]
-		} else {
-			http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-			p.logger.Println("Invalid Authorization header format:", authHeader)
-			return
-		}
-
-		// Send the event to the analytic service
-		if err := p.sendEventToDatabase(event, token); err != nil {
-			http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
-			return
-		}
-
-	}
-	p.logger.Println("a message has been sent")
+	p.logger.Println("Users removed from project:", projectID)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Users successfully removed from project"))
 }
 
-func (p *ProjectHandler) sendNotification(subject string, message interface{}) error {
-	nc, err := Conn()
-	if err != nil {
-		log.Println("Error connecting to NATS:", err)
-		return fmt.Errorf("failed to connect to message broker: %w", err)
-	}
-	defer nc.Close()
-
-	jsonMessage, err := json.Marshal(message)
-	if err != nil {
-		log.Println("Error marshalling message:", err)
-		return fmt.Errorf("error marshalling message: %w", err)
-	}
-
-	err = nc.Publish(subject, jsonMessage)
-	if err != nil {
-		log.Println("Error publishing message to NATS:", err)
-		return fmt.Errorf("error publishing message to NATS: %w", err)
-	}
-
-	p.logger.Println("Notification sent:", subject)
-	return nil
-}
-
 func (h *ProjectHandler) HandleCheckProjectByTitle(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	managerID := vars["managerId"]
@@ -536,6 +292,10 @@ func (h *ProjectHandler) AddTaskToProjectHandler(w http.ResponseWriter, r *http.
 	taskID := vars["taskID"]
 
 	err := service.AddTaskToProject(projectID, taskID)
+	if errors.Is(err, service.ErrProjectBeingDeleted) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to add task to project: %v", err), http.StatusInternalServerError)
 		return
@@ -545,6 +305,25 @@ func (h *ProjectHandler) AddTaskToProjectHandler(w http.ResponseWriter, r *http.
 	w.Write([]byte("Task successfully added to project"))
 }
 
+func (h *ProjectHandler) RemoveTaskFromProjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["projectID"]
+	taskID := vars["taskID"]
+
+	err := service.RemoveTaskFromProject(projectID, taskID)
+	if errors.Is(err, service.ErrProjectNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to remove task from project: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Task successfully removed from project"))
+}
+
 func (h *ProjectHandler) IsActiveProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	projectID := vars["projectId"]
@@ -692,66 +471,60 @@ func (uh *ProjectHandler) DeleteProjectByIDHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Fetch the project details before deleting it
-	project, err := service.GetProjectByID(projectID)
-	if err != nil {
-		http.Error(w, "Failed to fetch project details: "+err.Error(), http.StatusInternalServerError)
+	memberID, _ := r.Context().Value(KeyAccount{}).(string)
+
+	// Brisanje se vodi kao saga; ako ne uspe odmah, nastavlja se u pozadini
+	deletion, err := service.StartProjectDeletion(context.TODO(), projectID, memberID)
+	if errors.Is(err, service.ErrProjectNotFound) {
+		http.Error(w, "Project not found", http.StatusNotFound)
 		return
 	}
-
-	// Fetch the list of users associated with the project
-	userIDs, err := service.GetUsersForProject(projectID)
 	if err != nil {
-		http.Error(w, "Failed to fetch users for project: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to delete project: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Send notifications to all users that they have been removed from the project
-	for _, userID := range userIDs {
-		subject := "project.removed2"
-		message := struct {
-			UserID      string `json:"userId"`
-			ProjectName string `json:"projectName"`
-		}{
-			UserID:      userID,
-			ProjectName: project.Title,
-		}
-
-		if err := uh.sendNotification(subject, message); err != nil {
-			http.Error(w, "Failed to send notification to user: "+userID, http.StatusInternalServerError)
-			return
-		}
+	deletion, err = service.RunSaga(context.TODO(), deletion.ID)
+	if err != nil {
+		http.Error(w, "Failed to delete project: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
-		uh.logger.Println("No Authorization header:", authHeader)
-		return
+	w.Header().Set("Content-Type", "application/json")
+	switch deletion.Status {
+	case saga.Completed:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "project deleted successfully", "sagaId": deletion.ID.Hex()})
+	case saga.Compensated:
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": "project deletion was rolled back: " + deletion.LastError, "sagaId": deletion.ID.Hex()})
+	case saga.Failed, saga.Stuck:
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"message": "project deletion needs manual cleanup: " + deletion.LastError, "sagaId": deletion.ID.Hex(), "status": deletion.Status})
+	default:
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "project deletion in progress", "sagaId": deletion.ID.Hex(), "status": deletion.Status})
 	}
+}
 
-	// Expect the format "Bearer <token>"
-	token := ""
+// GetProjectDeletionHandler vraća stanje poslednjeg brisanja projekta.
+func (uh *ProjectHandler) GetProjectDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["projectId"]
 
-	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
-		token = authHeader[7:]
-	} else {
-		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-		uh.logger.Println("Invalid Authorization header format:", authHeader)
+	deletion, err := service.GetLatestDeletion(context.TODO(), projectID)
+	if errors.Is(err, saga.ErrNotFound) {
+		http.Error(w, "No deletion found for project", http.StatusNotFound)
 		return
 	}
-
-	// Pozovi repository za brisanje project po taskID-u
-	err = service.DeleteProjectByID(projectID, token)
 	if err != nil {
-		http.Error(w, "Failed to delete project: "+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to fetch deletion: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Uspešan odgovor
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message":"project deleted successfully"}`))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deletion)
 }
+
 func (uh *ProjectHandler) UpdateTaskOrder(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project-service/models"
+	"project-service/service"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// writeWebhookError prevodi grešku webhook servisa u HTTP odgovor.
+func (h *ProjectHandler) writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrProjectNotFound), errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrDeliveryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrNotProjectManager):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrTooManyWebhooks):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrInvalidWebhookURL), errors.Is(err, service.ErrInvalidWebhookType),
+		errors.Is(err, service.ErrWebhookHostNotAllowed):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		h.logger.Println("Webhook request failed:", err)
+		http.Error(w, "Webhook request failed", http.StatusInternalServerError)
+	}
+}
+
+func writeWebhookJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *ProjectHandler) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+
+	webhooks, err := service.GetWebhooks(context.TODO(), mux.Vars(r)["projectId"], userID)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusOK, webhooks)
+}
+
+// CreateWebhookHandler pravi webhook; odgovor jedini put sadrži tajnu za
+// proveru potpisa.
+func (h *ProjectHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := service.CreateWebhook(context.TODO(), mux.Vars(r)["projectId"], userID, req)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusCreated, webhook)
+}
+
+func (h *ProjectHandler) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	vars := mux.Vars(r)
+
+	var req models.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := service.UpdateWebhook(context.TODO(), vars["projectId"], vars["webhookId"], userID, req)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusOK, webhook)
+}
+
+func (h *ProjectHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	vars := mux.Vars(r)
+
+	if err := service.DeleteWebhook(context.TODO(), vars["projectId"], vars["webhookId"], userID); err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveriesHandler vraća dnevnik isporuka webhook-a sa kodovima
+// odgovora svakog pokušaja.
+func (h *ProjectHandler) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	vars := mux.Vars(r)
+
+	var limit int64
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid limit parameter: "+v, http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	deliveries, err := service.GetWebhookDeliveries(context.TODO(), vars["projectId"], vars["webhookId"], userID, limit)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusOK, deliveries)
+}
+
+func (h *ProjectHandler) RedeliverWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	vars := mux.Vars(r)
+
+	delivery, err := service.RedeliverWebhook(context.TODO(), vars["projectId"], vars["webhookId"], vars["deliveryId"], userID)
+	if err != nil {
+		h.writeWebhookError(w, err)
+		return
+	}
+	writeWebhookJSON(w, http.StatusAccepted, delivery)
+}
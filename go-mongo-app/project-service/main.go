@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"log"
 	"net/http"
 	"os"
@@ -12,6 +13,8 @@ import (
 	bootstrap "project-service/boostrap"
 	"project-service/db"
 	"project-service/handlers"
+	"project-service/outbox"
+	"project-service/service"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
@@ -39,6 +42,35 @@ func main() {
 	defer nc.Close()
 	projectRepo := db.NewProjectRepo(db.Client)
 	logger := log.New(os.Stdout, "[product-api] ", log.LstdFlags)
+
+	// Poruke project.* se čuvaju u JetStream stream-u dok ih consumer-i ne obrade
+	js, err := jetstream.New(nc)
+	if err != nil {
+		log.Fatalf("Error creating JetStream context: %v", err)
+	}
+	if err := outbox.EnsureStream(context.Background(), js); err != nil {
+		log.Fatalf("Error creating %s stream: %v", outbox.Stream.Name, err)
+	}
+
+	// Relay isporučuje događaje i notifikacije upisane u outbox
+	relay := outbox.NewRelay(logger, nc, js)
+	go relay.Run(context.Background())
+
+	// Sage brisanja nastavljaju i posle pada servisa
+	if err := service.EnsureSagaIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating deletion saga indexes: %v", err)
+	}
+	go service.RunDeletionSagas(context.Background(), logger)
+
+	// Webhook-ovi projekata dobijaju iste događaje kao NATS i event store
+	if err := service.EnsureWebhookIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating webhook indexes: %v", err)
+	}
+	if err := service.ListenForWebhookEvents(context.Background(), js, logger); err != nil {
+		log.Fatalf("Error starting webhook consumers: %v", err)
+	}
+	go service.RunWebhookDeliveries(context.Background(), logger)
+
 	projectsHandler := handlers.NewProjectsHandler(logger, projectRepo, nc)
 
 	router := mux.NewRouter()
@@ -52,8 +84,16 @@ func main() {
 	router.HandleFunc("/projects/{projectId}/remove-users", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.RemoveUsersFromProject, "Manager"))).Methods("PUT")
 	router.HandleFunc("/projects/title/{managerId}", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.HandleCheckProjectByTitle, "Manager"))).Methods("POST")
 	router.HandleFunc("/projects/{projectID}/tasks/{taskID}", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.AddTaskToProjectHandler, "Manager"))).Methods("PUT", "OPTIONS")
+	router.HandleFunc("/projects/{projectID}/tasks/{taskID}", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.RemoveTaskFromProjectHandler, "Manager"))).Methods("DELETE")
 	router.HandleFunc("/projects/isActive/{projectId}", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.IsActiveProject, "Manager", "Member"))).Methods("GET")
 	router.HandleFunc("/projects/delete/{projectID}", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.DeleteProjectByIDHandler, "Manager"))).Methods("DELETE")
+	router.HandleFunc("/projects/{projectId}/deletion", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.GetProjectDeletionHandler, "Manager"))).Methods("GET")
+	router.HandleFunc("/projects/{projectId}/webhooks", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.GetWebhooksHandler, "Manager"))).Methods("GET")
+	router.HandleFunc("/projects/{projectId}/webhooks", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.CreateWebhookHandler, "Manager"))).Methods("POST")
+	router.HandleFunc("/projects/{projectId}/webhooks/{webhookId}", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.UpdateWebhookHandler, "Manager"))).Methods("PUT")
+	router.HandleFunc("/projects/{projectId}/webhooks/{webhookId}", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.DeleteWebhookHandler, "Manager"))).Methods("DELETE")
+	router.HandleFunc("/projects/{projectId}/webhooks/{webhookId}/deliveries", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.GetWebhookDeliveriesHandler, "Manager"))).Methods("GET")
+	router.HandleFunc("/projects/{projectId}/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.RedeliverWebhookHandler, "Manager"))).Methods("POST")
 	router.HandleFunc("/projects/{projectID}/task-order", projectsHandler.MiddlewareExtractUserFromHeader(projectsHandler.RoleRequired(projectsHandler.UpdateTaskOrder, "Member", "Manager"))).Methods("PUT")
 
 	c := cors.New(cors.Options{
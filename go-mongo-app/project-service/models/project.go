@@ -12,4 +12,5 @@ type Project struct {
 	MaxPeople       int                `bson:"max_people" json:"max_people"`
 	Users           []string           `bson:"users" json:"users"`
 	Tasks           []string           `bson:"tasks" json:"tasks"`
+	Deleting        bool               `bson:"deleting,omitempty" json:"deleting,omitempty"`
 }
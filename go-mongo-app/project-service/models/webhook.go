@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook šalje događaje projekta na spoljni URL. EventTypes su NATS subjekti
+// (npr. task.status.update) ili vrste događaja iz event store-a (npr. "Task
+// Created"); prazna lista znači sve događaje projekta.
+type Webhook struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	ProjectID  string             `bson:"project_id" json:"projectId"`
+	URL        string             `bson:"url" json:"url"`
+	Secret     string             `bson:"secret" json:"secret,omitempty"`
+	EventTypes []string           `bson:"event_types" json:"eventTypes"`
+	Active     bool               `bson:"active" json:"active"`
+	CreatedBy  string             `bson:"created_by" json:"createdBy"`
+	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updatedAt"`
+}
+
+// WebhookRequest je telo zahteva za pravljenje i izmenu webhook-a. Secret se
+// pravi sam ako nije poslat, a pri izmeni prazan Secret zadržava stari.
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"eventTypes"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookDelivery je jedan događaj koji treba isporučiti webhook-u, sa
+// svim pokušajima isporuke.
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	WebhookID     primitive.ObjectID `bson:"webhook_id" json:"webhookId"`
+	ProjectID     string             `bson:"project_id" json:"projectId"`
+	EventID       string             `bson:"event_id" json:"eventId"`
+	EventType     string             `bson:"event_type" json:"eventType"`
+	Payload       json.RawMessage    `bson:"payload" json:"payload"`
+	Status        string             `bson:"status" json:"status"`
+	Tries         int                `bson:"tries" json:"tries"`
+	Attempts      []DeliveryAttempt  `bson:"attempts" json:"attempts"`
+	ResponseCode  int                `bson:"response_code,omitempty" json:"responseCode,omitempty"`
+	LastError     string             `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"nextAttemptAt"`
+	LockedUntil   time.Time          `bson:"locked_until" json:"-"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	DeliveredAt   *time.Time         `bson:"delivered_at,omitempty" json:"deliveredAt,omitempty"`
+	RedeliveredAt *time.Time         `bson:"redelivered_at,omitempty" json:"redeliveredAt,omitempty"`
+}
+
+// DeliveryAttempt je zapis jednog slanja; ResponseCode je nula kada odgovor
+// nije ni stigao.
+type DeliveryAttempt struct {
+	At           time.Time `bson:"at" json:"at"`
+	ResponseCode int       `bson:"response_code,omitempty" json:"responseCode,omitempty"`
+	Error        string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMs   int64     `bson:"duration_ms" json:"durationMs"`
+}
@@ -0,0 +1,52 @@
+package outbox
+
+import (
+	"context"
+	"contracts"
+	"log"
+	"project-service/db"
+	"shared/outbox"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// projects is the outbox of project-service.
+var projects = outbox.New(outbox.Config{
+	Service:    "project-service",
+	Collection: Collection,
+	Stream:     Stream,
+	History:    func(h contracts.History) contracts.Message { return &contracts.ProjectHistory{History: h} },
+	Endpoints: map[string]string{
+		outbox.DestinationEventStore: "http://event_sourcing:8080/event/append",
+	},
+})
+
+// Messages are built with the shared constructors.
+type Message = outbox.Message
+
+var (
+	Event    = outbox.Event
+	NATS     = outbox.NATS
+	Contract = outbox.Contract
+)
+
+func Collection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("project_outbox")
+}
+
+// Enqueue stores messages in the outbox. ctx must be the session context of
+// the transaction writing the domain change, otherwise the two can diverge.
+func Enqueue(ctx context.Context, messages ...Message) error {
+	return projects.Enqueue(ctx, messages...)
+}
+
+// EnsureStream creates the stream, or updates it to the current config.
+func EnsureStream(ctx context.Context, js jetstream.JetStream) error {
+	return projects.EnsureStream(ctx, js)
+}
+
+func NewRelay(logger *log.Logger, natsConn *nats.Conn, js jetstream.JetStream) *outbox.Relay {
+	return projects.NewRelay(logger, natsConn, js)
+}
@@ -0,0 +1,20 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Stream keeps every project.* message published through the relay, so durable
+// consumers that are down when a message is published get it once they are
+// back. Other subjects stay on core NATS.
+var Stream = jetstream.StreamConfig{
+	Name:     "PROJECTS",
+	Subjects: []string{"project.>"},
+	Storage:  jetstream.FileStorage,
+	MaxAge:   7 * 24 * time.Hour,
+	// Nats-Msg-Id is the idempotency key, so a redelivered outbox message
+	// within the window is stored only once.
+	Duplicates: 2 * time.Minute,
+}
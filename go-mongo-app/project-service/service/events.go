@@ -0,0 +1,47 @@
+package service
+
+import (
+	"contracts"
+	"project-service/models"
+	"time"
+)
+
+// Poruke koje se upisuju u outbox zajedno sa promenom projekta.
+
+func eventTime() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func projectCreatedEvent(projectID, managerID, name string) map[string]interface{} {
+	formattedTime := eventTime()
+	return map[string]interface{}{
+		"type":    "Project Created",
+		"version": 2,
+		"time":    formattedTime,
+		"event": map[string]interface{}{
+			"projectId": projectID,
+			"managerId": managerID,
+			"name":      name,
+			"createdAt": formattedTime,
+		},
+		"projectId": projectID,
+	}
+}
+
+func projectMemberEvent(eventType, projectID, userID string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    eventType,
+		"version": 1,
+		"time":    eventTime(),
+		"event": map[string]interface{}{
+			"memberId":  userID,
+			"projectId": projectID,
+		},
+		"projectId": projectID,
+	}
+}
+
+// projectMember opisuje člana projekta za poruke iz paketa contracts.
+func projectMember(userID string, project *models.Project) contracts.ProjectMember {
+	return contracts.ProjectMember{UserID: userID, ProjectID: project.ID.Hex(), ProjectName: project.Title}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"contracts"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"project-service/db"
+	"project-service/models"
+	"project-service/outbox"
+	"shared/saga"
+	"shared/security"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrProjectNotFound     = errors.New("project not found")
+	ErrProjectBeingDeleted = errors.New("project is being deleted")
+	deletionActions        = map[string]saga.Action{
+		// Dok je projekat samo označen, brisanje može da se poništi
+		"mark_deleting":  {Run: markProjectDeleting, Compensate: unmarkProjectDeleting},
+		"delete_task":    {Run: deleteProjectTask},
+		"delete_project": {Run: deleteProjectDocument},
+	}
+)
+
+// StartProjectDeletion pokreće sagu brisanja projekta sa svim njegovim
+// zadacima, ili vraća sagu koja ga već briše.
+func StartProjectDeletion(ctx context.Context, projectID string, requestedBy string) (*saga.DeletionSaga, error) {
+	objID, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid projectID format: %v", err)
+	}
+
+	var project models.Project
+	err = db.Client.Database("testdb").Collection("projects").FindOne(ctx, bson.M{"_id": objID}).Decode(&project)
+	if err == mongo.ErrNoDocuments {
+		if deletion, err := sagas.Active(ctx, projectID); err == nil {
+			return deletion, nil
+		}
+		return nil, ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find project with ID %v: %v", projectID, err)
+	}
+
+	// Svaki zadatak je poseban korak kako bi se video napredak brisanja
+	steps := []string{"mark_deleting"}
+	for _, taskID := range project.Tasks {
+		steps = append(steps, "delete_task:"+taskID)
+	}
+	steps = append(steps, "delete_project")
+
+	return sagas.Start(ctx, saga.NewDeletion(projectID, projectID, requestedBy, steps...))
+}
+
+func markProjectDeleting(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	return setProjectDeleting(ctx, deletion.ResourceID, true)
+}
+
+func unmarkProjectDeleting(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	return setProjectDeleting(ctx, deletion.ResourceID, false)
+}
+
+func setProjectDeleting(ctx context.Context, projectID string, deleting bool) error {
+	objID, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid projectID format: %v", saga.ErrPermanent, err)
+	}
+
+	update := bson.M{"$set": bson.M{"deleting": true}}
+	if !deleting {
+		update = bson.M{"$unset": bson.M{"deleting": ""}}
+	}
+	_, err = db.Client.Database("testdb").Collection("projects").UpdateOne(ctx, bson.M{"_id": objID}, update)
+	return err
+}
+
+// deleteProjectTask briše zadatak preko task-service, koji i sam vodi sagu
+// brisanja; dok ona traje, korak se ponavlja.
+func deleteProjectTask(ctx context.Context, _ *saga.DeletionSaga, taskID string) error {
+	url := fmt.Sprintf("http://task-service:8080/tasks/delete/%s", taskID)
+	return callServiceStep(ctx, "DELETE", url)
+}
+
+// deleteProjectDocument briše projekat i obaveštava njegove članove.
+func deleteProjectDocument(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	objID, err := primitive.ObjectIDFromHex(deletion.ResourceID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid projectID format: %v", saga.ErrPermanent, err)
+	}
+	collection := db.Client.Database("testdb").Collection("projects")
+
+	var project models.Project
+	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&project)
+	if err == mongo.ErrNoDocuments {
+		// Već obrisano u prethodnom pokušaju
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Zadaci dodati pre nego što je projekat označen nisu bili u sagi
+	for _, taskID := range project.Tasks {
+		if sagaHasStep(deletion, "delete_task:"+taskID) {
+			continue
+		}
+		if err := deleteProjectTask(ctx, deletion, taskID); err != nil {
+			return fmt.Errorf("deleting task %s: %w", taskID, err)
+		}
+	}
+
+	return db.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		result, err := collection.DeleteOne(sessCtx, bson.M{"_id": objID})
+		if err != nil {
+			return fmt.Errorf("failed to delete project: %v", err)
+		}
+		if result.DeletedCount == 0 {
+			return nil
+		}
+
+		var messages []outbox.Message
+		for _, userID := range project.Users {
+			messages = append(messages,
+				outbox.Contract(&contracts.ProjectDeleted{ProjectMember: projectMember(userID, &project)}),
+			)
+		}
+		return outbox.Enqueue(sessCtx, messages...)
+	})
+}
+
+func sagaHasStep(deletion *saga.DeletionSaga, name string) bool {
+	for _, step := range deletion.Steps {
+		if step.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// callServiceStep poziva drugi servis u ime sage. Ne postojeći resurs se
+// smatra već obrisanim, pa se korak može bezbedno ponavljati.
+func callServiceStep(ctx context.Context, method, url string) error {
+	token, err := security.ServiceToken("project-service")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	message := strings.TrimSpace(string(body))
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	case http.StatusAccepted:
+		return saga.ErrInProgress
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusConflict:
+		return fmt.Errorf("%w: %s %s: %s", saga.ErrPermanent, resp.Status, url, message)
+	default:
+		return fmt.Errorf("%s %s: %s", resp.Status, url, message)
+	}
+}
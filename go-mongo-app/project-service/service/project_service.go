@@ -3,6 +3,7 @@ package service
 import (
 	"bytes"
 	"context"
+	"contracts"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"net/http"
 	"project-service/db"
 	"project-service/models"
+	"project-service/outbox"
 	"regexp"
 	"strings"
 	"time"
@@ -257,13 +259,21 @@ func CreateProject(project models.Project) (string, error) {
 		"createdAt":         time.Now(),
 	}
 
-	result, err := collection.InsertOne(context.TODO(), safeProject)
+	// Projekat i Project Created događaj se upisuju u istoj transakciji
+	var projectID string
+	err = db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		result, err := collection.InsertOne(sessCtx, safeProject)
+		if err != nil {
+			return err
+		}
+		projectID = result.InsertedID.(primitive.ObjectID).Hex()
+		return outbox.Enqueue(sessCtx, outbox.Event(projectCreatedEvent(projectID, project.ManagerID, project.Title)))
+	})
 	if err != nil {
 		return "", err
 	}
 
 	// Vraćanje generisanog ID-a
-	projectID := result.InsertedID.(primitive.ObjectID).Hex()
 	return projectID, nil
 }
 
@@ -329,6 +339,9 @@ func AddUsersToProject(projectID string, userIDs []string, token string) error {
 	} else if err != nil {
 		return err
 	}
+	if project.Deleting {
+		return ErrProjectBeingDeleted
+	}
 
 	if len(project.Users)+len(userIDs) > project.MaxPeople {
 		return errors.New("adding these users exceeds the max number of users for this project")
@@ -340,18 +353,27 @@ func AddUsersToProject(projectID string, userIDs []string, token string) error {
 				return fmt.Errorf("user %s is already a member of this project", userID)
 			}
 		}
-
-		_, err := collection.UpdateOne(
-			context.TODO(),
-			bson.M{"_id": projectObjectID},
-			bson.M{"$addToSet": bson.M{"users": userID}},
-		)
-		if err != nil {
-			return fmt.Errorf("failed to add user %s to project: %v", userID, err)
-		}
 	}
 
-	return nil
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		var messages []outbox.Message
+		for _, userID := range userIDs {
+			_, err := collection.UpdateOne(
+				sessCtx,
+				bson.M{"_id": projectObjectID},
+				bson.M{"$addToSet": bson.M{"users": userID}},
+			)
+			if err != nil {
+				return fmt.Errorf("failed to add user %s to project: %v", userID, err)
+			}
+
+			messages = append(messages,
+				outbox.Contract(&contracts.ProjectMemberAdded{ProjectMember: projectMember(userID, &project)}),
+				outbox.Event(projectMemberEvent("Member Added to Project", projectID, userID)),
+			)
+		}
+		return outbox.Enqueue(sessCtx, messages...)
+	})
 }
 
 func countProjectUsers(projectID string) (int, error) {
@@ -418,16 +440,25 @@ func RemoveUsersFromProject(projectID string, userIDs []string) error {
 	}
 
 	// Remove users from the project
-	_, err = collection.UpdateOne(
-		context.TODO(),
-		bson.M{"_id": projectObjectID},
-		bson.M{"$pull": bson.M{"users": bson.M{"$in": userIDs}}},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to remove users from project: %v", err)
-	}
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		_, err := collection.UpdateOne(
+			sessCtx,
+			bson.M{"_id": projectObjectID},
+			bson.M{"$pull": bson.M{"users": bson.M{"$in": userIDs}}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to remove users from project: %v", err)
+		}
 
-	return nil
+		var messages []outbox.Message
+		for _, userID := range userIDs {
+			messages = append(messages,
+				outbox.Contract(&contracts.ProjectMemberRemoved{ProjectMember: projectMember(userID, &project)}),
+				outbox.Event(projectMemberEvent("Member Removed from Project", projectID, userID)),
+			)
+		}
+		return outbox.Enqueue(sessCtx, messages...)
+	})
 }
 
 func GetProjectByTitle(title string) (bool, error) {
@@ -465,6 +496,9 @@ func AddTaskToProject(projectID string, taskID string) error {
 	} else if err != nil {
 		return err
 	}
+	if project.Deleting {
+		return ErrProjectBeingDeleted
+	}
 
 	_, err = collection.UpdateOne(
 		context.TODO(),
@@ -478,6 +512,29 @@ func AddTaskToProject(projectID string, taskID string) error {
 	return nil
 }
 
+// RemoveTaskFromProject uklanja zadatak iz liste zadataka projekta.
+func RemoveTaskFromProject(projectID string, taskID string) error {
+	projectObjectID, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		return errors.New("invalid project ID format")
+	}
+
+	collection := db.Client.Database("testdb").Collection("projects")
+	result, err := collection.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": projectObjectID},
+		bson.M{"$pull": bson.M{"tasks": taskID}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrProjectNotFound
+	}
+
+	return nil
+}
+
 func IsActiveProject(projectID string, token string) (bool, error) {
 	// Lista za skladištenje statusa
 	var taskStatuses []string
@@ -591,89 +648,6 @@ func isValidTitle(title string) bool {
 	return regexp.MustCompile(`^[a-zA-Z0-9\s]+$`).MatchString(title)
 }
 
-// DeleteProjectByID briše projekat iz MongoDB-a i briše sve povezane taskove.
-func DeleteProjectByID(projectID string, token string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Poveži se na kolekciju "projects" u MongoDB-u
-	collection := db.Client.Database("testdb").Collection("projects")
-
-	// Pokušaj da parsiraš projectID kao ObjectID
-	objID, err := primitive.ObjectIDFromHex(projectID)
-	if err != nil {
-		return fmt.Errorf("invalid projectID format: %v", err)
-	}
-
-	// Log za projectID
-	fmt.Println("Attempting to delete project with ObjectID:", objID)
-
-	// Dohvati projekat iz baze kako bismo dobili listu taskova
-	var project models.Project
-	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&project)
-	if err != nil {
-		return fmt.Errorf("could not find project with ID %v: %v", projectID, err)
-	}
-
-	// 2. Pozovi brisanje svih taskova vezanih za projekat
-	for _, taskID := range project.Tasks {
-		// Napravi DELETE zahtev za svaki task, prosleđujući token
-		err := deleteTask(taskID, token)
-		if err != nil {
-			return fmt.Errorf("failed to delete task with ID %s: %v", taskID, err)
-		}
-	}
-
-	// 3. Obriši projekat iz MongoDB-a
-	filter := bson.M{"_id": objID}
-	result, err := collection.DeleteOne(ctx, filter)
-	if err != nil {
-		return fmt.Errorf("failed to delete project: %v", err)
-	}
-
-	// Proveri rezultat
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("no project found with projectID: %s", projectID)
-	}
-
-	// Uspešno obrisano
-	fmt.Println("Project deleted successfully")
-	return nil
-}
-
-// deleteTask šalje HTTP DELETE zahtev za brisanje taska na task-service
-func deleteTask(taskID string, token string) error {
-	// URL za DELETE zahtev
-	url := fmt.Sprintf("http://task-service:8080/tasks/delete/%s", taskID)
-
-	// Napravimo DELETE zahtev
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
-
-	// Postavimo Authorization header sa Bearer tokenom
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	// Setuj HTTP klijent sa timeout-om
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	// Pošaljemo zahtev
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	// Proveri status kod odgovora
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to delete task, received status: %s", resp.Status)
-	}
-
-	// Task uspešno obrisan
-	fmt.Printf("Task %s deleted successfully\n", taskID)
-	return nil
-}
 func UpdateTaskOrder(projectID string, taskIDs []string, token string) error {
 	// Provera validnosti tokena
 	_, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
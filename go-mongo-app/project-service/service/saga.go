@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"log"
+	"project-service/db"
+	"shared/saga"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// sagas izvršava korake iz deletionActions nad sagama brisanja ovog servisa.
+var sagas = saga.New(sagaCollection, deletionActions)
+
+func sagaCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("project_deletion_sagas")
+}
+
+// EnsureSagaIndexes obezbeđuje da se za jedan resurs izvršava najviše jedno brisanje.
+func EnsureSagaIndexes(ctx context.Context) error {
+	return sagas.CreateIndexes(ctx)
+}
+
+// GetLatestDeletion vraća poslednju sagu brisanja resursa.
+func GetLatestDeletion(ctx context.Context, resourceID string) (*saga.DeletionSaga, error) {
+	return sagas.Latest(ctx, resourceID)
+}
+
+// RunSaga pomera sagu koliko je trenutno moguće.
+func RunSaga(ctx context.Context, sagaID primitive.ObjectID) (*saga.DeletionSaga, error) {
+	return sagas.Advance(ctx, sagaID)
+}
+
+// RunDeletionSagas nastavlja nezavršene sage dok se ctx ne otkaže.
+func RunDeletionSagas(ctx context.Context, logger *log.Logger) {
+	sagas.Run(ctx, logger)
+}
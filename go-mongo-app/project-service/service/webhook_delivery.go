@@ -0,0 +1,418 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"contracts"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"project-service/models"
+	"project-service/outbox"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+
+	// webhookConsumer je trajni consumer koji pravi isporuke; sve instance
+	// servisa ga dele.
+	webhookConsumer     = "project-webhooks"
+	webhookPollInterval = 2 * time.Second
+	webhookLease        = 30 * time.Second
+	webhookTimeout      = 10 * time.Second
+	maxWebhookAttempts  = 8
+	maxWebhookBackoff   = time.Hour
+	// maxLoggedAttempts ograničava dnevnik pokušaja jedne isporuke.
+	maxLoggedAttempts    = 20
+	maxDeliveriesPerPage = 200
+
+	webhookSignatureHeader = "X-Taskio-Signature"
+)
+
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// tasksStream pravi task-service; ovde se pravi samo ako još ne postoji.
+var tasksStream = jetstream.StreamConfig{
+	Name:       "TASKS",
+	Subjects:   []string{"task.>"},
+	Storage:    jetstream.FileStorage,
+	MaxAge:     7 * 24 * time.Hour,
+	Duplicates: 2 * time.Minute,
+}
+
+// webhookPayload je telo zahteva koji webhook prima. Data je poruka onakva
+// kakva je objavljena na NATS-u.
+type webhookPayload struct {
+	ID        string          `json:"id"`
+	Event     string          `json:"event"`
+	Subject   string          `json:"subject"`
+	ProjectID string          `json:"projectId"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// ListenForWebhookEvents pravi isporuke za događaje projekata i zadataka:
+// iste poruke koje idu na NATS i događaje upisane u event store, koje outbox
+// objavljuje na project.history i task.history.
+func ListenForWebhookEvents(ctx context.Context, js jetstream.JetStream, logger *log.Logger) error {
+	for _, config := range []jetstream.StreamConfig{outbox.Stream, tasksStream} {
+		if _, err := js.Stream(ctx, config.Name); errors.Is(err, jetstream.ErrStreamNotFound) {
+			_, err = js.CreateStream(ctx, config)
+			if err != nil && !errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+				return fmt.Errorf("creating stream %s: %v", config.Name, err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("looking up stream %s: %v", config.Name, err)
+		}
+
+		consumer, err := js.CreateOrUpdateConsumer(ctx, config.Name, jetstream.ConsumerConfig{
+			Durable:        webhookConsumer,
+			FilterSubjects: webhookSubjects(config),
+			// Webhook-ovi dobijaju događaje od trenutka kada je consumer napravljen
+			DeliverPolicy: jetstream.DeliverNewPolicy,
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			AckWait:       30 * time.Second,
+			MaxDeliver:    10,
+		})
+		if err != nil {
+			return fmt.Errorf("creating consumer on %s: %v", config.Name, err)
+		}
+
+		stream := config.Name
+		_, err = consumer.Consume(func(msg jetstream.Msg) { handleWebhookEvent(ctx, stream, msg, logger) })
+		if err != nil {
+			return fmt.Errorf("consuming %s: %v", config.Name, err)
+		}
+	}
+	return nil
+}
+
+// webhookSubjects vraća subjekte iz paketa contracts koje stream čuva.
+func webhookSubjects(config jetstream.StreamConfig) []string {
+	prefix := strings.TrimSuffix(config.Subjects[0], ">")
+	subjects := []string{}
+	for _, subject := range contracts.Subjects() {
+		if strings.HasPrefix(subject, prefix) {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Strings(subjects)
+	return subjects
+}
+
+func handleWebhookEvent(ctx context.Context, stream string, msg jetstream.Msg, logger *log.Logger) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		logger.Println("Webhooks: reading message metadata failed:", err)
+		msg.Term()
+		return
+	}
+
+	eventID := msg.Headers().Get(nats.MsgIdHdr)
+	if eventID == "" {
+		eventID = stream + "-" + strconv.FormatUint(meta.Sequence.Stream, 10)
+	}
+
+	err = dispatchWebhookEvent(ctx, eventID, msg.Subject(), msg.Data())
+	if errors.Is(err, contracts.ErrInvalid) {
+		logger.Printf("Webhooks: dropping %s message %s: %v", msg.Subject(), eventID, err)
+		msg.Term()
+		return
+	}
+	if err != nil {
+		logger.Printf("Webhooks: dispatching %s message %s failed: %v", msg.Subject(), eventID, err)
+		msg.NakWithDelay(time.Duration(meta.NumDelivered) * 5 * time.Second)
+		return
+	}
+	msg.Ack()
+}
+
+// dispatchWebhookEvent pravi isporuku za svaki uključen webhook projekta koji
+// prima događaj. Događaj iz istorije se za webhook zove po vrsti iz event
+// store-a, a ostali po subjektu.
+func dispatchWebhookEvent(ctx context.Context, eventID, subject string, data []byte) error {
+	message, err := contracts.Decode(subject, data)
+	if err != nil {
+		return err
+	}
+	var ref struct {
+		ProjectID string `json:"projectId"`
+	}
+	if err := json.Unmarshal(data, &ref); err != nil || ref.ProjectID == "" {
+		return nil
+	}
+
+	event := subject
+	switch m := message.(type) {
+	case *contracts.ProjectHistory:
+		event, eventID = m.Type, m.ID
+	case *contracts.TaskHistory:
+		event, eventID = m.Type, m.ID
+	}
+
+	cursor, err := webhooksCollection().Find(ctx, bson.M{"project_id": ref.ProjectID, "active": true})
+	if err != nil {
+		return err
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range webhooks {
+		if !webhookSubscribed(&webhooks[i], event, subject) {
+			continue
+		}
+		payload, err := json.Marshal(webhookPayload{
+			ID:        eventID,
+			Event:     event,
+			Subject:   subject,
+			ProjectID: ref.ProjectID,
+			CreatedAt: now.UTC(),
+			Data:      data,
+		})
+		if err != nil {
+			return err
+		}
+		delivery := models.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     webhooks[i].ID,
+			ProjectID:     ref.ProjectID,
+			EventID:       eventID,
+			EventType:     event,
+			Payload:       payload,
+			Status:        DeliveryPending,
+			Attempts:      []models.DeliveryAttempt{},
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		}
+		_, err = webhookDeliveriesCollection().InsertOne(ctx, delivery)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunWebhookDeliveries šalje isporuke koje čekaju dok se ctx ne prekine.
+func RunWebhookDeliveries(ctx context.Context, logger *log.Logger) {
+	client := &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: webhookDialer().DialContext},
+		// Preusmerenje se beleži kao odgovor, a ne prati
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			delivery, err := claimDelivery(ctx)
+			if err != nil {
+				logger.Println("Webhooks: claiming delivery failed:", err)
+				break
+			}
+			if delivery == nil {
+				break
+			}
+			if err := deliverWebhook(ctx, client, delivery); err != nil {
+				logger.Printf("Webhooks: recording delivery %s failed: %v", delivery.ID.Hex(), err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func claimDelivery(ctx context.Context) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	var delivery models.WebhookDelivery
+	err := webhookDeliveriesCollection().FindOneAndUpdate(ctx,
+		bson.M{"status": DeliveryPending, "next_attempt_at": bson.M{"$lte": now}, "locked_until": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"locked_until": now.Add(webhookLease)}},
+		options.FindOneAndUpdate().SetSort(bson.M{"next_attempt_at": 1}).SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// deliverWebhook šalje isporuku i beleži pokušaj. Greške mreže, 408, 429 i
+// 5xx se ponavljaju sa sve dužom pauzom; ostali odgovori su konačni.
+func deliverWebhook(ctx context.Context, client *http.Client, delivery *models.WebhookDelivery) error {
+	var webhook models.Webhook
+	err := webhooksCollection().FindOne(ctx, bson.M{"_id": delivery.WebhookID}).Decode(&webhook)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	attempt := models.DeliveryAttempt{At: time.Now()}
+	retry := false
+	switch {
+	case err == mongo.ErrNoDocuments:
+		attempt.Error = "webhook was deleted"
+	case !webhook.Active:
+		attempt.Error = "webhook is disabled"
+	default:
+		attempt.ResponseCode, err = sendWebhook(ctx, client, &webhook, delivery)
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		retry = attempt.ResponseCode == 0 || attempt.ResponseCode == http.StatusRequestTimeout ||
+			attempt.ResponseCode == http.StatusTooManyRequests || attempt.ResponseCode >= 500
+	}
+	attempt.DurationMs = time.Since(attempt.At).Milliseconds()
+
+	set := bson.M{"locked_until": time.Time{}, "response_code": attempt.ResponseCode, "last_error": attempt.Error}
+	switch {
+	case attempt.Error == "":
+		set["status"] = DeliveryDelivered
+		set["delivered_at"] = attempt.At
+	case retry && delivery.Tries+1 < maxWebhookAttempts:
+		set["next_attempt_at"] = attempt.At.Add(webhookBackoff(delivery.Tries + 1))
+	default:
+		set["status"] = DeliveryFailed
+	}
+
+	_, err = webhookDeliveriesCollection().UpdateOne(ctx, bson.M{"_id": delivery.ID}, bson.M{
+		"$set":  set,
+		"$inc":  bson.M{"tries": 1},
+		"$push": bson.M{"attempts": bson.M{"$each": []models.DeliveryAttempt{attempt}, "$slice": -maxLoggedAttempts}},
+	})
+	return err
+}
+
+// signWebhookPayload vraća vrednost zaglavlja X-Taskio-Signature: HMAC-SHA256
+// nad "<timestamp>.<telo>", da primalac može da odbije stare ponovljene zahteve.
+func signWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhook šalje telo isporuke potpisano tajnom webhook-a.
+func sendWebhook(ctx context.Context, client *http.Client, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Taskio-Webhooks/1.0")
+	req.Header.Set("X-Taskio-Event", delivery.EventType)
+	req.Header.Set("X-Taskio-Delivery", delivery.ID.Hex())
+	req.Header.Set("X-Taskio-Timestamp", timestamp)
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+}
+
+func webhookBackoff(tries int) time.Duration {
+	delay := 30 * time.Second << uint(tries-1)
+	if delay <= 0 || delay > maxWebhookBackoff {
+		return maxWebhookBackoff
+	}
+	return delay
+}
+
+// GetWebhookDeliveries vraća poslednje isporuke webhook-a, najnovije prve.
+func GetWebhookDeliveries(ctx context.Context, projectID, webhookID, userID string, limit int64) ([]models.WebhookDelivery, error) {
+	if _, err := managedProject(projectID, userID); err != nil {
+		return nil, err
+	}
+	webhook, err := projectWebhook(ctx, projectID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxDeliveriesPerPage {
+		limit = maxDeliveriesPerPage
+	}
+
+	cursor, err := webhookDeliveriesCollection().Find(ctx, bson.M{"webhook_id": webhook.ID},
+		options.Find().SetSort(bson.M{"_id": -1}).SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhook ponovo šalje isporuku sa istim telom i ID-em, bez obzira
+// na to da li je ranije uspela. Dnevnik pokušaja se zadržava.
+func RedeliverWebhook(ctx context.Context, projectID, webhookID, deliveryID, userID string) (*models.WebhookDelivery, error) {
+	if _, err := managedProject(projectID, userID); err != nil {
+		return nil, err
+	}
+	webhook, err := projectWebhook(ctx, projectID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := primitive.ObjectIDFromHex(deliveryID)
+	if err != nil {
+		return nil, ErrDeliveryNotFound
+	}
+
+	now := time.Now()
+	var delivery models.WebhookDelivery
+	err = webhookDeliveriesCollection().FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "webhook_id": webhook.ID},
+		bson.M{"$set": bson.M{
+			"status":          DeliveryPending,
+			"tries":           0,
+			"next_attempt_at": now,
+			"locked_until":    time.Time{},
+			"redelivered_at":  now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
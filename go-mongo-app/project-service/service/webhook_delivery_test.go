@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"project-service/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Vektori su izračunati nezavisno (Python hmac/hashlib).
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{
+			name:      "event payload",
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			payload:   `{"type":"task.created","taskId":"t1"}`,
+			want:      "sha256=dde7a733b9d7917153a552910b2ae06b7b4cf2c666e79c5ee075ef81e18d7481",
+		},
+		{
+			name:      "empty payload",
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			payload:   "",
+			want:      "sha256=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc",
+		},
+		{
+			name:      "other secret and timestamp",
+			secret:    "another-secret",
+			timestamp: "1700000001",
+			payload:   `{"type":"task.created","taskId":"t1"}`,
+			want:      "sha256=8e4e5531dc300b26a2446fd62908d6cf7c7ba5aafb5485cdb95055aa23049741",
+		},
+		{
+			name:      "utf-8 secret and payload",
+			secret:    "ključ",
+			timestamp: "1700000000",
+			payload:   `{"name":"Zadatak č"}`,
+			want:      "sha256=23422c965cf104b2718cf77cdc95fbb7324dde32a2648c854284a32fd609ed15",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signWebhookPayload(tt.secret, tt.timestamp, []byte(tt.payload)); got != tt.want {
+				t.Errorf("signature = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendWebhookSignsRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		wantErr    bool
+		wantStatus int
+	}{
+		{name: "accepted", status: http.StatusNoContent, wantStatus: http.StatusNoContent},
+		{name: "rejected", status: http.StatusInternalServerError, wantErr: true, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := &models.Webhook{Secret: "whsec_test"}
+			delivery := &models.WebhookDelivery{ID: primitive.NewObjectID(), EventType: "task.created", Payload: []byte(`{"type":"task.created","taskId":"t1"}`)}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				want := signWebhookPayload(webhook.Secret, r.Header.Get("X-Taskio-Timestamp"), body)
+				if got := r.Header.Get(webhookSignatureHeader); got != want {
+					t.Errorf("signature header = %s, want %s", got, want)
+				}
+				if got := r.Header.Get("X-Taskio-Delivery"); got != delivery.ID.Hex() {
+					t.Errorf("delivery header = %s, want %s", got, delivery.ID.Hex())
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			webhook.URL = server.URL
+
+			status, err := sendWebhook(context.Background(), server.Client(), webhook, delivery)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+var ErrWebhookHostNotAllowed = errors.New("url must point to a public host")
+
+// sharedAddressSpace (100.64.0.0/10) koriste provajderi i neke mreže
+// kontejnera; net.IP.IsPrivate ga ne pokriva.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// blockedWebhookIP proverava da li je adresa interna: loopback, privatna,
+// link-local (uključujući 169.254.169.254) ili nespecifikovana.
+func blockedWebhookIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// checkWebhookHost razrešava host webhook-a i odbija ga ako je neka od
+// njegovih adresa interna.
+func checkWebhookHost(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedWebhookIP(ip) {
+			return ErrWebhookHostNotAllowed
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrWebhookHostNotAllowed
+	}
+	for _, addr := range addrs {
+		if blockedWebhookIP(addr.IP) {
+			return ErrWebhookHostNotAllowed
+		}
+	}
+	return nil
+}
+
+// webhookDialer proverava adresu na koju se zaista povezuje, posle
+// razrešavanja imena, pa ni DNS rebinding ne vodi do interne mreže.
+func webhookDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || blockedWebhookIP(ip) {
+				return ErrWebhookHostNotAllowed
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"project-service/db"
+	"project-service/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxWebhooksPerProject = 10
+
+var (
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrNotProjectManager  = errors.New("only the project manager can manage webhooks")
+	ErrTooManyWebhooks    = errors.New("project already has the maximum number of webhooks")
+	ErrInvalidWebhookURL  = errors.New("url must be an absolute http or https URL")
+	ErrInvalidWebhookType = errors.New("eventTypes must not contain empty values")
+)
+
+func webhooksCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("project_webhooks")
+}
+
+func webhookDeliveriesCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("project_webhook_deliveries")
+}
+
+// EnsureWebhookIndexes pravi indekse webhook-ova i isporuka. Jedinstven
+// indeks čuva od dve isporuke istog događaja istom webhook-u kada NATS
+// poruku isporuči ponovo.
+func EnsureWebhookIndexes(ctx context.Context) error {
+	_, err := webhooksCollection().Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.M{"project_id": 1}})
+	if err != nil {
+		return err
+	}
+	_, err = webhookDeliveriesCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "webhook_id", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+	})
+	return err
+}
+
+// managedProject vraća projekat ako je userID njegov menadžer.
+func managedProject(projectID, userID string) (*models.Project, error) {
+	project, err := GetProjectByID(projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.ManagerID != userID {
+		return nil, ErrNotProjectManager
+	}
+	return project, nil
+}
+
+func validateWebhook(ctx context.Context, req *models.WebhookRequest) error {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+	if err := checkWebhookHost(ctx, parsed.Hostname()); err != nil {
+		return err
+	}
+	for _, eventType := range req.EventTypes {
+		if strings.TrimSpace(eventType) == "" {
+			return ErrInvalidWebhookType
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhook pravi webhook projekta. Vraćeni webhook sadrži tajnu; posle
+// toga se ona više ne prikazuje.
+func CreateWebhook(ctx context.Context, projectID, userID string, req models.WebhookRequest) (*models.Webhook, error) {
+	if _, err := managedProject(projectID, userID); err != nil {
+		return nil, err
+	}
+	if err := validateWebhook(ctx, &req); err != nil {
+		return nil, err
+	}
+	count, err := webhooksCollection().CountDocuments(ctx, bson.M{"project_id": projectID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerProject {
+		return nil, ErrTooManyWebhooks
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	eventTypes := req.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	now := time.Now()
+	webhook := models.Webhook{
+		ID:         primitive.NewObjectID(),
+		ProjectID:  projectID,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: eventTypes,
+		Active:     req.Active == nil || *req.Active,
+		CreatedBy:  userID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if _, err := webhooksCollection().InsertOne(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetWebhooks vraća webhook-ove projekta, bez tajni.
+func GetWebhooks(ctx context.Context, projectID, userID string) ([]models.Webhook, error) {
+	if _, err := managedProject(projectID, userID); err != nil {
+		return nil, err
+	}
+	cursor, err := webhooksCollection().Find(ctx, bson.M{"project_id": projectID}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	webhooks := []models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func projectWebhook(ctx context.Context, projectID, webhookID string) (*models.Webhook, error) {
+	id, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	var webhook models.Webhook
+	err = webhooksCollection().FindOne(ctx, bson.M{"_id": id, "project_id": projectID}).Decode(&webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook menja URL, vrste događaja, tajnu ili uključenost webhook-a;
+// polja koja nisu poslata ostaju ista.
+func UpdateWebhook(ctx context.Context, projectID, webhookID, userID string, req models.WebhookRequest) (*models.Webhook, error) {
+	if _, err := managedProject(projectID, userID); err != nil {
+		return nil, err
+	}
+	webhook, err := projectWebhook(ctx, projectID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL == "" {
+		req.URL = webhook.URL
+	}
+	if err := validateWebhook(ctx, &req); err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"url": req.URL, "updated_at": time.Now()}
+	if req.EventTypes != nil {
+		set["event_types"] = req.EventTypes
+	}
+	if req.Secret != "" {
+		set["secret"] = req.Secret
+	}
+	if req.Active != nil {
+		set["active"] = *req.Active
+	}
+	err = webhooksCollection().FindOneAndUpdate(ctx, bson.M{"_id": webhook.ID}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(webhook)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// DeleteWebhook briše webhook i njegove isporuke.
+func DeleteWebhook(ctx context.Context, projectID, webhookID, userID string) error {
+	if _, err := managedProject(projectID, userID); err != nil {
+		return err
+	}
+	webhook, err := projectWebhook(ctx, projectID, webhookID)
+	if err != nil {
+		return err
+	}
+	if _, err := webhooksCollection().DeleteOne(ctx, bson.M{"_id": webhook.ID}); err != nil {
+		return err
+	}
+	_, err = webhookDeliveriesCollection().DeleteMany(ctx, bson.M{"webhook_id": webhook.ID})
+	return err
+}
+
+// webhookSubscribed proverava da li webhook prima događaj neke od datih vrsta.
+func webhookSubscribed(webhook *models.Webhook, eventTypes ...string) bool {
+	if len(webhook.EventTypes) == 0 {
+		return true
+	}
+	for _, wanted := range webhook.EventTypes {
+		for _, eventType := range eventTypes {
+			if wanted == eventType {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+// Package mail pravi MIME poruke i šalje ih preko SMTP-a ili u fajlove;
+// koriste ga svi servisi koji šalju email.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+type EmailConfig struct {
+	From     string
+	Password string
+	SMTPHost string
+	SMTPPort string
+}
+
+// ConfigFromEnv čita SMTP podešavanja iz EMAIL_FROM, EMAIL_PASSWORD,
+// SMTP_HOST i SMTP_PORT.
+func ConfigFromEnv() EmailConfig {
+	return EmailConfig{
+		From:     os.Getenv("EMAIL_FROM"),
+		Password: os.Getenv("EMAIL_PASSWORD"),
+		SMTPHost: os.Getenv("SMTP_HOST"),
+		SMTPPort: os.Getenv("SMTP_PORT"),
+	}
+}
+
+func (c EmailConfig) Configured() bool {
+	return c.From != "" && c.SMTPHost != "" && c.SMTPPort != ""
+}
+
+// Message je email sa tekstualnom i HTML verzijom istog sadržaja.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Bytes vraća poruku u MIME formatu (multipart/alternative), spremnu za slanje.
+func (m *Message) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	body := multipart.NewWriter(&buf)
+
+	header := textproto.MIMEHeader{}
+	header.Set("From", headerValue(m.From))
+	header.Set("To", headerValue(m.To))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", headerValue(m.Subject)))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(m.From))
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Type", "multipart/alternative; boundary="+body.Boundary())
+
+	var out bytes.Buffer
+	for _, key := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"} {
+		fmt.Fprintf(&out, "%s: %s\r\n", key, header.Get(key))
+	}
+	out.WriteString("\r\n")
+
+	// Klijenti prikazuju poslednji deo koji podržavaju, pa HTML ide posle teksta
+	parts := []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", m.Text},
+		{"text/html; charset=UTF-8", m.HTML},
+	}
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+// Address vraća samu adresu iz vrednosti kao što je "Taskio <taskio@example.com>".
+func Address(value string) string {
+	address, err := mail.ParseAddress(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return address.Address
+}
+
+// headerValue uklanja prelome redova da vrednost ne bi dodala nova zaglavlja.
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(Address(from), "@"); at >= 0 {
+		domain = Address(from)[at+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return fmt.Sprintf("<%x.%d@%s>", b, time.Now().UnixNano(), domain)
+}
@@ -0,0 +1,23 @@
+package mail
+
+import "time"
+
+const (
+	// MaxAttempts je broj pokušaja slanja pre nego što se poruka odbaci.
+	MaxAttempts = 8
+	// retryBase je pauza posle prvog neuspeha; svaka sledeća je duplo duža.
+	retryBase = 30 * time.Second
+	retryMax  = time.Hour
+)
+
+// RetryDelay vraća pauzu pred sledeći pokušaj posle attempts neuspelih.
+func RetryDelay(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	if delay > retryMax {
+		delay = retryMax
+	}
+	return delay
+}
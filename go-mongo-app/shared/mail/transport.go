@@ -0,0 +1,65 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Transport isporučuje gotovu poruku primaocu.
+type Transport interface {
+	Send(message *Message) error
+}
+
+// SMTPTransport šalje poruke preko SMTP servera.
+type SMTPTransport struct {
+	Config EmailConfig
+}
+
+func (t SMTPTransport) Send(message *Message) error {
+	data, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// Lokalni SMTP server za testiranje ne traži prijavu
+	var auth smtp.Auth
+	if t.Config.Password != "" {
+		auth = smtp.PlainAuth("", Address(t.Config.From), t.Config.Password, t.Config.SMTPHost)
+	}
+	return smtp.SendMail(t.Config.SMTPHost+":"+t.Config.SMTPPort, auth, Address(message.From), []string{Address(message.To)}, data)
+}
+
+// FileTransport upisuje svaku poruku kao .eml fajl u direktorijum Dir,
+// umesto da je šalje. Koristi se za lokalni razvoj i testove.
+type FileTransport struct {
+	Dir string
+}
+
+func (t FileTransport) Send(message *Message) error {
+	data, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(Address(message.To)))
+	return os.WriteFile(filepath.Join(t.Dir, name), data, 0o644)
+}
+
+// TransportFromEnv bira transport prema MAIL_TRANSPORT: "smtp" (podrazumevano)
+// ili "file", koji poruke upisuje u MAIL_DIR.
+func TransportFromEnv(config EmailConfig) Transport {
+	if os.Getenv("MAIL_TRANSPORT") == "file" {
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "taskio-mail")
+		}
+		return FileTransport{Dir: dir}
+	}
+	return SMTPTransport{Config: config}
+}
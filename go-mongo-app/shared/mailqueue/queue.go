@@ -0,0 +1,190 @@
+// Package mailqueue čuva poruke u MongoDB redu i šalje ih uz ponavljanje
+// neuspelih slanja.
+package mailqueue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"shared/mail"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	mailPending = "pending"
+	mailSending = "sending"
+	mailSent    = "sent"
+	mailFailed  = "failed"
+)
+
+const (
+	// sendLease je vreme za koje se poruka smatra zauzetom; posle toga je
+	// preuzima drugi pokušaj (npr. ako je servis pao tokom slanja).
+	sendLease = 2 * time.Minute
+	// keepFor je koliko dugo se poslate i odbačene poruke čuvaju u redu. Telo
+	// poruke se briše odmah po završetku, pa ostaju samo podaci o slanju.
+	keepFor      = 7 * 24 * time.Hour
+	pollInterval = 15 * time.Second
+)
+
+// bodyFields su delovi poruke koji se ne čuvaju posle slanja ili odustajanja;
+// linkovi za potvrdu i reset lozinke ne treba da ostanu u bazi.
+var bodyFields = bson.M{"text": "", "html": ""}
+
+type queuedMail struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Key           string             `bson:"key,omitempty"`
+	Template      string             `bson:"template"`
+	Locale        string             `bson:"locale"`
+	From          string             `bson:"from"`
+	To            string             `bson:"to"`
+	Subject       string             `bson:"subject"`
+	Text          string             `bson:"text"`
+	HTML          string             `bson:"html"`
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt"`
+	LastError     string             `bson:"lastError,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt"`
+	SentAt        *time.Time         `bson:"sentAt,omitempty"`
+	ExpiresAt     *time.Time         `bson:"expiresAt,omitempty"`
+}
+
+// Queue šalje poruke preko reda u bazi, tako da neuspelo slanje ne obara
+// zahtev i ponavlja se sa sve dužom pauzom. Kolekcija se zadaje funkcijom,
+// jer klijent baze često još ne postoji kada se red pravi.
+type Queue struct {
+	collection func() *mongo.Collection
+	transport  mail.Transport
+	wake       chan struct{}
+}
+
+func New(collection func() *mongo.Collection, transport mail.Transport) *Queue {
+	return &Queue{collection: collection, transport: transport, wake: make(chan struct{}, 1)}
+}
+
+// CreateIndexes pravi indeks za preuzimanje poruka, jedinstven indeks ključa
+// poruke i TTL indeks koji briše poslate i odbačene poruke.
+func (q *Queue) CreateIndexes(ctx context.Context) error {
+	_, err := q.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"key": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Enqueue stavlja poruku u red za slanje. template i locale se samo beleže,
+// da bi se u redu videlo o kojoj poruci je reč.
+func (q *Queue) Enqueue(ctx context.Context, template, locale string, message *mail.Message) error {
+	return q.EnqueueOnce(ctx, "", template, locale, message)
+}
+
+// EnqueueOnce stavlja poruku u red samo ako poruka sa ključem key još nije
+// stavljena, pa ponovna obrada istog događaja ne šalje poruku dva puta.
+// Prazan ključ se ne proverava.
+func (q *Queue) EnqueueOnce(ctx context.Context, key, template, locale string, message *mail.Message) error {
+	if message.To == "" {
+		return errors.New("missing recipient")
+	}
+
+	now := time.Now().UTC()
+	_, err := q.collection().InsertOne(ctx, queuedMail{
+		Key:           key,
+		Template:      template,
+		Locale:        locale,
+		From:          message.From,
+		To:            message.To,
+		Subject:       message.Subject,
+		Text:          message.Text,
+		HTML:          message.HTML,
+		Status:        mailPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run šalje poruke iz reda dok se ctx ne završi.
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			sent, err := q.sendNext(ctx)
+			if err != nil {
+				log.Println("Error processing mail queue:", err)
+			}
+			if !sent {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
+
+// sendNext preuzima jednu dospelu poruku i pokušava da je pošalje. Vraća
+// false kada u redu nema dospelih poruka.
+func (q *Queue) sendNext(ctx context.Context) (bool, error) {
+	now := time.Now().UTC()
+	filter := bson.M{
+		"status":        bson.M{"$in": []string{mailPending, mailSending}},
+		"nextAttemptAt": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": mailSending, "nextAttemptAt": now.Add(sendLease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	var queued queuedMail
+	err := q.collection().FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).SetReturnDocument(options.After),
+	).Decode(&queued)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sendErr := q.transport.Send(&mail.Message{From: queued.From, To: queued.To, Subject: queued.Subject, Text: queued.Text, HTML: queued.HTML})
+
+	now = time.Now().UTC()
+	expiresAt := now.Add(keepFor)
+	var result bson.M
+	switch {
+	case sendErr == nil:
+		result = bson.M{"$set": bson.M{"status": mailSent, "sentAt": now, "expiresAt": expiresAt}, "$unset": bodyFields}
+	case queued.Attempts >= mail.MaxAttempts:
+		log.Printf("Giving up on %s email to %s after %d attempts: %v", queued.Template, queued.To, queued.Attempts, sendErr)
+		result = bson.M{"$set": bson.M{"status": mailFailed, "lastError": sendErr.Error(), "expiresAt": expiresAt}, "$unset": bodyFields}
+	default:
+		log.Printf("Error sending %s email to %s (attempt %d): %v", queued.Template, queued.To, queued.Attempts, sendErr)
+		result = bson.M{"$set": bson.M{"status": mailPending, "lastError": sendErr.Error(), "nextAttemptAt": now.Add(mail.RetryDelay(queued.Attempts))}}
+	}
+	_, err = q.collection().UpdateOne(ctx, bson.M{"_id": queued.ID}, result)
+	return true, err
+}
@@ -0,0 +1,194 @@
+// Package outbox stores side effects of domain changes in the same MongoDB
+// transaction as the change and relays them to the event store, HTTP
+// endpoints and NATS afterwards.
+package outbox
+
+import (
+	"context"
+	"contracts"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Destinations a message can be relayed to.
+const (
+	DestinationEventStore = "event_store"
+	DestinationAnalytics  = "analytics"
+	DestinationNATS       = "nats"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	// StatusFailed messages ran out of attempts or were rejected by the
+	// destination; they stay in the collection for inspection.
+	StatusFailed = "failed"
+)
+
+// Message is a side effect of a domain change, stored in the same transaction
+// as the change and delivered later by the Relay.
+type Message struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Destination   string             `bson:"destination"`
+	Subject       string             `bson:"subject,omitempty"`
+	Payload       []byte             `bson:"payload"`
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at"`
+	LockedUntil   time.Time          `bson:"locked_until"`
+	LastError     string             `bson:"last_error,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	SentAt        *time.Time         `bson:"sent_at,omitempty"`
+
+	// body is marshalled into Payload by Enqueue
+	body interface{}
+}
+
+// Config describes the outbox of one service.
+type Config struct {
+	// Service prefixes idempotency keys and signs the relay's service token.
+	Service string
+	// Collection is a func because the database client usually does not
+	// exist yet when the outbox is created.
+	Collection func() *mongo.Collection
+	// Stream keeps the service's own subjects; other subjects stay on core NATS.
+	Stream jetstream.StreamConfig
+	// History wraps an event store event for the stream's history subject.
+	History func(contracts.History) contracts.Message
+	// Endpoints maps HTTP destinations to the URL their messages are posted to.
+	Endpoints map[string]string
+}
+
+type Outbox struct {
+	config Config
+}
+
+func New(config Config) *Outbox {
+	return &Outbox{config: config}
+}
+
+// IdempotencyKey identifies the message across delivery attempts so the
+// destination can drop duplicates.
+func (o *Outbox) IdempotencyKey(m Message) string {
+	return o.config.Service + "-" + m.ID.Hex()
+}
+
+// EnsureStream creates the stream, or updates it to the current config.
+func (o *Outbox) EnsureStream(ctx context.Context, js jetstream.JetStream) error {
+	_, err := js.CreateOrUpdateStream(ctx, o.config.Stream)
+	return err
+}
+
+func (o *Outbox) inStream(subject string) bool {
+	for _, pattern := range o.config.Stream.Subjects {
+		if strings.HasPrefix(subject, strings.TrimSuffix(pattern, ">")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Event creates a message appending the event to the event store. Enqueue
+// also publishes the event on the stream's history subject.
+func Event(event interface{}) Message {
+	return newMessage(DestinationEventStore, "", event)
+}
+
+// Analytics creates a status change message for analytics-service.
+func Analytics(payload interface{}) Message {
+	return newMessage(DestinationAnalytics, "", payload)
+}
+
+// NATS creates a message published on the given subject.
+func NATS(subject string, message interface{}) Message {
+	return newMessage(DestinationNATS, subject, message)
+}
+
+// Contract creates a message published on the contract's subject. The
+// contract is validated when the message is enqueued.
+func Contract(message contracts.Message) Message {
+	return newMessage(DestinationNATS, message.Subject(), message)
+}
+
+func newMessage(destination, subject string, body interface{}) Message {
+	now := time.Now()
+	return Message{
+		ID:            primitive.NewObjectID(),
+		Destination:   destination,
+		Subject:       subject,
+		Status:        StatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		body:          body,
+	}
+}
+
+// Enqueue stores messages in the outbox. ctx must be the session context of
+// the transaction writing the domain change, otherwise the two can diverge.
+func (o *Outbox) Enqueue(ctx context.Context, messages ...Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(messages))
+	for _, m := range messages {
+		payload, err := marshal(m.body)
+		if err != nil {
+			return fmt.Errorf("marshalling %s outbox message: %w", m.Destination, err)
+		}
+		m.Payload = payload
+		docs = append(docs, m)
+
+		if m.Destination == DestinationEventStore {
+			mirror, err := o.history(m)
+			if err != nil {
+				return fmt.Errorf("marshalling history of outbox message: %w", err)
+			}
+			docs = append(docs, mirror)
+		}
+	}
+	_, err := o.config.Collection().InsertMany(ctx, docs)
+	return err
+}
+
+func marshal(body interface{}) ([]byte, error) {
+	if contract, ok := body.(contracts.Message); ok {
+		return contracts.Encode(contract)
+	}
+	return json.Marshal(body)
+}
+
+// history mirrors an event store message on NATS. The mirror carries the
+// event's idempotency key, so consumers can match it to the stored event.
+func (o *Outbox) history(m Message) (Message, error) {
+	var event struct {
+		Type      string          `json:"type"`
+		Version   int             `json:"version"`
+		Time      string          `json:"time"`
+		ProjectID string          `json:"projectId"`
+		Event     json.RawMessage `json:"event"`
+	}
+	if err := json.Unmarshal(m.Payload, &event); err != nil {
+		return Message{}, err
+	}
+
+	mirror := Contract(o.config.History(contracts.History{
+		ID:           o.IdempotencyKey(m),
+		Type:         event.Type,
+		EventVersion: event.Version,
+		Time:         event.Time,
+		ProjectID:    event.ProjectID,
+		Event:        event.Event,
+	}))
+	payload, err := marshal(mirror.body)
+	if err != nil {
+		return Message{}, err
+	}
+	mirror.Payload = payload
+	return mirror, nil
+}
@@ -0,0 +1,230 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"shared/security"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	pollInterval = time.Second
+	lease        = 30 * time.Second
+	maxAttempts  = 10
+	maxBackoff   = 5 * time.Minute
+
+	idempotencyKeyHeader = "Idempotency-Key"
+)
+
+// errRejected marks a delivery the destination refused outright; retrying it
+// would not help, so the message is failed immediately.
+var errRejected = errors.New("rejected by destination")
+
+// Relay delivers outbox messages. Messages for a destination are delivered in
+// the order they were written: while the oldest one waits for a retry, the
+// ones behind it wait too.
+type Relay struct {
+	outbox   *Outbox
+	logger   *log.Logger
+	natsConn *nats.Conn
+	js       jetstream.JetStream
+	client   *http.Client
+}
+
+func (o *Outbox) NewRelay(logger *log.Logger, natsConn *nats.Conn, js jetstream.JetStream) *Relay {
+	return &Relay{
+		outbox:   o,
+		logger:   logger,
+		natsConn: natsConn,
+		js:       js,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, destination := range r.destinations() {
+			r.drain(ctx, destination)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// destinations lists the HTTP endpoints in a fixed order, followed by NATS.
+func (r *Relay) destinations() []string {
+	destinations := []string{}
+	for destination := range r.outbox.config.Endpoints {
+		destinations = append(destinations, destination)
+	}
+	sort.Strings(destinations)
+	return append(destinations, DestinationNATS)
+}
+
+func (r *Relay) drain(ctx context.Context, destination string) {
+	for ctx.Err() == nil {
+		message, err := r.claimNext(ctx, destination)
+		if err != nil {
+			r.logger.Printf("Outbox: claiming %s message failed: %v", destination, err)
+			return
+		}
+		if message == nil {
+			return
+		}
+		if !r.process(ctx, message) {
+			return
+		}
+	}
+}
+
+// claimNext leases the oldest pending message of a destination if it is due.
+func (r *Relay) claimNext(ctx context.Context, destination string) (*Message, error) {
+	var oldest Message
+	err := r.outbox.config.Collection().FindOne(ctx,
+		bson.M{"destination": destination, "status": StatusPending},
+		options.FindOne().SetSort(bson.M{"_id": 1}),
+	).Decode(&oldest)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if oldest.NextAttemptAt.After(now) || oldest.LockedUntil.After(now) {
+		return nil, nil
+	}
+
+	// Another relay instance may have claimed it in the meantime
+	var claimed Message
+	err = r.outbox.config.Collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": oldest.ID, "status": StatusPending, "locked_until": oldest.LockedUntil},
+		bson.M{"$set": bson.M{"locked_until": now.Add(lease)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&claimed)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &claimed, nil
+}
+
+// process delivers a claimed message and records the outcome. It reports
+// whether the next message of the same destination may be delivered.
+func (r *Relay) process(ctx context.Context, m *Message) bool {
+	err := r.deliver(m)
+	now := time.Now()
+
+	var update bson.M
+	switch {
+	case err == nil:
+		update = bson.M{"$set": bson.M{"status": StatusSent, "sent_at": now, "locked_until": time.Time{}}}
+	case errors.Is(err, errRejected) || m.Attempts+1 >= maxAttempts:
+		r.logger.Printf("Outbox: giving up on %s message %s after %d attempts: %v", m.Destination, m.ID.Hex(), m.Attempts+1, err)
+		update = bson.M{
+			"$set": bson.M{"status": StatusFailed, "last_error": err.Error(), "locked_until": time.Time{}},
+			"$inc": bson.M{"attempts": 1},
+		}
+	default:
+		r.logger.Printf("Outbox: delivering %s message %s failed, retrying: %v", m.Destination, m.ID.Hex(), err)
+		update = bson.M{
+			"$set": bson.M{"next_attempt_at": now.Add(backoff(m.Attempts + 1)), "last_error": err.Error(), "locked_until": time.Time{}},
+			"$inc": bson.M{"attempts": 1},
+		}
+	}
+
+	if _, updateErr := r.outbox.config.Collection().UpdateOne(ctx, bson.M{"_id": m.ID}, update); updateErr != nil {
+		// The lease expires and the message is delivered again; the
+		// idempotency key keeps the destination from applying it twice.
+		r.logger.Printf("Outbox: recording outcome of message %s failed: %v", m.ID.Hex(), updateErr)
+		return false
+	}
+	return err == nil || errors.Is(err, errRejected) || m.Attempts+1 >= maxAttempts
+}
+
+func (r *Relay) deliver(m *Message) error {
+	if url, ok := r.outbox.config.Endpoints[m.Destination]; ok {
+		return r.post(url, m)
+	}
+	switch m.Destination {
+	case DestinationNATS:
+		msg := nats.NewMsg(m.Subject)
+		msg.Data = m.Payload
+		msg.Header.Set(nats.MsgIdHdr, r.outbox.IdempotencyKey(*m))
+		if r.outbox.inStream(m.Subject) {
+			// The stream acknowledges the message once it is stored
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err := r.js.PublishMsg(ctx, msg)
+			return err
+		}
+		if err := r.natsConn.PublishMsg(msg); err != nil {
+			return err
+		}
+		// Publish only buffers the message; flushing confirms the server got it
+		return r.natsConn.FlushTimeout(5 * time.Second)
+	default:
+		return fmt.Errorf("%w: unknown destination %q", errRejected, m.Destination)
+	}
+}
+
+func (r *Relay) post(url string, m *Message) error {
+	token, err := security.ServiceToken(r.outbox.config.Service)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(m.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set(idempotencyKeyHeader, r.outbox.IdempotencyKey(*m))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity {
+		return fmt.Errorf("%w: %s: %s", errRejected, resp.Status, bytes.TrimSpace(body))
+	}
+	return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+}
+
+func backoff(attempt int) time.Duration {
+	delay := time.Second << uint(attempt)
+	if delay <= 0 || delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,121 @@
+package outbox
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func newTestRelay(endpoints map[string]string) *Relay {
+	o := New(Config{Service: "task-service", Endpoints: endpoints})
+	return o.NewRelay(log.New(io.Discard, "", 0), nil, nil)
+}
+
+func TestDeliverPostsPayloadWithIdempotencyKey(t *testing.T) {
+	t.Setenv("TOKEN_SECRET", "secret")
+
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	relay := newTestRelay(map[string]string{DestinationEventStore: server.URL})
+	m := Event(nil)
+	m.Payload = []byte(`{"type":"TaskCreated"}`)
+
+	if err := relay.deliver(&m); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if body != `{"type":"TaskCreated"}` {
+		t.Errorf("body = %s", body)
+	}
+	if key := got.Header.Get(idempotencyKeyHeader); key != "task-service-"+m.ID.Hex() {
+		t.Errorf("idempotency key = %q", key)
+	}
+	if !strings.HasPrefix(got.Header.Get("Authorization"), "Bearer ") {
+		t.Errorf("authorization = %q, want a bearer token", got.Header.Get("Authorization"))
+	}
+}
+
+// A rejected request is not retried, a server error is.
+func TestDeliverSeparatesRejectedFromRetryable(t *testing.T) {
+	t.Setenv("TOKEN_SECRET", "secret")
+
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", status)
+	}))
+	defer server.Close()
+
+	relay := newTestRelay(map[string]string{DestinationAnalytics: server.URL})
+	m := Analytics(nil)
+
+	err := relay.deliver(&m)
+	if !errors.Is(err, errRejected) {
+		t.Fatalf("400: err = %v, want errRejected", err)
+	}
+
+	status = http.StatusServiceUnavailable
+	err = relay.deliver(&m)
+	if err == nil || errors.Is(err, errRejected) {
+		t.Fatalf("503: err = %v, want a retryable error", err)
+	}
+}
+
+func TestDeliverRejectsUnknownDestination(t *testing.T) {
+	relay := newTestRelay(nil)
+	m := newMessage("billing", "", nil)
+	if err := relay.deliver(&m); !errors.Is(err, errRejected) {
+		t.Fatalf("err = %v, want errRejected", err)
+	}
+}
+
+func TestDestinationsAreSortedAndEndWithNATS(t *testing.T) {
+	relay := newTestRelay(map[string]string{
+		DestinationEventStore: "http://event-store",
+		DestinationAnalytics:  "http://analytics",
+	})
+	want := []string{DestinationAnalytics, DestinationEventStore, DestinationNATS}
+	if got := relay.destinations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("destinations = %v, want %v", got, want)
+	}
+}
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	previous := time.Duration(0)
+	for attempt := 1; attempt < 64; attempt++ {
+		delay := backoff(attempt)
+		if delay < previous || delay > maxBackoff {
+			t.Fatalf("backoff(%d) = %v after %v", attempt, delay, previous)
+		}
+		previous = delay
+	}
+	if backoff(1) != 2*time.Second {
+		t.Errorf("backoff(1) = %v, want 2s", backoff(1))
+	}
+	if previous != maxBackoff {
+		t.Errorf("backoff does not reach %v", maxBackoff)
+	}
+}
+
+func TestInStreamMatchesWildcardSubjects(t *testing.T) {
+	o := New(Config{Stream: jetstream.StreamConfig{Subjects: []string{"task.>"}}})
+	if !o.inStream("task.history") {
+		t.Error("task.history should be in the stream")
+	}
+	if o.inStream("project.deleted") {
+		t.Error("project.deleted should stay on core NATS")
+	}
+}
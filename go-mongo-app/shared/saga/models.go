@@ -0,0 +1,37 @@
+package saga
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DeletionSaga je sačuvano stanje brisanja koje obuhvata više servisa. Koraci
+// se izvršavaju redom; dok su završeni samo koraci koji se mogu poništiti,
+// trajna greška ih poništava umesto da resurs ostane napola obrisan.
+type DeletionSaga struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	ResourceID    string             `bson:"resource_id" json:"resourceId"`
+	ProjectID     string             `bson:"project_id,omitempty" json:"projectId,omitempty"`
+	RequestedBy   string             `bson:"requested_by" json:"requestedBy"`
+	Status        string             `bson:"status" json:"status"`
+	Active        bool               `bson:"active,omitempty" json:"-"`
+	Steps         []Step             `bson:"steps" json:"steps"`
+	LastError     string             `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"nextAttemptAt"`
+	LockedUntil   time.Time          `bson:"locked_until" json:"-"`
+	CreatedAt     time.Time          `bson:"created_at" json:"createdAt"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updatedAt"`
+	FinishedAt    *time.Time         `bson:"finished_at,omitempty" json:"finishedAt,omitempty"`
+}
+
+// Step je korak sage. Attempts broji neuspela izvršavanja, a
+// CompensationAttempts neuspela poništavanja koraka.
+type Step struct {
+	Name                 string     `bson:"name" json:"name"`
+	Status               string     `bson:"status" json:"status"`
+	Attempts             int        `bson:"attempts" json:"attempts"`
+	CompensationAttempts int        `bson:"compensation_attempts,omitempty" json:"compensationAttempts,omitempty"`
+	LastError            string     `bson:"last_error,omitempty" json:"lastError,omitempty"`
+	CompletedAt          *time.Time `bson:"completed_at,omitempty" json:"completedAt,omitempty"`
+}
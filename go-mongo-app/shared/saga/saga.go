@@ -0,0 +1,349 @@
+// Package saga izvršava brisanja koja obuhvataju više servisa kao sage
+// sačuvane u MongoDB, tako da se prekinuto brisanje nastavlja posle pada.
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	Running      = "running"
+	Compensating = "compensating"
+	Completed    = "completed"
+	Compensated  = "compensated"
+	// Failed i Stuck su završna stanja koja traže ručnu intervenciju: Failed
+	// kada korak nema akciju, a Stuck kada poništavanje ne uspe ni posle
+	// maxStepAttempts pokušaja.
+	Failed = "failed"
+	Stuck  = "stuck"
+
+	StepPending     = "pending"
+	StepDone        = "done"
+	StepCompensated = "compensated"
+
+	lease           = time.Minute
+	pollInterval    = 5 * time.Second
+	maxStepAttempts = 5
+	maxBackoff      = 5 * time.Minute
+)
+
+var (
+	ErrNotFound = errors.New("deletion not found")
+
+	// ErrPermanent označava grešku koraka koju ponavljanje ne može da ispravi.
+	ErrPermanent = errors.New("permanent failure")
+	// ErrInProgress znači da je drugi servis prihvatio korak, ali ga još nije završio.
+	ErrInProgress = errors.New("still in progress")
+	// ErrUnknownStep znači da servis nema akciju za korak sage.
+	ErrUnknownStep = errors.New("unknown step")
+)
+
+// Action izvršava korak, a Compensate ga poništava kada kasniji korak trajno
+// ne uspe. Korak bez Compensate se ne može poništiti; kada se on završi,
+// saga ide samo napred.
+type Action struct {
+	Run        func(ctx context.Context, saga *DeletionSaga, target string) error
+	Compensate func(ctx context.Context, saga *DeletionSaga, target string) error
+}
+
+// Runner čuva sage u kolekciji i izvršava njihove korake. Naziv koraka je
+// akcija, uz opcioni cilj posle dvotačke (npr. "delete_task:<id>"). Kolekcija
+// se zadaje funkcijom, jer klijent baze često još ne postoji kada se Runner pravi.
+type Runner struct {
+	collection func() *mongo.Collection
+	actions    map[string]Action
+}
+
+func New(collection func() *mongo.Collection, actions map[string]Action) *Runner {
+	return &Runner{collection: collection, actions: actions}
+}
+
+// NewDeletion pravi sagu brisanja resursa sa zadatim koracima.
+func NewDeletion(resourceID, projectID, requestedBy string, steps ...string) *DeletionSaga {
+	now := time.Now()
+	saga := &DeletionSaga{
+		ID:            primitive.NewObjectID(),
+		ResourceID:    resourceID,
+		ProjectID:     projectID,
+		RequestedBy:   requestedBy,
+		Status:        Running,
+		Active:        true,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	for _, name := range steps {
+		saga.Steps = append(saga.Steps, Step{Name: name, Status: StepPending})
+	}
+	return saga
+}
+
+// CreateIndexes obezbeđuje da se za jedan resurs izvršava najviše jedno brisanje.
+func (r *Runner) CreateIndexes(ctx context.Context) error {
+	_, err := r.collection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"resource_id": 1},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"active": true}),
+	})
+	return err
+}
+
+// Start čuva novu sagu, ili vraća sagu koja se već izvršava za isti resurs.
+func (r *Runner) Start(ctx context.Context, saga *DeletionSaga) (*DeletionSaga, error) {
+	_, err := r.collection().InsertOne(ctx, saga)
+	if mongo.IsDuplicateKeyError(err) {
+		return r.Active(ctx, saga.ResourceID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return saga, nil
+}
+
+// Active vraća sagu koja trenutno briše resurs.
+func (r *Runner) Active(ctx context.Context, resourceID string) (*DeletionSaga, error) {
+	var saga DeletionSaga
+	err := r.collection().FindOne(ctx, bson.M{"resource_id": resourceID, "active": true}).Decode(&saga)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &saga, nil
+}
+
+// Latest vraća poslednju sagu brisanja resursa.
+func (r *Runner) Latest(ctx context.Context, resourceID string) (*DeletionSaga, error) {
+	var saga DeletionSaga
+	err := r.collection().FindOne(ctx,
+		bson.M{"resource_id": resourceID},
+		options.FindOne().SetSort(bson.M{"created_at": -1}),
+	).Decode(&saga)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &saga, nil
+}
+
+// Advance pomera sagu koliko je trenutno moguće. Kada sagu drži drugi
+// izvršilac, vraća se njeno sačuvano stanje.
+func (r *Runner) Advance(ctx context.Context, sagaID primitive.ObjectID) (*DeletionSaga, error) {
+	saga, err := r.claim(ctx, bson.M{"_id": sagaID})
+	if err != nil {
+		return nil, err
+	}
+	if saga == nil {
+		var stored DeletionSaga
+		if err := r.collection().FindOne(ctx, bson.M{"_id": sagaID}).Decode(&stored); err != nil {
+			return nil, err
+		}
+		return &stored, nil
+	}
+
+	r.advance(ctx, saga)
+	return saga, nil
+}
+
+// Run nastavlja nezavršene sage, uključujući one prekinute padom servisa,
+// dok se ctx ne otkaže.
+func (r *Runner) Run(ctx context.Context, logger *log.Logger) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			saga, err := r.claim(ctx, bson.M{"next_attempt_at": bson.M{"$lte": time.Now()}})
+			if err != nil {
+				logger.Println("Saga: claiming deletion failed:", err)
+				break
+			}
+			if saga == nil {
+				break
+			}
+			r.advance(ctx, saga)
+			switch {
+			case saga.Active:
+				logger.Printf("Saga: deletion of %s paused: %s", saga.ResourceID, saga.LastError)
+			case saga.Status == Failed, saga.Status == Stuck:
+				logger.Printf("Saga: deletion of %s is %s and needs manual cleanup: %s", saga.ResourceID, saga.Status, saga.LastError)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim zauzima aktivnu sagu koja odgovara filteru, da je drugi izvršilac ne
+// pokrene istovremeno.
+func (r *Runner) claim(ctx context.Context, filter bson.M) (*DeletionSaga, error) {
+	now := time.Now()
+	filter["active"] = true
+	filter["locked_until"] = bson.M{"$lte": now}
+
+	var saga DeletionSaga
+	err := r.collection().FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"locked_until": now.Add(lease)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&saga)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &saga, nil
+}
+
+func (r *Runner) advance(ctx context.Context, saga *DeletionSaga) {
+	if saga.Status == Running {
+		r.runForward(ctx, saga)
+	}
+	if saga.Status == Compensating {
+		r.runBackward(ctx, saga)
+	}
+	saga.LockedUntil = time.Time{}
+	r.save(ctx, saga)
+}
+
+func (r *Runner) runForward(ctx context.Context, saga *DeletionSaga) {
+	for i := range saga.Steps {
+		step := &saga.Steps[i]
+		if step.Status == StepDone {
+			continue
+		}
+
+		action, target, err := r.action(step.Name)
+		if err == nil && action.Run == nil {
+			err = fmt.Errorf("%w: %s", ErrUnknownStep, step.Name)
+		}
+		if err != nil {
+			step.LastError = err.Error()
+			saga.LastError = fmt.Sprintf("%s: %v", step.Name, err)
+			finish(saga, Failed)
+			return
+		}
+		if err := action.Run(ctx, saga, target); err != nil {
+			step.Attempts++
+			step.LastError = err.Error()
+			saga.LastError = fmt.Sprintf("%s: %v", step.Name, err)
+
+			giveUp := errors.Is(err, ErrPermanent) || (step.Attempts >= maxStepAttempts && !errors.Is(err, ErrInProgress))
+			if giveUp && r.canCompensate(saga) {
+				saga.Status = Compensating
+				saga.NextAttemptAt = time.Now()
+				return
+			}
+			saga.NextAttemptAt = time.Now().Add(backoff(step.Attempts))
+			return
+		}
+
+		now := time.Now()
+		step.Status = StepDone
+		step.LastError = ""
+		step.CompletedAt = &now
+		saga.LastError = ""
+		r.save(ctx, saga)
+	}
+	finish(saga, Completed)
+}
+
+func (r *Runner) runBackward(ctx context.Context, saga *DeletionSaga) {
+	for i := len(saga.Steps) - 1; i >= 0; i-- {
+		step := &saga.Steps[i]
+		if step.Status != StepDone {
+			continue
+		}
+
+		action, target, err := r.action(step.Name)
+		if err == nil && action.Compensate == nil {
+			err = fmt.Errorf("%w: no compensation for %s", ErrUnknownStep, step.Name)
+		}
+		if err != nil {
+			step.LastError = "compensation: " + err.Error()
+			saga.LastError = fmt.Sprintf("%s: %s", step.Name, step.LastError)
+			finish(saga, Stuck)
+			return
+		}
+		if err := action.Compensate(ctx, saga, target); err != nil {
+			step.CompensationAttempts++
+			step.LastError = "compensation: " + err.Error()
+			saga.LastError = fmt.Sprintf("%s: %s", step.Name, step.LastError)
+
+			giveUp := errors.Is(err, ErrPermanent) || (step.CompensationAttempts >= maxStepAttempts && !errors.Is(err, ErrInProgress))
+			if giveUp {
+				finish(saga, Stuck)
+				return
+			}
+			saga.NextAttemptAt = time.Now().Add(backoff(step.CompensationAttempts))
+			return
+		}
+		step.Status = StepCompensated
+		r.save(ctx, saga)
+	}
+	finish(saga, Compensated)
+}
+
+// canCompensate proverava da li se svi završeni koraci još mogu poništiti.
+func (r *Runner) canCompensate(saga *DeletionSaga) bool {
+	for _, step := range saga.Steps {
+		if step.Status != StepDone {
+			continue
+		}
+		if action, _, err := r.action(step.Name); err != nil || action.Compensate == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Runner) save(ctx context.Context, saga *DeletionSaga) {
+	saga.UpdatedAt = time.Now()
+	if _, err := r.collection().ReplaceOne(ctx, bson.M{"_id": saga.ID}, saga); err != nil {
+		// Zauzeće ističe i saga se ponovo preuzima od poslednjeg sačuvanog koraka
+		log.Printf("Saga: saving deletion of %s failed: %v", saga.ResourceID, err)
+	}
+}
+
+// action deli naziv koraka kao "delete_task:<id>" na akciju i cilj.
+func (r *Runner) action(name string) (Action, string, error) {
+	name, target, _ := strings.Cut(name, ":")
+	action, ok := r.actions[name]
+	if !ok {
+		return Action{}, "", fmt.Errorf("%w: %s", ErrUnknownStep, name)
+	}
+	return action, target, nil
+}
+
+func finish(saga *DeletionSaga, status string) {
+	now := time.Now()
+	saga.Status = status
+	saga.Active = false
+	saga.FinishedAt = &now
+}
+
+func backoff(attempt int) time.Duration {
+	delay := time.Second << uint(attempt)
+	if delay <= 0 || delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,172 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Klijent bez konekcije: save samo zapisuje grešku, pa se koraci testiraju
+// bez MongoDB.
+func newTestRunner(t *testing.T, actions map[string]Action) *Runner {
+	t.Helper()
+	client, err := mongo.NewClient(options.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	collection := client.Database("test").Collection("sagas")
+	return New(func() *mongo.Collection { return collection }, actions)
+}
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func run(err error) func(context.Context, *DeletionSaga, string) error {
+	return func(context.Context, *DeletionSaga, string) error { return err }
+}
+
+func TestCompletedSaga(t *testing.T) {
+	var targets []string
+	runner := newTestRunner(t, map[string]Action{
+		"delete_task": {Run: func(_ context.Context, _ *DeletionSaga, target string) error {
+			targets = append(targets, target)
+			return nil
+		}},
+	})
+	saga := NewDeletion("p1", "p1", "u1", "delete_task:t1", "delete_task:t2")
+
+	runner.advance(context.Background(), saga)
+
+	if saga.Status != Completed || saga.Active {
+		t.Fatalf("status = %s, active = %v", saga.Status, saga.Active)
+	}
+	if len(targets) != 2 || targets[0] != "t1" || targets[1] != "t2" {
+		t.Errorf("targets = %v", targets)
+	}
+}
+
+func TestPermanentFailureCompensatesDoneStepsInReverse(t *testing.T) {
+	var compensated []string
+	compensate := func(name string) func(context.Context, *DeletionSaga, string) error {
+		return func(context.Context, *DeletionSaga, string) error {
+			compensated = append(compensated, name)
+			return nil
+		}
+	}
+	runner := newTestRunner(t, map[string]Action{
+		"archive":  {Run: run(nil), Compensate: compensate("archive")},
+		"detach":   {Run: run(nil), Compensate: compensate("detach")},
+		"workflow": {Run: run(ErrPermanent)},
+	})
+	saga := NewDeletion("p1", "p1", "u1", "archive", "detach", "workflow")
+
+	runner.advance(context.Background(), saga)
+
+	if saga.Status != Compensated {
+		t.Fatalf("status = %s, want %s (%s)", saga.Status, Compensated, saga.LastError)
+	}
+	if len(compensated) != 2 || compensated[0] != "detach" || compensated[1] != "archive" {
+		t.Errorf("compensated = %v, want [detach archive]", compensated)
+	}
+	for _, step := range saga.Steps[:2] {
+		if step.Status != StepCompensated {
+			t.Errorf("step %s is %s", step.Name, step.Status)
+		}
+	}
+}
+
+// Kada se završeni korak ne može poništiti, saga nastavlja da pokušava napred.
+func TestFailureAfterIrreversibleStepKeepsRetrying(t *testing.T) {
+	runner := newTestRunner(t, map[string]Action{
+		"delete_tasks": {Run: run(nil)},
+		"workflow":     {Run: run(ErrPermanent)},
+	})
+	saga := NewDeletion("p1", "p1", "u1", "delete_tasks", "workflow")
+
+	runner.advance(context.Background(), saga)
+
+	if saga.Status != Running || !saga.Active {
+		t.Fatalf("status = %s, active = %v", saga.Status, saga.Active)
+	}
+	if saga.Steps[1].Attempts != 1 || saga.NextAttemptAt.IsZero() {
+		t.Errorf("step = %+v", saga.Steps[1])
+	}
+}
+
+func TestUnknownStepFailsSaga(t *testing.T) {
+	runner := newTestRunner(t, map[string]Action{"archive": {Run: run(nil)}})
+	saga := NewDeletion("p1", "p1", "u1", "archive", "notify:u1")
+
+	runner.advance(context.Background(), saga)
+
+	if saga.Status != Failed || saga.Active || saga.FinishedAt == nil {
+		t.Fatalf("status = %s, active = %v", saga.Status, saga.Active)
+	}
+	if saga.Steps[0].Status != StepDone {
+		t.Errorf("archive = %s, want %s", saga.Steps[0].Status, StepDone)
+	}
+}
+
+func TestCompensationGetsStuckAfterMaxAttempts(t *testing.T) {
+	calls := 0
+	runner := newTestRunner(t, map[string]Action{
+		"archive": {Run: run(nil), Compensate: func(context.Context, *DeletionSaga, string) error {
+			calls++
+			return errors.New("project-service unavailable")
+		}},
+		"workflow": {Run: run(ErrPermanent)},
+	})
+	saga := NewDeletion("p1", "p1", "u1", "archive", "workflow")
+
+	for i := 0; i < maxStepAttempts && saga.Active; i++ {
+		runner.advance(context.Background(), saga)
+		if i < maxStepAttempts-1 && saga.Status != Compensating {
+			t.Fatalf("after %d attempts status = %s", i+1, saga.Status)
+		}
+	}
+
+	if saga.Status != Stuck || saga.Active {
+		t.Fatalf("status = %s, active = %v", saga.Status, saga.Active)
+	}
+	if calls != maxStepAttempts || saga.Steps[0].CompensationAttempts != maxStepAttempts {
+		t.Errorf("calls = %d, attempts = %d", calls, saga.Steps[0].CompensationAttempts)
+	}
+}
+
+func TestCompensationInProgressIsNotCountedAgainstTheLimit(t *testing.T) {
+	runner := newTestRunner(t, map[string]Action{
+		"archive":  {Run: run(nil), Compensate: run(ErrInProgress)},
+		"workflow": {Run: run(ErrPermanent)},
+	})
+	saga := NewDeletion("p1", "p1", "u1", "archive", "workflow")
+
+	for i := 0; i < maxStepAttempts+2; i++ {
+		runner.advance(context.Background(), saga)
+	}
+
+	if saga.Status != Compensating || !saga.Active {
+		t.Fatalf("status = %s, active = %v", saga.Status, saga.Active)
+	}
+}
+
+func TestPermanentCompensationErrorIsStuckImmediately(t *testing.T) {
+	runner := newTestRunner(t, map[string]Action{
+		"archive":  {Run: run(nil), Compensate: run(ErrPermanent)},
+		"workflow": {Run: run(ErrPermanent)},
+	})
+	saga := NewDeletion("p1", "p1", "u1", "archive", "workflow")
+
+	runner.advance(context.Background(), saga)
+
+	if saga.Status != Stuck {
+		t.Fatalf("status = %s, want %s", saga.Status, Stuck)
+	}
+}
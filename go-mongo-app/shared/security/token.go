@@ -0,0 +1,21 @@
+// Package security potpisuje tokene kojima servisi pozivaju jedni druge.
+package security
+
+import (
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// ServiceToken potpisuje kratkotrajni Manager token kojim servis poziva druge
+// servise u svoje ime, na primer kada prosleđuje poruke iz outbox-a ili
+// nastavlja sagu. Token korisnika koji je pokrenuo posao do tada je možda istekao.
+func ServiceToken(service string) (string, error) {
+	claims := jwt.MapClaims{
+		"id":   service,
+		"role": "Manager",
+		"exp":  time.Now().Add(5 * time.Minute).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("TOKEN_SECRET")))
+}
@@ -44,7 +44,12 @@ func InsertInitialTasks() {
 
 }
 
+// ClearTasks briše kolekciju samo kada je CLEAR_ON_START=true, npr. za lokalno
+// testiranje; inače bi svaki restart obrisao podatke koje graf i outbox čuvaju.
 func ClearTasks() {
+	if os.Getenv("CLEAR_ON_START") != "true" {
+		return
+	}
 
 	collection := db.Client.Database("testdb").Collection("tasks")
 	_, err := collection.DeleteMany(context.TODO(), bson.D{})
@@ -3,6 +3,7 @@ module task-service
 go 1.20
 
 require (
+	contracts v0.0.0
 	github.com/colinmarc/hdfs v1.1.3
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/gorilla/mux v1.8.1
@@ -10,7 +11,7 @@ require (
 	github.com/nats-io/nats.go v1.37.0
 	github.com/rs/cors v1.11.1
 	go.mongodb.org/mongo-driver v1.17.1
-
+	shared v0.0.0
 )
 
 require golang.org/x/crypto v0.26.0 // indirect
@@ -32,3 +33,7 @@ require (
 	golang.org/x/text v0.17.0 // indirect
 	google.golang.org/protobuf v1.31.0 // indirect
 )
+
+replace contracts => ../contracts
+
+replace shared => ../shared
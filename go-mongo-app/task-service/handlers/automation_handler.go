@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"task-service/models"
+	"task-service/service"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateAutomationRuleHandler čuva novo pravilo automatizacije za projekat.
+func (uh *TasksHandler) CreateAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var rule models.AutomationRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	rule.ProjectID = mux.Vars(r)["project_id"]
+	rule.CreatedBy, _ = r.Context().Value(KeyAccount{}).(string)
+	if !requireProjectManager(w, r, rule.ProjectID) {
+		return
+	}
+
+	if err := service.ValidateAutomationRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := service.CreateAutomationRule(context.TODO(), &rule); err != nil {
+		http.Error(w, "Failed to create rule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// GetAutomationRulesHandler vraća pravila projekta.
+func (uh *TasksHandler) GetAutomationRulesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+	if !requireProjectManager(w, r, projectID) {
+		return
+	}
+	rules, err := service.GetAutomationRules(context.TODO(), projectID)
+	if err != nil {
+		http.Error(w, "Failed to fetch rules: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// managedRule vraća pravilo ako je korisnik menadžer projekta kome pripada.
+func managedRule(w http.ResponseWriter, r *http.Request) (*models.AutomationRule, bool) {
+	rule, err := service.GetAutomationRule(context.TODO(), mux.Vars(r)["rule_id"])
+	if errors.Is(err, service.ErrRuleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch rule: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if !requireProjectManager(w, r, rule.ProjectID) {
+		return nil, false
+	}
+	return rule, true
+}
+
+// UpdateAutomationRuleHandler menja postojeće pravilo.
+func (uh *TasksHandler) UpdateAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := managedRule(w, r); !ok {
+		return
+	}
+	var rule models.AutomationRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if err := service.ValidateAutomationRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := service.UpdateAutomationRule(context.TODO(), mux.Vars(r)["rule_id"], &rule)
+	if errors.Is(err, service.ErrRuleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to update rule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteAutomationRuleHandler briše pravilo.
+func (uh *TasksHandler) DeleteAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := managedRule(w, r); !ok {
+		return
+	}
+	err := service.DeleteAutomationRule(context.TODO(), mux.Vars(r)["rule_id"])
+	if errors.Is(err, service.ErrRuleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to delete rule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestAutomationRuleHandler proverava sačuvano (ruleId) ili novo (rule)
+// pravilo nad zadatkom i vraća šta bi uradilo, bez izvršavanja akcija.
+func (uh *TasksHandler) TestAutomationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+	if !requireProjectManager(w, r, projectID) {
+		return
+	}
+
+	var request struct {
+		RuleID string                 `json:"ruleId"`
+		Rule   *models.AutomationRule `json:"rule"`
+		TaskID string                 `json:"taskId"`
+		Event  *models.TaskEvent      `json:"event"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if request.TaskID == "" {
+		http.Error(w, "taskId is required", http.StatusBadRequest)
+		return
+	}
+
+	var rule models.AutomationRule
+	switch {
+	case request.RuleID != "":
+		saved, err := service.GetAutomationRule(context.TODO(), request.RuleID)
+		if errors.Is(err, service.ErrRuleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to fetch rule: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rule = *saved
+	case request.Rule != nil:
+		rule = *request.Rule
+		rule.ProjectID = projectID
+		if err := service.ValidateAutomationRule(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "ruleId or rule is required", http.StatusBadRequest)
+		return
+	}
+	if rule.ProjectID != projectID {
+		http.Error(w, "Rule does not belong to this project", http.StatusBadRequest)
+		return
+	}
+
+	evaluation, err := service.TestAutomationRule(rule, request.TaskID, request.Event)
+	if err != nil {
+		http.Error(w, "Failed to test rule: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(evaluation)
+}
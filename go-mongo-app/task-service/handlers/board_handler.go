@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"task-service/models"
+	"task-service/service"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// boardReplay je broj poslednjih događaja po projektu koji se čuvaju za
+	// klijente koji se ponovo povezuju; stariji prekid dobija novi snimak table.
+	boardReplay = 200
+	// boardBuffer je broj događaja koji mogu da čekaju na sporog klijenta.
+	boardBuffer     = 64
+	boardHeartbeat  = 25 * time.Second
+	boardEventName  = "board"
+	presenceName    = "presence"
+	snapshotName    = "snapshot"
+	boardRetryDelay = 5000
+)
+
+type boardMessage struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+type boardClient struct {
+	userID string
+	events chan boardMessage
+}
+
+type boardRoom struct {
+	clients map[*boardClient]struct{}
+	recent  []models.BoardEvent
+}
+
+// boardHub prosleđuje događaje table pregledačima koji je gledaju. Prisustvo
+// se vodi po instanci servisa.
+type boardHub struct {
+	mu    sync.Mutex
+	rooms map[string]*boardRoom
+}
+
+func newBoardHub() *boardHub {
+	return &boardHub{rooms: make(map[string]*boardRoom)}
+}
+
+func (h *boardHub) room(projectID string) *boardRoom {
+	room := h.rooms[projectID]
+	if room == nil {
+		room = &boardRoom{clients: make(map[*boardClient]struct{})}
+		h.rooms[projectID] = room
+	}
+	return room
+}
+
+func (h *boardHub) join(projectID, userID string) *boardClient {
+	client := &boardClient{userID: userID, events: make(chan boardMessage, boardBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	room := h.room(projectID)
+	room.clients[client] = struct{}{}
+	h.broadcastPresence(projectID, room)
+	return client
+}
+
+func (h *boardHub) leave(projectID string, client *boardClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	room := h.rooms[projectID]
+	if room == nil {
+		return
+	}
+	if _, ok := room.clients[client]; ok {
+		delete(room.clients, client)
+		close(client.events)
+	}
+	h.broadcastPresence(projectID, room)
+}
+
+// publish čuva događaj za ponovno slanje i šalje ga svim gledaocima table.
+func (h *boardHub) publish(event models.BoardEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	room := h.room(event.ProjectID)
+	room.recent = append(room.recent, event)
+	if len(room.recent) > boardReplay {
+		room.recent = room.recent[len(room.recent)-boardReplay:]
+	}
+	h.send(event.ProjectID, room, boardMessage{ID: strconv.FormatInt(event.Version, 10), Name: boardEventName, Data: data})
+}
+
+// since vraća sačuvane događaje posle verzije after. Ako prvi propušteni
+// događaj više nije sačuvan, ok je false i klijentu treba novi snimak.
+func (h *boardHub) since(projectID string, after int64) (events []models.BoardEvent, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	room := h.rooms[projectID]
+	if room == nil || len(room.recent) == 0 {
+		return nil, false
+	}
+	if room.recent[0].Version > after+1 {
+		return nil, false
+	}
+	for _, event := range room.recent {
+		if event.Version > after {
+			events = append(events, event)
+		}
+	}
+	return events, true
+}
+
+// broadcastPresence šalje listu korisnika koji gledaju tablu. Poziva se pod h.mu.
+func (h *boardHub) broadcastPresence(projectID string, room *boardRoom) {
+	seen := map[string]bool{}
+	viewers := []string{}
+	for client := range room.clients {
+		if !seen[client.userID] {
+			seen[client.userID] = true
+			viewers = append(viewers, client.userID)
+		}
+	}
+	sort.Strings(viewers)
+	data, _ := json.Marshal(map[string]interface{}{"projectId": projectID, "viewers": viewers})
+	h.send(projectID, room, boardMessage{Name: presenceName, Data: data})
+
+	if len(room.clients) == 0 && len(room.recent) == 0 {
+		delete(h.rooms, projectID)
+	}
+}
+
+// send šalje poruku bez čekanja. Spor klijent se isključuje i nastavlja
+// od poslednje verzije koju je primio. Poziva se pod h.mu.
+func (h *boardHub) send(projectID string, room *boardRoom, message boardMessage) {
+	dropped := false
+	for client := range room.clients {
+		select {
+		case client.events <- message:
+		default:
+			delete(room.clients, client)
+			close(client.events)
+			dropped = true
+		}
+	}
+	if dropped {
+		h.broadcastPresence(projectID, room)
+	}
+}
+
+// ListenForBoardEvents prosleđuje događaje table sa NATS-a povezanim
+// pregledačima. Svaka instanca prima sve događaje, jer svaka ima svoje klijente.
+func (uh *TasksHandler) ListenForBoardEvents() error {
+	_, err := uh.natsConn.Subscribe(service.BoardSubjectPrefix+"*", func(msg *nats.Msg) {
+		var event models.BoardEvent
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			uh.logger.Printf("Error unmarshalling board event: %v", err)
+			return
+		}
+		uh.board.publish(event)
+	})
+	return err
+}
+
+// MiddlewareStreamAuth dozvoljava da se token pošalje i kao access_token
+// parametar, jer EventSource u pregledaču ne može da postavi zaglavlje.
+func (uh *TasksHandler) MiddlewareStreamAuth(next func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	extract := uh.MiddlewareExtractUserFromHeader(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if token := r.URL.Query().Get("access_token"); token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		extract(w, r)
+	}
+}
+
+// requireProjectMember dozvoljava pristup tabli samo menadžeru i članovima projekta.
+func requireProjectMember(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	member, err := service.IsProjectMember(projectID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check project membership: "+err.Error(), http.StatusBadGateway)
+		return false
+	}
+	if !member {
+		http.Error(w, "Access forbidden: not a member of the project", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// requireProjectManager dozvoljava pristup samo menadžeru projekta.
+func requireProjectManager(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	manager, err := service.IsProjectManager(projectID, userID)
+	if err != nil {
+		http.Error(w, "Failed to check project manager: "+err.Error(), http.StatusBadGateway)
+		return false
+	}
+	if !manager {
+		http.Error(w, "Access forbidden: not the manager of the project", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// GetBoardSnapshotHandler vraća zadatke projekta sa trenutnom verzijom table.
+func (uh *TasksHandler) GetBoardSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+	if !requireProjectMember(w, r, projectID) {
+		return
+	}
+
+	snapshot, err := service.GetBoardSnapshot(context.TODO(), projectID)
+	if err != nil {
+		http.Error(w, "Failed to fetch board: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// StreamBoardHandler šalje promene table kao Server-Sent Events. Klijent prvo
+// dobija snimak table (događaj snapshot), a zatim događaje board čiji je ID
+// verzija table, i presence sa listom korisnika koji gledaju tablu. Pri
+// ponovnom povezivanju Last-Event-ID (ili lastEventId parametar) nastavlja od
+// te verzije; ako propušteni događaji više nisu sačuvani, šalje se novi snimak.
+func (uh *TasksHandler) StreamBoardHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+	if !requireProjectMember(w, r, projectID) {
+		return
+	}
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	var lastVersion int64 = -1
+	if lastEventID != "" {
+		version, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || version < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastVersion = version
+	}
+
+	rc := http.NewResponseController(w)
+	// Tok traje duže od WriteTimeout servera
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Pridruživanje pre čitanja snimka, da se ništa ne izgubi između
+	client := uh.board.join(projectID, userID)
+	defer uh.board.leave(projectID, client)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", boardRetryDelay)
+
+	replayed := false
+	if lastVersion >= 0 {
+		if current, err := service.GetBoardVersion(context.TODO(), projectID); err == nil && current == lastVersion {
+			replayed = true
+		} else if events, ok := uh.board.since(projectID, lastVersion); ok {
+			for _, event := range events {
+				data, _ := json.Marshal(event)
+				writeBoardMessage(w, boardMessage{ID: strconv.FormatInt(event.Version, 10), Name: boardEventName, Data: data})
+				lastVersion = event.Version
+			}
+			replayed = true
+		}
+	}
+	if !replayed {
+		snapshot, err := service.GetBoardSnapshot(context.TODO(), projectID)
+		if err != nil {
+			uh.logger.Printf("Error fetching board snapshot for project %s: %v", projectID, err)
+			return
+		}
+		data, _ := json.Marshal(snapshot)
+		writeBoardMessage(w, boardMessage{ID: strconv.FormatInt(snapshot.Version, 10), Name: snapshotName, Data: data})
+		lastVersion = snapshot.Version
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(boardHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case message, open := <-client.events:
+			if !open {
+				return
+			}
+			if message.ID != "" {
+				version, _ := strconv.ParseInt(message.ID, 10, 64)
+				if version <= lastVersion {
+					continue
+				}
+				lastVersion = version
+			}
+			writeBoardMessage(w, message)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeBoardMessage(w http.ResponseWriter, message boardMessage) {
+	if message.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", message.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Name, message.Data)
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"task-service/models"
+	"task-service/service"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// seriesRequest je telo zahteva za seriju. Pravilo se zadaje kao objekat
+// rule ili kao RRULE tekst, npr. "FREQ=WEEKLY;BYDAY=MO".
+type seriesRequest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Mode        string                `json:"mode"`
+	RRule       string                `json:"rrule"`
+	Rule        models.RecurrenceRule `json:"rule"`
+	StartAt     time.Time             `json:"startAt"`
+}
+
+func (req seriesRequest) toSeries() (*models.TaskSeries, error) {
+	rule := req.Rule
+	if req.RRule != "" {
+		parsed, err := service.ParseRRule(req.RRule)
+		if err != nil {
+			return nil, err
+		}
+		rule = parsed
+	}
+	series := &models.TaskSeries{
+		Name:        service.SanitizeInput(req.Name),
+		Description: service.SanitizeInput(req.Description),
+		Mode:        req.Mode,
+		Rule:        rule,
+		StartAt:     req.StartAt,
+	}
+	return series, service.ValidateTaskSeries(series)
+}
+
+// CreateTaskSeriesHandler pravi seriju zadataka koji se ponavljaju.
+func (uh *TasksHandler) CreateTaskSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	var request seriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	series, err := request.toSeries()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	series.ProjectID = mux.Vars(r)["project_id"]
+	if !requireProjectManager(w, r, series.ProjectID) {
+		return
+	}
+	series.CreatedBy, _ = r.Context().Value(KeyAccount{}).(string)
+
+	if err := service.CreateTaskSeries(context.TODO(), series); err != nil {
+		http.Error(w, "Failed to create series: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(series)
+}
+
+// GetTaskSeriesHandler vraća serije projekta.
+func (uh *TasksHandler) GetTaskSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+	if !requireProjectMember(w, r, projectID) {
+		return
+	}
+
+	series, err := service.GetTaskSeries(context.TODO(), projectID)
+	if err != nil {
+		http.Error(w, "Failed to fetch series: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+// UpdateTaskSeriesHandler menja šablon i pravilo serije.
+func (uh *TasksHandler) UpdateTaskSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	var request seriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	changes, err := request.toSeries()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	seriesID := mux.Vars(r)["series_id"]
+	if !uh.requireSeriesManager(w, r, seriesID) {
+		return
+	}
+
+	series, err := service.UpdateTaskSeries(context.TODO(), seriesID, changes)
+	uh.writeSeriesResult(w, series, err)
+}
+
+// StopTaskSeriesHandler zaustavlja seriju.
+func (uh *TasksHandler) StopTaskSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	seriesID := mux.Vars(r)["series_id"]
+	if !uh.requireSeriesManager(w, r, seriesID) {
+		return
+	}
+
+	series, err := service.StopTaskSeries(context.TODO(), seriesID)
+	uh.writeSeriesResult(w, series, err)
+}
+
+// requireSeriesManager dozvoljava izmenu serije samo menadžeru njenog projekta.
+func (uh *TasksHandler) requireSeriesManager(w http.ResponseWriter, r *http.Request, seriesID string) bool {
+	series, err := service.GetTaskSeriesByID(context.TODO(), seriesID)
+	if err != nil {
+		uh.writeSeriesResult(w, nil, err)
+		return false
+	}
+	return requireProjectManager(w, r, series.ProjectID)
+}
+
+func (uh *TasksHandler) writeSeriesResult(w http.ResponseWriter, series *models.TaskSeries, err error) {
+	switch {
+	case errors.Is(err, service.ErrSeriesNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrSeriesInactive):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, "Failed to update series: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
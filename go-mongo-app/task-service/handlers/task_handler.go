@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/nats-io/nats.go"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"io"
 	"io/ioutil"
 	"log"
 	"mime/multipart"
@@ -17,10 +18,11 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"shared/saga"
 	"strings"
 	"task-service/db"
+	"task-service/models"
 	"task-service/service"
-	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -33,10 +35,11 @@ type TasksHandler struct {
 	logger   *log.Logger
 	repo     *db.TaskRepo
 	natsConn *nats.Conn
+	board    *boardHub
 }
 
 func NewTasksHandler(l *log.Logger, r *db.TaskRepo, natsConn *nats.Conn) *TasksHandler {
-	return &TasksHandler{l, r, natsConn}
+	return &TasksHandler{l, r, natsConn, newBoardHub()}
 }
 
 func (t *TasksHandler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
@@ -146,64 +149,17 @@ func (t *TasksHandler) UpdateTaskHandler(w http.ResponseWriter, r *http.Request)
 
 	// Ako zadatak nema zavisnosti ili je uslov za status ispunjen, nastavi sa ažuriranjem statusa
 	updatedTask, err := service.UpdateTaskStatus(taskID, requestBody.Status, token)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	nc, err := Conn()
-	if err != nil {
-		log.Println("Error connecting to NATS:", err)
-		http.Error(w, "Failed to connect to message broker", http.StatusInternalServerError)
+	if errors.Is(err, service.ErrTaskStatusConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
-	defer nc.Close()
-
-	message := struct {
-		TaskName   string   `json:"taskName"`
-		TaskStatus string   `json:"taskStatus"`
-		MemberIds  []string `json:"memberIds"`
-	}{
-		TaskName:   task.Name,
-		TaskStatus: requestBody.Status,
-		MemberIds:  task.Users,
-	}
-
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
-
-	// Kreiraj event koji uključuje prethodni i trenutni status
-	event := map[string]interface{}{
-		"type": "Task Status Changed",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"taskId":         task.ID,
-			"projectId":      task.Project_ID,
-			"previousStatus": previousStatus,     // Status pre promene
-			"currentStatus":  requestBody.Status, // Novi status
-			"memberId":       task.Users,
-		},
-		"projectId": task.Project_ID,
-	}
-
-	if err := t.sendEventToDatabase(event, token); err != nil {
-		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
-		return
-	}
-
-	jsonMessage, err := json.Marshal(message)
 	if err != nil {
-		log.Println("Error marshalling message:", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	subject := "task.status.update"
-	err = nc.Publish(subject, jsonMessage)
-	if err != nil {
-		log.Println("Error publishing message to NATS:", err)
-	}
-
-	t.logger.Println("Task status update message sent to NATS")
+	// Notifikacije i događaji se upisuju u outbox zajedno sa promenom statusa
+	t.logger.Println("Task status updated:", taskID)
 
 	// Vraćanje odgovora sa ažuriranim zadatkom
 	w.Header().Set("Content-Type", "application/json")
@@ -254,6 +210,7 @@ func (uh *TasksHandler) CreateTaskHandler(w http.ResponseWriter, r *http.Request
 		Name        string   `json:"name"`
 		Description string   `json:"description"`
 		DependsOn   []string `json:"dependsOn"` // List of task IDs this task depends on
+		DueDate     string   `json:"dueDate"`
 	}
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&taskInput); err != nil {
@@ -281,31 +238,12 @@ func (uh *TasksHandler) CreateTaskHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	// Create the task using the service, passing projectID, name, description, and dependsOn
-	task, err := service.CreateTask(projectID, taskInput.Name, taskInput.Description, taskInput.DependsOn, token)
+	task, err := service.CreateTask(projectID, taskInput.Name, taskInput.Description, taskInput.DueDate, taskInput.DependsOn, token)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
-
-	event := map[string]interface{}{
-		"type": "Task Created",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"taskId":    task.ID,
-			"projectId": task.Project_ID,
-		},
-		"projectId": task.Project_ID,
-	}
-
-	// Send the event to the analytic service
-	if err := uh.sendEventToDatabase(event, token); err != nil {
-		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
-		return
-	}
-
 	// Send the created task as JSON response
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(task); err != nil {
@@ -314,44 +252,6 @@ func (uh *TasksHandler) CreateTaskHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
-func (uh *TasksHandler) sendEventToDatabase(event interface{}, token string) error {
-	analyticsServiceURL := fmt.Sprintf("http://event_sourcing:8080/event/append")
-
-	// Marshal the event into JSON
-	eventData, err := json.Marshal(event)
-	if err != nil {
-		log.Printf("Error marshalling event: %v", err)
-		return err
-	}
-
-	// Create a new POST request with the event data
-	req, err := http.NewRequest("POST", analyticsServiceURL, bytes.NewBuffer(eventData))
-	if err != nil {
-		log.Printf("Error creating request: %v", err)
-		return err
-	}
-
-	// Set the appropriate headers for the request
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token)) // Dodaj token u Authorization header
-
-	// Use the default HTTP client (without TLS)
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Printf("Error sending request to analytics service: %v", err)
-		return err
-	}
-	defer resp.Body.Close()
-
-	// Check the response status code
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Failed to send event to analytics service: %s", resp.Status)
-		return fmt.Errorf("failed to send event to analytics service: %s", resp.Status)
-	}
-
-	return nil
-}
-
 // AddUserToTaskHandler handles adding a user to a task.
 func (t *TasksHandler) AddUserToTaskHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -381,73 +281,12 @@ func (t *TasksHandler) AddUserToTaskHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	task, err := service.GetTaskByID(taskID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-	nc, err := Conn()
-	if err != nil {
-		log.Println("Error connecting to NATS:", err)
-		http.Error(w, "Failed to connect to message broker", http.StatusInternalServerError)
-		return
-	}
-	defer nc.Close()
-
-	subject := "task.joined"
-
-	message := struct {
-		UserID   string `json:"userId"`
-		TaskName string `json:"taskName"`
-	}{
-		UserID:   userID,
-		TaskName: task.Name,
-	}
-
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
-
-	event := map[string]interface{}{
-		"type": "Member Added to Task",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"memberId": userID,
-			"taskId":   task.ID,
-		},
-		"projectId": task.Project_ID,
-	}
-
-	if err := t.sendEventToDatabase(event, token); err != nil {
-		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
-		return
-	}
-
-	jsonMessage, err := json.Marshal(message)
-	if err != nil {
-		log.Println("Error marshalling message:", err)
-		return
-	}
-
-	err = nc.Publish(subject, jsonMessage)
-	if err != nil {
-		log.Println("Error publishing message to NATS:", err)
-	}
-
-	t.logger.Println("a message has been sent")
+	t.logger.Println("User added to task:", taskID, userID)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User added to task successfully"})
 }
 
-func Conn() (*nats.Conn, error) {
-	conn, err := nats.Connect("nats://nats:4222")
-	if err != nil {
-		log.Fatal(err)
-		return nil, err
-	}
-	return conn, nil
-}
-
 // RemoveUserFromTaskHandler handles removing a user from a task.
 func (t *TasksHandler) RemoveUserFromTaskHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -476,59 +315,6 @@ func (t *TasksHandler) RemoveUserFromTaskHandler(w http.ResponseWriter, r *http.
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	nc, err := Conn()
-	if err != nil {
-		log.Println("Error connecting to NATS:", err)
-		http.Error(w, "Failed to connect to message broker", http.StatusInternalServerError)
-		return
-	}
-	defer nc.Close()
-
-	task, err := service.GetTaskByID(taskID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
-		return
-	}
-
-	subject := "task.removed"
-
-	message := struct {
-		UserID   string `json:"userId"`
-		TaskName string `json:"taskName"`
-	}{
-		UserID:   userID,
-		TaskName: task.Name,
-	}
-
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
-
-	event := map[string]interface{}{
-		"type": "Member Removed from Task",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"memberId": userID,
-			"taskId":   task.ID,
-		},
-		"projectId": task.Project_ID,
-	}
-
-	if err := t.sendEventToDatabase(event, token); err != nil {
-		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
-		return
-	}
-
-	jsonMessage, err := json.Marshal(message)
-	if err != nil {
-		log.Println("Error marshalling message:", err)
-		return
-	}
-
-	err = nc.Publish(subject, jsonMessage)
-	if err != nil {
-		log.Println("Error publishing message to NATS:", err)
-	}
-
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User removed from task successfully"})
 }
@@ -636,8 +422,23 @@ func (uh *TasksHandler) AddDependencyHandler(w http.ResponseWriter, r *http.Requ
 	fmt.Println("Received Task ID:", taskID)
 	fmt.Println("Received Dependency ID:", dependencyID)
 
+	// Vrsta zavisnosti i kašnjenje su opcioni; podrazumevano je finish-to-start
+	link := models.DependencyLink{Type: models.FinishToStart}
+	if err := json.NewDecoder(r.Body).Decode(&link); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	if link.Type == "" {
+		link.Type = models.FinishToStart
+	}
+	if !service.IsValidDependencyType(link.Type) {
+		http.Error(w, fmt.Sprintf("invalid dependency type %q, expected FS, SS, FF or SF", link.Type), http.StatusBadRequest)
+		return
+	}
+
 	// Pozivanje funkcije za dodavanje zavisnosti
-	err := service.AddDependencyToTask(taskID, dependencyID)
+	memberID, _ := r.Context().Value(KeyAccount{}).(string)
+	err := service.AddDependencyToTask(taskID, dependencyID, link, memberID)
 	if err != nil {
 		fmt.Println("Error adding dependency:", err) // Dodaj log za grešku
 		http.Error(w, fmt.Sprintf("Error adding dependency: %v", err), http.StatusInternalServerError)
@@ -645,10 +446,12 @@ func (uh *TasksHandler) AddDependencyHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Vraćanje uspešnog odgovora
-	response := map[string]string{
+	response := map[string]interface{}{
 		"message":       "Dependency added successfully",
 		"task_id":       taskID,
 		"dependency_id": dependencyID,
+		"type":          link.Type,
+		"lag_days":      link.LagDays,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -656,6 +459,41 @@ func (uh *TasksHandler) AddDependencyHandler(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(response)
 }
 
+// SetDependenciesHandler prepisuje zavisnosti zadatka. Poziva ga workflow-service
+// posle uklanjanja ili izmene zavisnosti u grafu.
+func (uh *TasksHandler) SetDependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["task_id"]
+
+	var request struct {
+		DependsOn []string `json:"dependsOn"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	memberID, _ := r.Context().Value(KeyAccount{}).(string)
+	err := service.SetTaskDependencies(taskID, request.DependsOn, memberID)
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, service.ErrTaskBeingDeleted):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Error updating dependencies: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id":   taskID,
+		"dependsOn": request.DependsOn,
+	})
+}
+
 func (uh *TasksHandler) GetTasksForProjectHandler(w http.ResponseWriter, r *http.Request) {
 	// Parsiranje URL parametra (projectID)
 	vars := mux.Vars(r)
@@ -875,8 +713,8 @@ func (uh *TasksHandler) UpdateTaskStatusHandler(w http.ResponseWriter, r *http.R
 	// Ažuriranje statusa zadatka
 	updatedTask, err := service.UpdateTaskStatus(taskID, payload.Status, token)
 	if err != nil {
-		if strings.Contains(err.Error(), "dependency task") {
-			http.Error(w, err.Error(), http.StatusConflict) // Konflikt zbog zavisnosti
+		if errors.Is(err, service.ErrTaskStatusConflict) || strings.Contains(err.Error(), "dependency task") {
+			http.Error(w, err.Error(), http.StatusConflict) // Konflikt zbog zavisnosti ili istovremene izmene
 		} else if strings.Contains(err.Error(), "task not found") {
 			http.Error(w, err.Error(), http.StatusNotFound) // Zadatak nije pronađen
 		} else {
@@ -998,63 +836,8 @@ func (uh *TasksHandler) UploadFileHandler(w http.ResponseWriter, r *http.Request
 		filePaths = append(filePaths, hdfsFilePath)
 	}
 
-	objectID, err := primitive.ObjectIDFromHex(taskID)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid task ID: %v", err), http.StatusBadRequest)
-		return
-	}
-
-	collection := db.Client.Database("testdb").Collection("tasks")
-	_, err = collection.UpdateOne(
-		r.Context(),
-		bson.M{"_id": objectID},
-		bson.M{"$push": bson.M{"filePaths": bson.M{"$each": filePaths}}},
-	)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to update task in MongoDB: %v", err), http.StatusInternalServerError)
-		return
-	}
-
-	task, err := service.GetTaskByID(taskID)
-	if err != nil {
-		http.Error(w, "Task not found", http.StatusNotFound)
-		return
-	}
-
-	currentTime := time.Now().Add(1 * time.Hour)
-	formattedTime := currentTime.Format(time.RFC3339)
-
-	event := map[string]interface{}{
-		"type": "Document Added",
-		"time": formattedTime,
-		"event": map[string]interface{}{
-			"taskId":    taskID,
-			"projectId": task.Project_ID,
-			"filePath":  task.FilePaths,
-			"memberId":  task.Users,
-		},
-		"projectId": task.Project_ID,
-	}
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
-		uh.logger.Println("No Authorization header:", authHeader)
-		return
-	}
-
-	// Expect the format "Bearer <token>"
-	token := ""
-
-	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
-		token = authHeader[7:]
-	} else {
-		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-		uh.logger.Println("Invalid Authorization header format:", authHeader)
-		return
-	}
-
-	if err := uh.sendEventToDatabase(event, token); err != nil {
-		http.Error(w, "Failed to send event to analytics service", http.StatusInternalServerError)
+	if err := service.AddFilesToTask(taskID, filePaths); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -1251,35 +1034,60 @@ func (uh *TasksHandler) DeleteTaskByIDHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
-		uh.logger.Println("No Authorization header:", authHeader)
+	memberID, _ := r.Context().Value(KeyAccount{}).(string)
+
+	// Brisanje se vodi kao saga; ako ne uspe odmah, nastavlja se u pozadini
+	deletion, err := service.StartTaskDeletion(context.TODO(), taskID, memberID)
+	if errors.Is(err, service.ErrTaskNotFound) {
+		http.Error(w, "Task not found", http.StatusNotFound)
 		return
 	}
-
-	// Expect the format "Bearer <token>"
-	token := ""
-
-	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
-		token = authHeader[7:]
-	} else {
-		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-		uh.logger.Println("Invalid Authorization header format:", authHeader)
+	if err != nil {
+		http.Error(w, "Failed to delete task: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Pozovi repository za brisanje taska po taskID-u
-	err := service.DeleteTaskByID(taskID, token)
+	deletion, err = service.RunSaga(context.TODO(), deletion.ID)
 	if err != nil {
 		http.Error(w, "Failed to delete task: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Uspešan odgovor
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"message":"Task deleted successfully"}`))
+	w.Header().Set("Content-Type", "application/json")
+	switch deletion.Status {
+	case saga.Completed:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Task deleted successfully", "sagaId": deletion.ID.Hex()})
+	case saga.Compensated:
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Task deletion was rolled back: " + deletion.LastError, "sagaId": deletion.ID.Hex()})
+	case saga.Failed, saga.Stuck:
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Task deletion needs manual cleanup: " + deletion.LastError, "sagaId": deletion.ID.Hex(), "status": deletion.Status})
+	default:
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Task deletion in progress", "sagaId": deletion.ID.Hex(), "status": deletion.Status})
+	}
 }
+
+// GetTaskDeletionHandler vraća stanje poslednjeg brisanja zadatka.
+func (uh *TasksHandler) GetTaskDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["taskID"]
+
+	deletion, err := service.GetLatestDeletion(context.TODO(), taskID)
+	if errors.Is(err, saga.ErrNotFound) {
+		http.Error(w, "No deletion found for task", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to fetch deletion: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deletion)
+}
+
 func (uh *TasksHandler) UpdateTaskPosition(w http.ResponseWriter, r *http.Request) {
 	// Extract taskID from URL
 	vars := mux.Vars(r)
@@ -1319,7 +1127,8 @@ func (uh *TasksHandler) UpdateTaskPosition(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Call the service layer to update the task position
-	err := service.UpdateTaskPosition(taskID, payload.Position, token)
+	memberID, _ := r.Context().Value(KeyAccount{}).(string)
+	err := service.UpdateTaskPosition(taskID, payload.Position, memberID, token)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to update task position: %v", err), http.StatusInternalServerError)
 		return
@@ -1329,3 +1138,81 @@ func (uh *TasksHandler) UpdateTaskPosition(w http.ResponseWriter, r *http.Reques
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Task position updated successfully"})
 }
+
+// GetProjectTaskSettingsHandler vraća podešavanja zadataka projekta.
+func (uh *TasksHandler) GetProjectTaskSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	settings, err := service.GetProjectTaskSettings(context.TODO(), mux.Vars(r)["project_id"])
+	if err != nil {
+		http.Error(w, "Failed to fetch settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdateProjectTaskSettingsHandler čuva podešavanja zadataka projekta.
+func (uh *TasksHandler) UpdateProjectTaskSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	var settings models.ProjectTaskSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+	settings.ProjectID = mux.Vars(r)["project_id"]
+	if !requireProjectManager(w, r, settings.ProjectID) {
+		return
+	}
+
+	if err := service.SaveProjectTaskSettings(context.TODO(), &settings); err != nil {
+		http.Error(w, "Failed to save settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// SetTaskDueDateHandler postavlja rok zadatka; prazan dueDate briše rok.
+func (uh *TasksHandler) SetTaskDueDateHandler(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		DueDate string `json:"dueDate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	task, err := service.SetTaskDueDate(context.TODO(), mux.Vars(r)["taskId"], requestBody.DueDate)
+	if err != nil {
+		if err.Error() == "task not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
+
+// GetOverdueTasksForUserHandler vraća nezavršene zadatke korisnika kojima je
+// prošao rok. Član vidi samo svoje zadatke.
+func (uh *TasksHandler) GetOverdueTasksForUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+	currentUser, _ := r.Context().Value(KeyAccount{}).(string)
+	role, _ := r.Context().Value(KeyRole{}).(string)
+	if role != "Manager" && userID != currentUser {
+		http.Error(w, "Access forbidden: members can only see their own tasks", http.StatusForbidden)
+		return
+	}
+
+	tasks, err := service.GetOverdueTasksForUser(context.TODO(), userID)
+	if err != nil {
+		http.Error(w, "Failed to fetch overdue tasks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tasks)
+}
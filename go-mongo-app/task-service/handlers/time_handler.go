@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"task-service/models"
+	"task-service/service"
+
+	"github.com/gorilla/mux"
+)
+
+// StartTimerHandler pokreće tajmer prijavljenog člana na zadatku.
+func (uh *TasksHandler) StartTimerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	entry, err := service.StartTimer(context.TODO(), mux.Vars(r)["taskId"], userID)
+	if err != nil {
+		writeTimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// StopTimerHandler zaustavlja tajmer i upisuje vreme, uz opcionu belešku.
+func (uh *TasksHandler) StopTimerHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Note string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	entry, err := service.StopTimer(context.TODO(), mux.Vars(r)["taskId"], userID, request.Note)
+	if err != nil {
+		writeTimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
+
+// LogTimeHandler upisuje vreme provedeno na zadatku.
+func (uh *TasksHandler) LogTimeHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		DurationMinutes int    `json:"durationMinutes"`
+		Note            string `json:"note"`
+		Date            string `json:"date"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		return
+	}
+
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	entry, err := service.LogTime(context.TODO(), mux.Vars(r)["taskId"], userID, request.DurationMinutes, request.Note, request.Date)
+	if err != nil {
+		writeTimeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// GetTaskTimeEntriesHandler vraća unose vremena zadatka članovima projekta.
+func (uh *TasksHandler) GetTaskTimeEntriesHandler(w http.ResponseWriter, r *http.Request) {
+	task, err := service.GetTaskByID(mux.Vars(r)["taskId"])
+	if err != nil {
+		writeTimeError(w, err)
+		return
+	}
+	if !requireProjectMember(w, r, task.Project_ID) {
+		return
+	}
+
+	entries, err := service.GetTaskTimeEntries(context.TODO(), task.ID.Hex())
+	if err != nil {
+		http.Error(w, "Failed to fetch time entries: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// DeleteTimeEntryHandler briše unos vremena prijavljenog korisnika.
+func (uh *TasksHandler) DeleteTimeEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(KeyAccount{}).(string)
+	if err := service.DeleteTimeEntry(context.TODO(), mux.Vars(r)["entryId"], userID); err != nil {
+		writeTimeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUserTimesheetHandler vraća izveštaj vremena korisnika. Član vidi samo
+// svoj izveštaj, a menadžer samo unose korisnika sa projekata kojima upravlja.
+func (uh *TasksHandler) GetUserTimesheetHandler(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userId"]
+	currentUser, _ := r.Context().Value(KeyAccount{}).(string)
+	role, _ := r.Context().Value(KeyRole{}).(string)
+	if role != "Manager" && userID != currentUser {
+		http.Error(w, "Access forbidden: members can only see their own timesheet", http.StatusForbidden)
+		return
+	}
+
+	query := r.URL.Query()
+	timesheet, err := service.GetTimesheet(context.TODO(), userID, "", query.Get("from"), query.Get("to"))
+	if err == nil && userID != currentUser {
+		if timesheet, err = service.KeepManagedProjects(timesheet, currentUser); err != nil {
+			http.Error(w, "Failed to check project manager: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+	}
+	writeTimesheet(w, r, timesheet, err, "timesheet-user-"+userID)
+}
+
+// GetProjectTimesheetHandler vraća izveštaj vremena svih članova projekta
+// njegovom menadžeru.
+func (uh *TasksHandler) GetProjectTimesheetHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+	if !requireProjectManager(w, r, projectID) {
+		return
+	}
+	query := r.URL.Query()
+	timesheet, err := service.GetTimesheet(context.TODO(), "", projectID, query.Get("from"), query.Get("to"))
+	writeTimesheet(w, r, timesheet, err, "timesheet-project-"+projectID)
+}
+
+// writeTimesheet šalje izveštaj kao JSON, ili kao CSV za ?format=csv.
+func writeTimesheet(w http.ResponseWriter, r *http.Request, timesheet *models.Timesheet, err error, fileName string) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		body, err := service.TimesheetCSV(timesheet)
+		if err != nil {
+			http.Error(w, "Failed to write CSV: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".csv"))
+		w.Write(body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(timesheet)
+}
+
+func writeTimeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotTaskMember), errors.Is(err, service.ErrTimeEntryNotAllowed):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, service.ErrTimerRunning):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrNoRunningTimer), errors.Is(err, service.ErrTimeEntryNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err.Error() == "task not found":
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	"github.com/rs/cors"
 	"log"
 	"net/http"
@@ -12,6 +14,8 @@ import (
 	bootstrap "task-service/boostrap"
 	"task-service/db"
 	"task-service/handlers"
+	"task-service/outbox"
+	"task-service/service"
 	"time"
 )
 
@@ -57,8 +61,51 @@ func main() {
 	logger := log.New(os.Stdout, "[product-api] ", log.LstdFlags)
 	taskRepo := db.NewTaskRepo(db.Client)
 
+	// Poruke task.* se čuvaju u JetStream stream-u dok ih consumer-i ne obrade
+	js, err := jetstream.New(nc)
+	if err != nil {
+		log.Fatalf("Error creating JetStream context: %v", err)
+	}
+	if err := outbox.EnsureStream(context.Background(), js); err != nil {
+		log.Fatalf("Error creating %s stream: %v", outbox.Stream.Name, err)
+	}
+
+	// Relay isporučuje događaje i notifikacije upisane u outbox
+	relay := outbox.NewRelay(logger, nc, js)
+	go relay.Run(context.Background())
+
+	// Sage brisanja nastavljaju i posle pada servisa
+	if err := service.EnsureSagaIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating deletion saga indexes: %v", err)
+	}
+	go service.RunDeletionSagas(context.Background(), logger)
+
+	// Pravila automatizacije reaguju na događaje zadataka i na zadatke bez člana
+	if err := service.EnsureAutomationIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating automation indexes: %v", err)
+	}
+	if err := service.ListenForTaskEvents(context.Background(), js, logger); err != nil {
+		log.Fatalf("Error subscribing to task events: %v", err)
+	}
+	go service.RunUnassignedScan(context.Background(), logger)
+
+	// Planer pravi zadatke serija koje se ponavljaju
+	if err := service.EnsureSeriesIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating task series indexes: %v", err)
+	}
+	go service.RunSeriesScheduler(context.Background(), logger)
+
+	if err := service.EnsureTimeEntryIndexes(context.Background()); err != nil {
+		log.Fatalf("Error creating time entry indexes: %v", err)
+	}
+
 	tasksHandler := handlers.NewTasksHandler(logger, taskRepo, nc)
 
+	// Promene table se sa NATS-a prosleđuju pregledačima koji je gledaju
+	if err := tasksHandler.ListenForBoardEvents(); err != nil {
+		log.Fatalf("Error subscribing to board events: %v", err)
+	}
+
 	// Postavke routera
 	router := mux.NewRouter()
 	router.HandleFunc("/tasks", tasksHandler.GetTasks).Methods("GET")
@@ -69,14 +116,38 @@ func main() {
 	router.HandleFunc("/tasks/{taskID}/users", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetUsersForTaskHandler, "Manager", "Member"))).Methods("GET")
 	router.HandleFunc("/tasks/{taskId}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.UpdateTaskHandler, "Member", "Manager"))).Methods("PUT")
 	router.HandleFunc("/tasks/{taskId}/member-of/{userId}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.CheckUserInTaskHandler, "Manager", "Member"))).Methods("GET")
+	router.HandleFunc("/tasks/{task_id}/dependencies", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.SetDependenciesHandler, "Manager"))).Methods("PUT")
 	router.HandleFunc("/tasks/{task_id}/dependencies/{dependency_id}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.AddDependencyHandler, "Manager"))).Methods("PUT")
 	router.HandleFunc("/tasks/projects/{project_id}/tasks", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetTasksForProjectHandler, "Manager"))).Methods("GET")
+	router.HandleFunc("/tasks/projects/{project_id}/rules", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.CreateAutomationRuleHandler, "Manager"))).Methods("POST")
+	router.HandleFunc("/tasks/projects/{project_id}/rules", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetAutomationRulesHandler, "Manager"))).Methods("GET")
+	router.HandleFunc("/tasks/projects/{project_id}/rules/test", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.TestAutomationRuleHandler, "Manager"))).Methods("POST")
+	router.HandleFunc("/tasks/rules/{rule_id}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.UpdateAutomationRuleHandler, "Manager"))).Methods("PUT")
+	router.HandleFunc("/tasks/rules/{rule_id}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.DeleteAutomationRuleHandler, "Manager"))).Methods("DELETE")
+	router.HandleFunc("/tasks/projects/{project_id}/board", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetBoardSnapshotHandler, "Manager", "Member"))).Methods("GET")
+	router.HandleFunc("/tasks/projects/{project_id}/board/stream", tasksHandler.MiddlewareStreamAuth(tasksHandler.RoleRequired(tasksHandler.StreamBoardHandler, "Manager", "Member"))).Methods("GET")
+	router.HandleFunc("/tasks/projects/{project_id}/settings", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetProjectTaskSettingsHandler, "Manager", "Member"))).Methods("GET")
+	router.HandleFunc("/tasks/projects/{project_id}/settings", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.UpdateProjectTaskSettingsHandler, "Manager"))).Methods("PUT")
+	router.HandleFunc("/tasks/projects/{project_id}/series", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.CreateTaskSeriesHandler, "Manager"))).Methods("POST")
+	router.HandleFunc("/tasks/projects/{project_id}/series", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetTaskSeriesHandler, "Manager", "Member"))).Methods("GET")
+	router.HandleFunc("/tasks/series/{series_id}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.UpdateTaskSeriesHandler, "Manager"))).Methods("PUT")
+	router.HandleFunc("/tasks/series/{series_id}/stop", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.StopTaskSeriesHandler, "Manager"))).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/timer/start", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.StartTimerHandler, "Member", "Manager"))).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/timer/stop", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.StopTimerHandler, "Member", "Manager"))).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/time-entries", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.LogTimeHandler, "Member", "Manager"))).Methods("POST")
+	router.HandleFunc("/tasks/{taskId}/time-entries", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetTaskTimeEntriesHandler, "Member", "Manager"))).Methods("GET")
+	router.HandleFunc("/tasks/time-entries/{entryId}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.DeleteTimeEntryHandler, "Member", "Manager"))).Methods("DELETE")
+	router.HandleFunc("/tasks/{taskId}/due-date", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.SetTaskDueDateHandler, "Manager"))).Methods("PUT")
+	router.HandleFunc("/tasks/users/{userId}/overdue", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetOverdueTasksForUserHandler, "Member", "Manager"))).Methods("GET")
+	router.HandleFunc("/tasks/timesheets/users/{userId}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetUserTimesheetHandler, "Member", "Manager"))).Methods("GET")
+	router.HandleFunc("/tasks/timesheets/projects/{project_id}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetProjectTimesheetHandler, "Manager"))).Methods("GET")
 	router.HandleFunc("/tasks/{task_id}/dependenciesWork", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetDependenciesForTaskHandler, "Member", "Manager"))).Methods("GET", "OPTIONS")
 	router.HandleFunc("/tasks/upload", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.UploadFileHandler, "Member"))).Methods("POST")
 	router.HandleFunc("/tasks/{taskID}/download/{fileName:.+}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.DownloadFileHandler, "Member", "Manager"))).Methods("GET")
 	router.HandleFunc("/tasks/files/{taskID}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetTaskFilesHandler, "Member", "Manager"))).Methods("GET", "OPTIONS")
 	router.HandleFunc("/tasks/exists", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.TaskExistsHandler, "Manager"))).Methods("POST")
 	router.HandleFunc("/tasks/delete/{taskID}", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.DeleteTaskByIDHandler, "Manager"))).Methods("DELETE")
+	router.HandleFunc("/tasks/{taskID}/deletion", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.GetTaskDeletionHandler, "Manager"))).Methods("GET")
 	router.HandleFunc("/tasks/{taskID}/position", tasksHandler.MiddlewareExtractUserFromHeader(tasksHandler.RoleRequired(tasksHandler.UpdateTaskPosition, "Member", "Manager"))).Methods("PUT")
 
 	c := cors.New(cors.Options{
@@ -1,6 +1,36 @@
 package models
 
 type Workflow struct {
-	DependencyTasks []string `json:"dependency_tasks"`
-	TaskID          string   `json:"task_id"`
+	DependencyTasks []string         `json:"dependency_tasks"`
+	Dependencies    []DependencyLink `json:"dependencies"`
+	TaskID          string           `json:"task_id"`
+}
+
+// Vrste zavisnosti između zadataka.
+const (
+	FinishToStart  = "FS"
+	StartToStart   = "SS"
+	FinishToFinish = "FF"
+	StartToFinish  = "SF"
+)
+
+// DependencyLink je zavisnost ka zadatku TaskID. LagDays pomera ograničenje
+// za dati broj dana; negativna vrednost je prednost (lead).
+type DependencyLink struct {
+	TaskID  string `json:"task_id"`
+	Type    string `json:"type"`
+	LagDays int    `json:"lag_days"`
+}
+
+// Links vraća zavisnosti sa vrstom; stariji workflow-service vraća samo ID-eve,
+// koji se tretiraju kao finish-to-start.
+func (w *Workflow) Links() []DependencyLink {
+	if len(w.Dependencies) > 0 {
+		return w.Dependencies
+	}
+	links := make([]DependencyLink, 0, len(w.DependencyTasks))
+	for _, id := range w.DependencyTasks {
+		links = append(links, DependencyLink{TaskID: id, Type: FinishToStart})
+	}
+	return links
 }
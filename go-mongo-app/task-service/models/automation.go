@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AutomationRule pokreće akcije kada se na zadatku projekta desi događaj iz
+// okidača i kada su ispunjeni svi uslovi.
+type AutomationRule struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectID  string             `bson:"projectId" json:"projectId"`
+	Name       string             `bson:"name" json:"name"`
+	Enabled    bool               `bson:"enabled" json:"enabled"`
+	Trigger    RuleTrigger        `bson:"trigger" json:"trigger"`
+	Conditions []RuleCondition    `bson:"conditions" json:"conditions"`
+	Actions    []RuleAction       `bson:"actions" json:"actions"`
+	CreatedBy  string             `bson:"createdBy" json:"createdBy"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// RuleTrigger je vrsta događaja koji pokreće pravilo. ForDays važi samo za
+// okidač "unassigned" i kaže koliko dana zadatak mora biti bez člana.
+type RuleTrigger struct {
+	Event   string `bson:"event" json:"event"`
+	ForDays int    `bson:"forDays,omitempty" json:"forDays,omitempty"`
+}
+
+// RuleCondition poredi polje događaja ili zadatka sa vrednošću.
+type RuleCondition struct {
+	Field    string `bson:"field" json:"field"`
+	Operator string `bson:"operator" json:"operator"`
+	Value    string `bson:"value" json:"value"`
+}
+
+// RuleAction je jedna akcija pravila. Message važi za notifikacije, a
+// Position za pomeranje zadatka.
+type RuleAction struct {
+	Type     string `bson:"type" json:"type"`
+	Message  string `bson:"message,omitempty" json:"message,omitempty"`
+	Position *int   `bson:"position,omitempty" json:"position,omitempty"`
+}
+
+// TaskEvent je poruka koju task-service objavljuje na NATS za svaku promenu
+// zadatka na koju pravila mogu da reaguju.
+type TaskEvent struct {
+	ID             string    `json:"id"`
+	Type           string    `json:"type"`
+	TaskID         string    `json:"taskId"`
+	ProjectID      string    `json:"projectId"`
+	PreviousStatus string    `json:"previousStatus,omitempty"`
+	Status         string    `json:"status,omitempty"`
+	UserID         string    `json:"userId,omitempty"`
+	OccurredAt     time.Time `json:"occurredAt"`
+}
+
+// RuleEvaluation je rezultat provere pravila nad jednim događajem. Actions
+// su popunjene samo ako je pravilo pogođeno.
+type RuleEvaluation struct {
+	RuleID         string            `json:"ruleId,omitempty"`
+	RuleName       string            `json:"ruleName"`
+	TaskID         string            `json:"taskId"`
+	TriggerMatched bool              `json:"triggerMatched"`
+	Matched        bool              `json:"matched"`
+	Conditions     []ConditionResult `json:"conditions"`
+	Actions        []PlannedAction   `json:"actions"`
+}
+
+// ConditionResult je ishod jednog uslova sa vrednošću koja je poređena.
+type ConditionResult struct {
+	RuleCondition
+	Actual string `json:"actual"`
+	Passed bool   `json:"passed"`
+}
+
+// PlannedAction je akcija sa razrešenim primaocima ili pozicijom.
+type PlannedAction struct {
+	Type       string   `json:"type"`
+	Recipients []string `json:"recipients,omitempty"`
+	Message    string   `json:"message,omitempty"`
+	Position   *int     `json:"position,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// BoardEvent je promena na tabli projekta koja se šalje pregledačima.
+// Version raste za jedan sa svakom promenom projekta, pa klijent koji
+// primi preskočenu verziju zna da je propustio izmenu.
+type BoardEvent struct {
+	ProjectID  string    `json:"projectId"`
+	Version    int64     `json:"version"`
+	Type       string    `json:"type"`
+	TaskID     string    `json:"taskId"`
+	Field      string    `json:"field,omitempty"`
+	Task       *Task     `json:"task,omitempty"`
+	UserID     string    `json:"userId,omitempty"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// BoardSnapshot je stanje table od kog klijent kreće i na koje primenjuje
+// događaje sa većom verzijom.
+type BoardSnapshot struct {
+	ProjectID string `json:"projectId"`
+	Version   int64  `json:"version"`
+	Tasks     []Task `json:"tasks"`
+}
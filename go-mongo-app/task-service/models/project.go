@@ -12,3 +12,13 @@ type Project struct {
 	MaxPeople       int                `bson:"max_people" json:"max_people"`
 	Users           []string           `bson:"users" json:"users"`
 }
+
+// OverdueTask je nezavršen zadatak čiji je rok prošao.
+type OverdueTask struct {
+	TaskID       string `json:"taskId"`
+	Name         string `json:"name"`
+	Status       string `json:"status"`
+	ProjectID    string `json:"projectId"`
+	ProjectTitle string `json:"projectTitle"`
+	DueDate      string `json:"dueDate"`
+}
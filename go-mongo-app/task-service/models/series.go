@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Načini pravljenja sledećeg zadatka serije.
+const (
+	// SeriesOnSchedule pravi zadatak u zakazano vreme, bez obzira na prethodni
+	SeriesOnSchedule = "on_schedule"
+	// SeriesAfterDone pravi sledeći zadatak kada je prethodni završen
+	SeriesAfterDone = "after_done"
+)
+
+// Stanja serije.
+const (
+	SeriesActive    = "active"
+	SeriesStopped   = "stopped"
+	SeriesCompleted = "completed"
+)
+
+// TaskSeries je šablon zadatka koji se ponavlja po pravilu Rule.
+type TaskSeries struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProjectID        string             `bson:"projectId" json:"projectId"`
+	Name             string             `bson:"name" json:"name"`
+	Description      string             `bson:"description" json:"description"`
+	Rule             RecurrenceRule     `bson:"rule" json:"rule"`
+	Mode             string             `bson:"mode" json:"mode"`
+	Status           string             `bson:"status" json:"status"`
+	StartAt          time.Time          `bson:"startAt" json:"startAt"`
+	NextRunAt        *time.Time         `bson:"nextRunAt,omitempty" json:"nextRunAt,omitempty"`
+	LastOccurrenceAt *time.Time         `bson:"lastOccurrenceAt,omitempty" json:"lastOccurrenceAt,omitempty"`
+	LastTaskID       string             `bson:"lastTaskId,omitempty" json:"lastTaskId,omitempty"`
+	Occurrences      int                `bson:"occurrences" json:"occurrences"`
+	Skipped          int                `bson:"skipped" json:"skipped"`
+	CreatedBy        string             `bson:"createdBy" json:"createdBy"`
+	CreatedAt        time.Time          `bson:"createdAt" json:"createdAt"`
+	LockedUntil      time.Time          `bson:"lockedUntil" json:"-"`
+}
+
+// RecurrenceRule je podskup RFC 5545 RRULE: FREQ, INTERVAL, BYDAY,
+// BYMONTHDAY, COUNT i UNTIL.
+type RecurrenceRule struct {
+	Freq       string     `bson:"freq" json:"freq"`
+	Interval   int        `bson:"interval" json:"interval"`
+	ByDay      []string   `bson:"byDay,omitempty" json:"byDay,omitempty"`
+	ByMonthDay int        `bson:"byMonthDay,omitempty" json:"byMonthDay,omitempty"`
+	Count      int        `bson:"count,omitempty" json:"count,omitempty"`
+	Until      *time.Time `bson:"until,omitempty" json:"until,omitempty"`
+}
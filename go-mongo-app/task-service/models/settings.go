@@ -0,0 +1,8 @@
+package models
+
+// ProjectTaskSettings su podešavanja zadataka jednog projekta.
+type ProjectTaskSettings struct {
+	ProjectID string `bson:"_id" json:"projectId"`
+	// DependencyPropagation uključuje ready i atRisk oznake zavisnih zadataka
+	DependencyPropagation bool `bson:"dependencyPropagation" json:"dependencyPropagation"`
+}
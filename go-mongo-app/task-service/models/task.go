@@ -1,17 +1,28 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Task struct {
-	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
-	Name        string               `bson:"name" json:"name"`
-	Description string               `bson:"description" json:"description"`
-	Status      string               `bson:"status" json:"status"`
-	Users       []string             `bson:"users" json:"users"`
-	Project_ID  string               `json:"project_id" bson:"project_id"`
-	DependsOn   []primitive.ObjectID `json:"dependsOn" bson:"dependsOn"`
-	FilePaths   []string             `bson:"filePaths" json:"filePaths"`
-	Position    int                  `bson:"position" json:"position"`
+	ID              primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name            string               `bson:"name" json:"name"`
+	Description     string               `bson:"description" json:"description"`
+	Status          string               `bson:"status" json:"status"`
+	Users           []string             `bson:"users" json:"users"`
+	Project_ID      string               `json:"project_id" bson:"project_id"`
+	DependsOn       []primitive.ObjectID `json:"dependsOn" bson:"dependsOn"`
+	FilePaths       []string             `bson:"filePaths" json:"filePaths"`
+	Position        int                  `bson:"position" json:"position"`
+	Deleting        bool                 `bson:"deleting,omitempty" json:"deleting,omitempty"`
+	StartedAt       *time.Time           `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	FinishedAt      *time.Time           `bson:"finishedAt,omitempty" json:"finishedAt,omitempty"`
+	UnassignedSince *time.Time           `bson:"unassignedSince,omitempty" json:"unassignedSince,omitempty"`
+	Ready           bool                 `bson:"ready,omitempty" json:"ready,omitempty"`
+	AtRisk          bool                 `bson:"atRisk,omitempty" json:"atRisk,omitempty"`
+	SeriesID        string               `bson:"seriesId,omitempty" json:"seriesId,omitempty"`
+	OccurrenceAt    *time.Time           `bson:"occurrenceAt,omitempty" json:"occurrenceAt,omitempty"`
+	DueDate         string               `bson:"dueDate,omitempty" json:"dueDate,omitempty"`
 }
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TimeEntry je vreme koje je član proveo na zadatku. Unos sa pokrenutim
+// tajmerom (Running) još nema trajanje.
+type TimeEntry struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TaskID          string             `bson:"taskId" json:"taskId"`
+	ProjectID       string             `bson:"projectId" json:"projectId"`
+	UserID          string             `bson:"userId" json:"userId"`
+	Date            string             `bson:"date" json:"date"`
+	DurationMinutes int                `bson:"durationMinutes" json:"durationMinutes"`
+	Note            string             `bson:"note" json:"note"`
+	Running         bool               `bson:"running,omitempty" json:"running,omitempty"`
+	StartedAt       *time.Time         `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	EndedAt         *time.Time         `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	CreatedAt       time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// Timesheet je zbir unosa vremena korisnika ili projekta za period.
+type Timesheet struct {
+	UserID       string         `json:"userId,omitempty"`
+	ProjectID    string         `json:"projectId,omitempty"`
+	From         string         `json:"from,omitempty"`
+	To           string         `json:"to,omitempty"`
+	TotalMinutes int            `json:"totalMinutes"`
+	ByTask       map[string]int `json:"byTask"`
+	ByUser       map[string]int `json:"byUser"`
+	ByWeek       map[string]int `json:"byWeek"`
+	Entries      []TimeEntry    `json:"entries"`
+}
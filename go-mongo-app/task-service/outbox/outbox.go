@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"contracts"
+	"log"
+	"shared/outbox"
+	"task-service/db"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// tasks is the outbox of task-service. Events also go to analytics-service,
+// which keeps status change statistics.
+var tasks = outbox.New(outbox.Config{
+	Service:    "task-service",
+	Collection: Collection,
+	Stream:     Stream,
+	History:    func(h contracts.History) contracts.Message { return &contracts.TaskHistory{History: h} },
+	Endpoints: map[string]string{
+		outbox.DestinationEventStore: "http://event_sourcing:8080/event/append",
+		outbox.DestinationAnalytics:  "http://analytics-service:8080/analytics/status-change",
+	},
+})
+
+// Messages are built with the shared constructors.
+type Message = outbox.Message
+
+var (
+	Event     = outbox.Event
+	Analytics = outbox.Analytics
+	NATS      = outbox.NATS
+	Contract  = outbox.Contract
+)
+
+func Collection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("task_outbox")
+}
+
+// Enqueue stores messages in the outbox. ctx must be the session context of
+// the transaction writing the domain change, otherwise the two can diverge.
+func Enqueue(ctx context.Context, messages ...Message) error {
+	return tasks.Enqueue(ctx, messages...)
+}
+
+// EnsureStream creates the stream, or updates it to the current config.
+func EnsureStream(ctx context.Context, js jetstream.JetStream) error {
+	return tasks.EnsureStream(ctx, js)
+}
+
+func NewRelay(logger *log.Logger, natsConn *nats.Conn, js jetstream.JetStream) *outbox.Relay {
+	return tasks.NewRelay(logger, natsConn, js)
+}
@@ -0,0 +1,476 @@
+package service
+
+import (
+	"context"
+	"contracts"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"shared/security"
+	"strconv"
+	"strings"
+	"task-service/db"
+	"task-service/models"
+	"task-service/outbox"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// automationActor je autor izmena koje prave pravila
+	automationActor = "automation"
+
+	// automationConsumer je trajni consumer na TASKS stream-u koji dele sve instance
+	automationConsumer = "task-automation"
+	// automationMaxDeliver je broj isporuka događaja posle kog se odustaje
+	automationMaxDeliver = 5
+	automationRetryDelay = 10 * time.Second
+	unassignedInterval   = 15 * time.Minute
+)
+
+// ListenForTaskEvents pokreće pravila za događaje sa task.events. Trajni
+// consumer pamti dokle je stigao, pa se događaji objavljeni dok servis ne
+// radi obrade kada se podigne, a instance servisa ih dele. Poruka se potvrđuje
+// tek posle uspešne obrade; neuspela obrada se ponavlja sa pauzom, najviše
+// automationMaxDeliver puta. Pokretanje pravila se beleži zajedno sa njegovim
+// akcijama, pa ponovljena poruka ne pokreće isto pravilo dvaput.
+func ListenForTaskEvents(ctx context.Context, js jetstream.JetStream, logger *log.Logger) error {
+	consumer, err := js.CreateOrUpdateConsumer(ctx, outbox.Stream.Name, jetstream.ConsumerConfig{
+		Durable:       automationConsumer,
+		FilterSubject: TaskEventsSubject,
+		DeliverPolicy: jetstream.DeliverAllPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       time.Minute,
+		MaxDeliver:    automationMaxDeliver,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = consumer.Consume(func(msg jetstream.Msg) {
+		var event models.TaskEvent
+		if err := json.Unmarshal(msg.Data(), &event); err != nil {
+			logger.Printf("Automation: invalid task event: %v", err)
+			msg.Term()
+			return
+		}
+		err := handleTaskEvent(context.Background(), event, logger)
+		if err == nil {
+			msg.Ack()
+			return
+		}
+
+		delivered := uint64(0)
+		if meta, metaErr := msg.Metadata(); metaErr == nil {
+			delivered = meta.NumDelivered
+		}
+		if delivered >= automationMaxDeliver {
+			logger.Printf("Automation: giving up on %s event for task %s after %d deliveries: %v", event.Type, event.TaskID, delivered, err)
+			msg.Term()
+			return
+		}
+		logger.Printf("Automation: handling %s event for task %s failed (delivery %d), retrying: %v", event.Type, event.TaskID, delivered, err)
+		msg.NakWithDelay(automationRetryDelay * time.Duration(delivered))
+	})
+	return err
+}
+
+func handleTaskEvent(ctx context.Context, event models.TaskEvent, logger *log.Logger) error {
+	task, err := GetTaskByID(event.TaskID)
+	if err != nil {
+		// Zadatak je u međuvremenu obrisan
+		return nil
+	}
+	if err := runRules(ctx, event, task, logger); err != nil {
+		return err
+	}
+
+	if event.Type == TaskEventStatusChanged && event.Status == "done" {
+		return fireDependenciesDone(ctx, event, task, logger)
+	}
+	return nil
+}
+
+// fireDependenciesDone pokreće dependencies_done za zadatke kojima je
+// završeni zadatak bio poslednja nezavršena zavisnost.
+func fireDependenciesDone(ctx context.Context, event models.TaskEvent, done *models.Task, logger *log.Logger) error {
+	collection := db.Client.Database("testdb").Collection("tasks")
+	cursor, err := collection.Find(ctx, bson.M{"dependsOn": done.ID})
+	if err != nil {
+		return err
+	}
+	var dependents []models.Task
+	if err := cursor.All(ctx, &dependents); err != nil {
+		return err
+	}
+
+	for i := range dependents {
+		dependent := &dependents[i]
+		pending, err := collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": dependent.DependsOn}, "status": bson.M{"$ne": "done"}})
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			continue
+		}
+		derived := models.TaskEvent{
+			ID:         event.ID,
+			Type:       TriggerDependenciesDone,
+			TaskID:     dependent.ID.Hex(),
+			ProjectID:  dependent.Project_ID,
+			Status:     dependent.Status,
+			OccurredAt: event.OccurredAt,
+		}
+		if err := runRules(ctx, derived, dependent, logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunUnassignedScan periodično pokreće pravila sa okidačem unassigned.
+// Zadaci bez unassignedSince potiču iz vremena pre praćenja članova i
+// preskaču se.
+func RunUnassignedScan(ctx context.Context, logger *log.Logger) {
+	ticker := time.NewTicker(unassignedInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := scanUnassigned(ctx, logger); err != nil {
+			logger.Printf("Automation: unassigned scan failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func scanUnassigned(ctx context.Context, logger *log.Logger) error {
+	cursor, err := rulesCollection().Find(ctx, bson.M{"enabled": true, "trigger.event": TriggerUnassigned})
+	if err != nil {
+		return err
+	}
+	var rules []models.AutomationRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return err
+	}
+
+	collection := db.Client.Database("testdb").Collection("tasks")
+	for _, rule := range rules {
+		cutoff := time.Now().AddDate(0, 0, -rule.Trigger.ForDays)
+		cursor, err := collection.Find(ctx, bson.M{
+			"project_id":      rule.ProjectID,
+			"unassignedSince": bson.M{"$lte": cutoff},
+			"deleting":        bson.M{"$ne": true},
+		})
+		if err != nil {
+			return err
+		}
+		var tasks []models.Task
+		if err := cursor.All(ctx, &tasks); err != nil {
+			return err
+		}
+
+		for i := range tasks {
+			task := &tasks[i]
+			if len(task.Users) > 0 {
+				continue
+			}
+			// Jedno pokretanje po periodu bez člana
+			event := models.TaskEvent{
+				ID:         "unassigned-" + task.UnassignedSince.UTC().Format(time.RFC3339),
+				Type:       TriggerUnassigned,
+				TaskID:     task.ID.Hex(),
+				ProjectID:  task.Project_ID,
+				Status:     task.Status,
+				OccurredAt: time.Now().UTC(),
+			}
+			if err := runRule(ctx, rule, event, task, logger); err != nil {
+				logger.Printf("Automation: rule %s on task %s failed: %v", rule.ID.Hex(), task.ID.Hex(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// runRules pokreće uključena pravila projekta čiji okidač odgovara događaju.
+func runRules(ctx context.Context, event models.TaskEvent, task *models.Task, logger *log.Logger) error {
+	cursor, err := rulesCollection().Find(ctx, bson.M{"projectId": task.Project_ID, "enabled": true, "trigger.event": event.Type})
+	if err != nil {
+		return err
+	}
+	var rules []models.AutomationRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return err
+	}
+	// Greška jednog pravila ne sprečava ostala; već pokrenuta pravila se pri
+	// ponovnoj isporuci preskaču
+	var failed []error
+	for _, rule := range rules {
+		if err := runRule(ctx, rule, event, task, logger); err != nil {
+			logger.Printf("Automation: rule %s on task %s failed: %v", rule.ID.Hex(), task.ID.Hex(), err)
+			failed = append(failed, err)
+		}
+	}
+	return errors.Join(failed...)
+}
+
+func runRule(ctx context.Context, rule models.AutomationRule, event models.TaskEvent, task *models.Task, logger *log.Logger) error {
+	evaluation, err := EvaluateAutomationRule(rule, event, task)
+	if err != nil {
+		return err
+	}
+	if !evaluation.Matched {
+		return nil
+	}
+
+	var messages []outbox.Message
+	var positions []int
+	for _, action := range evaluation.Actions {
+		switch action.Type {
+		case ActionSetPosition:
+			positions = append(positions, *action.Position)
+		default:
+			if len(action.Recipients) > 0 {
+				messages = append(messages, outbox.Contract(&contracts.TaskAutomationNotified{
+					TaskMembers: taskMembers(task, action.Recipients),
+					RuleName:    rule.Name,
+					Message:     action.Message,
+				}))
+			}
+		}
+	}
+
+	// Pokretanje se beleži pre akcija i u istoj transakciji, pa akcije
+	// pravila za isti događaj mogu da se izvrše samo jednom
+	err = db.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		_, err := ruleRunsCollection().InsertOne(sessCtx, bson.M{
+			"_id":     primitive.NewObjectID(),
+			"ruleId":  rule.ID,
+			"taskId":  task.ID.Hex(),
+			"key":     event.ID,
+			"event":   event.Type,
+			"firedAt": time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		for _, position := range positions {
+			if err := setTaskPosition(sessCtx, task.ID, position, automationActor); err != nil {
+				return err
+			}
+		}
+		return outbox.Enqueue(sessCtx, messages...)
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	if err == nil {
+		logger.Printf("Automation: rule %q fired for task %s on %s", rule.Name, task.ID.Hex(), event.Type)
+	}
+	return err
+}
+
+// EvaluateAutomationRule proverava okidač i uslove pravila nad događajem i
+// razrešava primaoce i pozicije akcija, bez izvršavanja akcija.
+func EvaluateAutomationRule(rule models.AutomationRule, event models.TaskEvent, task *models.Task) (*models.RuleEvaluation, error) {
+	evaluation := &models.RuleEvaluation{
+		RuleName:       rule.Name,
+		TaskID:         task.ID.Hex(),
+		TriggerMatched: rule.Trigger.Event == event.Type,
+		Conditions:     []models.ConditionResult{},
+		Actions:        []models.PlannedAction{},
+	}
+	if !rule.ID.IsZero() {
+		evaluation.RuleID = rule.ID.Hex()
+	}
+
+	passed := true
+	for _, condition := range rule.Conditions {
+		actual := conditionValue(condition.Field, event, task)
+		result := models.ConditionResult{RuleCondition: condition, Actual: actual, Passed: compare(actual, condition.Operator, condition.Value)}
+		passed = passed && result.Passed
+		evaluation.Conditions = append(evaluation.Conditions, result)
+	}
+	evaluation.Matched = evaluation.TriggerMatched && passed
+	if !evaluation.Matched {
+		return evaluation, nil
+	}
+
+	for _, action := range rule.Actions {
+		planned := models.PlannedAction{Type: action.Type}
+		switch action.Type {
+		case ActionNotifyAssignees:
+			planned.Recipients = task.Users
+			planned.Message = notificationText(action, rule, task)
+		case ActionNotifyManager:
+			managerID, err := projectManagerID(task.Project_ID)
+			if err != nil {
+				return nil, err
+			}
+			planned.Recipients = []string{managerID}
+			planned.Message = notificationText(action, rule, task)
+		case ActionSetPosition:
+			planned.Position = action.Position
+		}
+		evaluation.Actions = append(evaluation.Actions, planned)
+	}
+	return evaluation, nil
+}
+
+func conditionValue(field string, event models.TaskEvent, task *models.Task) string {
+	switch field {
+	case "status":
+		return task.Status
+	case "previousStatus":
+		return event.PreviousStatus
+	case "name":
+		return task.Name
+	case "memberCount":
+		return strconv.Itoa(len(task.Users))
+	case "position":
+		return strconv.Itoa(task.Position)
+	case "dependencyCount":
+		return strconv.Itoa(len(task.DependsOn))
+	case "userId":
+		return event.UserID
+	}
+	return ""
+}
+
+// compare poredi brojeve za gt i lt, a tekst bez obzira na velika slova za ostale operatore.
+func compare(actual, operator, expected string) bool {
+	switch operator {
+	case "eq":
+		return strings.EqualFold(actual, expected)
+	case "neq":
+		return !strings.EqualFold(actual, expected)
+	case "contains":
+		return strings.Contains(strings.ToLower(actual), strings.ToLower(expected))
+	case "gt", "lt":
+		a, errA := strconv.Atoi(actual)
+		b, errB := strconv.Atoi(expected)
+		if errA != nil || errB != nil {
+			return false
+		}
+		if operator == "gt" {
+			return a > b
+		}
+		return a < b
+	}
+	return false
+}
+
+// notificationText zamenjuje {task}, {status} i {rule} u poruci akcije.
+func notificationText(action models.RuleAction, rule models.AutomationRule, task *models.Task) string {
+	message := action.Message
+	if message == "" {
+		message = `Automation "{rule}" ran for the "{task}" task`
+	}
+	return strings.NewReplacer("{task}", task.Name, "{status}", task.Status, "{rule}", rule.Name).Replace(message)
+}
+
+// projectManagerID dohvata menadžera projekta iz project-service.
+func projectManagerID(projectID string) (string, error) {
+	project, err := fetchProject(projectID)
+	if err != nil {
+		return "", err
+	}
+	return project.ManagerID, nil
+}
+
+// IsProjectMember proverava da li je korisnik menadžer ili član projekta.
+func IsProjectMember(projectID, userID string) (bool, error) {
+	project, err := fetchProject(projectID)
+	if err != nil {
+		return false, err
+	}
+	if project.ManagerID == userID {
+		return true, nil
+	}
+	for _, member := range project.Users {
+		if member == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsProjectManager proverava da li je korisnik menadžer projekta.
+func IsProjectManager(projectID, userID string) (bool, error) {
+	managerID, err := projectManagerID(projectID)
+	if err != nil {
+		return false, err
+	}
+	return managerID == userID, nil
+}
+
+type projectInfo struct {
+	ManagerID string   `json:"manager_id"`
+	Title     string   `json:"title"`
+	Users     []string `json:"users"`
+}
+
+// fetchProject dohvata naziv, menadžera i članove projekta iz project-service.
+func fetchProject(projectID string) (*projectInfo, error) {
+	token, err := security.ServiceToken("task-service")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://project-service:8080/projects/%s", projectID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch project from project-service: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch project, status: %d", resp.StatusCode)
+	}
+
+	var project projectInfo
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return nil, fmt.Errorf("failed to decode project: %v", err)
+	}
+	return &project, nil
+}
+
+// TestAutomationRule proverava pravilo nad zadatkom bez izvršavanja akcija.
+// Ako event nije zadat, pravi se događaj okidača pravila sa trenutnim
+// stanjem zadatka.
+func TestAutomationRule(rule models.AutomationRule, taskID string, event *models.TaskEvent) (*models.RuleEvaluation, error) {
+	task, err := GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Project_ID != rule.ProjectID {
+		return nil, fmt.Errorf("task %s does not belong to project %s", taskID, rule.ProjectID)
+	}
+
+	if event == nil {
+		event = &models.TaskEvent{Type: rule.Trigger.Event, Status: task.Status}
+	}
+	event.TaskID = task.ID.Hex()
+	event.ProjectID = task.Project_ID
+	if event.Type == "" {
+		event.Type = rule.Trigger.Event
+	}
+	if event.Status == "" {
+		event.Status = task.Status
+	}
+	return EvaluateAutomationRule(rule, *event, task)
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task-service/db"
+	"task-service/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Okidači pravila automatizacije. Pored događaja sa task.events postoje
+// izvedeni okidači: dependencies_done kada su gotove sve zavisnosti zadatka
+// i unassigned kada je zadatak Trigger.ForDays dana bez člana.
+const (
+	TriggerTaskCreated      = TaskEventCreated
+	TriggerStatusChanged    = TaskEventStatusChanged
+	TriggerMemberAdded      = TaskEventMemberAdded
+	TriggerMemberRemoved    = TaskEventMemberRemoved
+	TriggerDependenciesDone = "dependencies_done"
+	TriggerUnassigned       = "unassigned"
+)
+
+// Akcije pravila automatizacije.
+const (
+	ActionNotifyAssignees = "notify_assignees"
+	ActionNotifyManager   = "notify_manager"
+	ActionSetPosition     = "set_position"
+)
+
+var ErrRuleNotFound = errors.New("automation rule not found")
+
+var (
+	ruleTriggers  = map[string]bool{TriggerTaskCreated: true, TriggerStatusChanged: true, TriggerMemberAdded: true, TriggerMemberRemoved: true, TriggerDependenciesDone: true, TriggerUnassigned: true}
+	ruleActions   = map[string]bool{ActionNotifyAssignees: true, ActionNotifyManager: true, ActionSetPosition: true}
+	ruleOperators = map[string]bool{"eq": true, "neq": true, "contains": true, "gt": true, "lt": true}
+	ruleFields    = map[string]bool{"status": true, "previousStatus": true, "name": true, "memberCount": true, "position": true, "dependencyCount": true, "userId": true}
+)
+
+func rulesCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("automation_rules")
+}
+
+// ruleRunsCollection beleži koja pravila su već pokrenuta za koji događaj,
+// da ponovljena poruka ili ponovljeno skeniranje ne pokrenu akcije dvaput.
+func ruleRunsCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("automation_runs")
+}
+
+// EnsureAutomationIndexes pravi indekse za pravila i jedinstven indeks za
+// njihova pokretanja.
+func EnsureAutomationIndexes(ctx context.Context) error {
+	if _, err := rulesCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "projectId", Value: 1}, {Key: "trigger.event", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	_, err := ruleRunsCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "ruleId", Value: 1}, {Key: "taskId", Value: 1}, {Key: "key", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// ValidateAutomationRule proverava okidač, uslove i akcije pravila.
+func ValidateAutomationRule(rule *models.AutomationRule) error {
+	if rule.Name == "" {
+		return errors.New("rule name cannot be empty")
+	}
+	if !ruleTriggers[rule.Trigger.Event] {
+		return fmt.Errorf("unknown trigger event %q", rule.Trigger.Event)
+	}
+	if rule.Trigger.Event == TriggerUnassigned && rule.Trigger.ForDays < 1 {
+		return errors.New("unassigned trigger requires forDays of at least 1")
+	}
+	for _, condition := range rule.Conditions {
+		if !ruleFields[condition.Field] {
+			return fmt.Errorf("unknown condition field %q", condition.Field)
+		}
+		if !ruleOperators[condition.Operator] {
+			return fmt.Errorf("unknown condition operator %q", condition.Operator)
+		}
+	}
+	if len(rule.Actions) == 0 {
+		return errors.New("rule needs at least one action")
+	}
+	for _, action := range rule.Actions {
+		if !ruleActions[action.Type] {
+			return fmt.Errorf("unknown action %q", action.Type)
+		}
+		if action.Type == ActionSetPosition && (action.Position == nil || *action.Position < 0) {
+			return errors.New("set_position action requires a non-negative position")
+		}
+	}
+	return nil
+}
+
+// CreateAutomationRule čuva novo pravilo projekta.
+func CreateAutomationRule(ctx context.Context, rule *models.AutomationRule) error {
+	if err := ValidateAutomationRule(rule); err != nil {
+		return err
+	}
+	rule.ID = primitive.NewObjectID()
+	rule.CreatedAt = time.Now()
+	if rule.Conditions == nil {
+		rule.Conditions = []models.RuleCondition{}
+	}
+	_, err := rulesCollection().InsertOne(ctx, rule)
+	return err
+}
+
+// GetAutomationRules vraća sva pravila projekta.
+func GetAutomationRules(ctx context.Context, projectID string) ([]models.AutomationRule, error) {
+	cursor, err := rulesCollection().Find(ctx, bson.M{"projectId": projectID}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	rules := []models.AutomationRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// GetAutomationRule vraća pravilo po ID-u.
+func GetAutomationRule(ctx context.Context, ruleID string) (*models.AutomationRule, error) {
+	id, err := primitive.ObjectIDFromHex(ruleID)
+	if err != nil {
+		return nil, ErrRuleNotFound
+	}
+	var rule models.AutomationRule
+	err = rulesCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&rule)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRuleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// UpdateAutomationRule menja naziv, okidač, uslove, akcije i uključenost
+// pravila. Projekat i autor pravila se ne menjaju.
+func UpdateAutomationRule(ctx context.Context, ruleID string, rule *models.AutomationRule) (*models.AutomationRule, error) {
+	if err := ValidateAutomationRule(rule); err != nil {
+		return nil, err
+	}
+	existing, err := GetAutomationRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Conditions == nil {
+		rule.Conditions = []models.RuleCondition{}
+	}
+	existing.Name = rule.Name
+	existing.Enabled = rule.Enabled
+	existing.Trigger = rule.Trigger
+	existing.Conditions = rule.Conditions
+	existing.Actions = rule.Actions
+
+	if _, err := rulesCollection().ReplaceOne(ctx, bson.M{"_id": existing.ID}, existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteAutomationRule briše pravilo.
+func DeleteAutomationRule(ctx context.Context, ruleID string) error {
+	id, err := primitive.ObjectIDFromHex(ruleID)
+	if err != nil {
+		return ErrRuleNotFound
+	}
+	result, err := rulesCollection().DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"task-service/db"
+	"task-service/models"
+	"task-service/outbox"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BoardSubjectPrefix je prefiks NATS subjekta table projekta: board.<projectId>.
+const BoardSubjectPrefix = "board."
+
+// Vrste događaja table.
+const (
+	BoardTaskCreated = "task_created"
+	BoardTaskUpdated = "task_updated"
+	BoardTaskMoved   = "task_moved"
+	BoardTaskDeleted = "task_deleted"
+)
+
+func BoardSubject(projectID string) string {
+	return BoardSubjectPrefix + projectID
+}
+
+func boardVersionsCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("board_versions")
+}
+
+// enqueueBoardEvent povećava verziju table i upisuje događaj u outbox u istoj
+// transakciji kao i promena. Zadatak se čita iz sessCtx, pa događaj nosi
+// stanje posle promene; za obrisan zadatak Task ostaje prazan.
+// Istovremene promene istog projekta se sudaraju na brojaču verzije, a
+// transakcija se ponavlja, tako da verzije nemaju rupa.
+func enqueueBoardEvent(sessCtx mongo.SessionContext, eventType, projectID string, taskID primitive.ObjectID, field, userID string) error {
+	var counter struct {
+		Version int64 `bson:"version"`
+	}
+	err := boardVersionsCollection().FindOneAndUpdate(sessCtx,
+		bson.M{"_id": projectID},
+		bson.M{"$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return err
+	}
+
+	event := models.BoardEvent{
+		ProjectID:  projectID,
+		Version:    counter.Version,
+		Type:       eventType,
+		TaskID:     taskID.Hex(),
+		Field:      field,
+		UserID:     userID,
+		OccurredAt: time.Now().UTC(),
+	}
+	if eventType != BoardTaskDeleted {
+		var task models.Task
+		err := db.Client.Database("testdb").Collection("tasks").FindOne(sessCtx, bson.M{"_id": taskID}).Decode(&task)
+		if err != nil {
+			return err
+		}
+		event.Task = &task
+	}
+	return outbox.Enqueue(sessCtx, outbox.NATS(BoardSubject(projectID), event))
+}
+
+// GetBoardVersion vraća poslednju verziju table projekta.
+func GetBoardVersion(ctx context.Context, projectID string) (int64, error) {
+	var counter struct {
+		Version int64 `bson:"version"`
+	}
+	err := boardVersionsCollection().FindOne(ctx, bson.M{"_id": projectID}).Decode(&counter)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return counter.Version, err
+}
+
+// GetBoardSnapshot vraća zadatke projekta i verziju table. Verzija se čita pre
+// zadataka, pa snimak može već sadržati neke promene posle nje; događaji nose
+// ceo zadatak, tako da ih klijent može bezbedno ponovo primeniti.
+func GetBoardSnapshot(ctx context.Context, projectID string) (*models.BoardSnapshot, error) {
+	version, err := GetBoardVersion(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := db.Client.Database("testdb").Collection("tasks").Find(ctx,
+		bson.M{"project_id": projectID},
+		options.Find().SetSort(bson.M{"position": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return &models.BoardSnapshot{ProjectID: projectID, Version: version, Tasks: tasks}, nil
+}
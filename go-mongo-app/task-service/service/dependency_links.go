@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"shared/security"
+	"task-service/models"
+	"time"
+)
+
+// IsValidDependencyType proverava da li je vrsta zavisnosti podržana.
+func IsValidDependencyType(dependencyType string) bool {
+	switch dependencyType {
+	case models.FinishToStart, models.StartToStart, models.FinishToFinish, models.StartToFinish:
+		return true
+	}
+	return false
+}
+
+// checkDependencyLink proverava da li zadatak sme da pređe u status dok
+// prethodnik pred ima svoje trenutno stanje. Zadatak počinje prelaskom iz
+// "pending", a završava prelaskom u "done".
+//
+// Kašnjenje (lag) se računa od trenutka kada je prethodnik počeo ili završio.
+// Prednost (negativan lag) ne može da se proveri bez planiranog datuma, pa
+// samo ublažava uslov: umesto završetka prethodnika dovoljan je njegov početak,
+// a umesto početka ne traži se ništa.
+func checkDependencyLink(link models.DependencyLink, pred *models.Task, status string, now time.Time) error {
+	starting := status != "pending"
+	finishing := status == "done"
+
+	var anchorsFinish bool
+	switch link.Type {
+	case models.StartToStart:
+		if !starting {
+			return nil
+		}
+	case models.FinishToFinish:
+		if !finishing {
+			return nil
+		}
+		anchorsFinish = true
+	case models.StartToFinish:
+		if !finishing {
+			return nil
+		}
+	default:
+		if !starting {
+			return nil
+		}
+		anchorsFinish = true
+	}
+
+	if link.LagDays < 0 {
+		if !anchorsFinish {
+			return nil
+		}
+		anchorsFinish = false
+	}
+
+	predID := pred.ID.Hex()
+	var at *time.Time
+	if anchorsFinish {
+		if pred.Status != "done" {
+			return fmt.Errorf("cannot change status: dependency task %s (%s) is not done", predID, link.Type)
+		}
+		at = pred.FinishedAt
+	} else {
+		if pred.Status == "pending" {
+			return fmt.Errorf("cannot change status: dependency task %s (%s) is pending", predID, link.Type)
+		}
+		at = pred.StartedAt
+	}
+
+	// Zadaci iz vremena pre praćenja početka i završetka nemaju vreme, pa lag ne važi
+	if link.LagDays > 0 && at != nil {
+		ready := at.AddDate(0, 0, link.LagDays)
+		if now.Before(ready) {
+			return fmt.Errorf("cannot change status: dependency task %s (%s) has a lag until %s", predID, link.Type, ready.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// registerDependencyLink upisuje zavisnost u workflow-service, koji proverava
+// cikluse i čuva vrstu i kašnjenje veze.
+func registerDependencyLink(task *models.Task, link models.DependencyLink) error {
+	token, err := security.ServiceToken("task-service")
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"task_id":      task.ID.Hex(),
+		"project_id":   task.Project_ID,
+		"dependencies": []models.DependencyLink{link},
+	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to serialize dependency payload: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", "http://workflow-service:8080/workflow/createWorkflow", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request to workflow-service: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to workflow-service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("workflow-service rejected dependency: %s", bytes.TrimSpace(body))
+	}
+	return nil
+}
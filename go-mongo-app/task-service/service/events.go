@@ -0,0 +1,147 @@
+package service
+
+import (
+	"contracts"
+	"shared/saga"
+	"task-service/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Poruke koje se upisuju u outbox zajedno sa promenom zadatka.
+
+func eventTime() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+func taskCreatedEvent(task *models.Task) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "Task Created",
+		"version": 2,
+		"time":    eventTime(),
+		"event": map[string]interface{}{
+			"taskId":      task.ID,
+			"projectId":   task.Project_ID,
+			"name":        task.Name,
+			"description": task.Description,
+			"status":      task.Status,
+			"dependsOn":   task.DependsOn,
+			"position":    task.Position,
+		},
+		"projectId": task.Project_ID,
+	}
+}
+
+func taskStatusChangedEvent(task *models.Task, previousStatus, currentStatus string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "Task Status Changed",
+		"version": 2,
+		"time":    eventTime(),
+		"event": map[string]interface{}{
+			"taskId":         task.ID,
+			"projectId":      task.Project_ID,
+			"previousStatus": previousStatus,
+			"currentStatus":  currentStatus,
+			"memberIds":      task.Users,
+		},
+		"projectId": task.Project_ID,
+	}
+}
+
+func taskMemberEvent(eventType string, task *models.Task, userID string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    eventType,
+		"version": 1,
+		"time":    eventTime(),
+		"event": map[string]interface{}{
+			"memberId": userID,
+			"taskId":   task.ID,
+		},
+		"projectId": task.Project_ID,
+	}
+}
+
+func documentAddedEvent(task *models.Task, filePaths []string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "Document Added",
+		"version": 2,
+		"time":    eventTime(),
+		"event": map[string]interface{}{
+			"taskId":    task.ID,
+			"projectId": task.Project_ID,
+			"filePaths": filePaths,
+			"memberIds": task.Users,
+		},
+		"projectId": task.Project_ID,
+	}
+}
+
+// taskUpdatedEvent records a single field edit of a task.
+func taskUpdatedEvent(task *models.Task, field string, from, to interface{}, memberID string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "Task Updated",
+		"version": 1,
+		"time":    eventTime(),
+		"event": map[string]interface{}{
+			"taskId":    task.ID,
+			"projectId": task.Project_ID,
+			"changes": []map[string]interface{}{
+				{"field": field, "from": from, "to": to},
+			},
+			"memberId": memberID,
+		},
+		"projectId": task.Project_ID,
+	}
+}
+
+func taskDeletedEvent(deletion *saga.DeletionSaga) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "Task Deleted",
+		"version": 1,
+		"time":    eventTime(),
+		"event": map[string]interface{}{
+			"taskId":    deletion.ResourceID,
+			"projectId": deletion.ProjectID,
+			"memberId":  deletion.RequestedBy,
+		},
+		"projectId": deletion.ProjectID,
+	}
+}
+
+// taskMember opisuje člana zadatka za poruke iz paketa contracts.
+func taskMember(userID string, task *models.Task) contracts.TaskMember {
+	return contracts.TaskMember{UserID: userID, TaskID: task.ID.Hex(), ProjectID: task.Project_ID, TaskName: task.Name}
+}
+
+// taskMembers opisuje zadatak i članove koje poruka obaveštava.
+func taskMembers(task *models.Task, memberIDs []string) contracts.TaskMembers {
+	return contracts.TaskMembers{TaskID: task.ID.Hex(), ProjectID: task.Project_ID, TaskName: task.Name, MemberIDs: memberIDs}
+}
+
+// TaskEventsSubject je NATS subjekat na kom se objavljuju promene zadataka
+// za pravila automatizacije.
+const TaskEventsSubject = "task.events"
+
+// Vrste događaja na task.events.
+const (
+	TaskEventCreated       = "task_created"
+	TaskEventStatusChanged = "status_changed"
+	TaskEventMemberAdded   = "member_added"
+	TaskEventMemberRemoved = "member_removed"
+)
+
+// taskEventMessage pravi poruku za task.events. Status je status zadatka
+// posle promene; ID ostaje isti pri ponovnom slanju iz outbox-a.
+func taskEventMessage(eventType string, task *models.Task, previousStatus, status, userID string) models.TaskEvent {
+	return models.TaskEvent{
+		ID:             primitive.NewObjectID().Hex(),
+		Type:           eventType,
+		TaskID:         task.ID.Hex(),
+		ProjectID:      task.Project_ID,
+		PreviousStatus: previousStatus,
+		Status:         status,
+		UserID:         userID,
+		OccurredAt:     time.Now().UTC(),
+	}
+}
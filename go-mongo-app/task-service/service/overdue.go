@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"log"
+	"task-service/db"
+	"task-service/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetOverdueTasksForUser vraća nezavršene zadatke korisnika kojima je prošao
+// rok. Zadaci bez roka nikad ne kasne.
+func GetOverdueTasksForUser(ctx context.Context, userID string) ([]models.OverdueTask, error) {
+	userID = SanitizeInput(userID)
+
+	// Datumi su u obliku 2006-01-02, pa se porede kao tekst. Članovi se
+	// upisuju i kao "users" i kao "Users", pa se traže oba polja
+	today := time.Now().UTC().Format(dateLayout)
+	filter := bson.M{
+		"status":  bson.M{"$ne": "done"},
+		"dueDate": bson.M{"$gt": "", "$lt": today},
+		"$or":     []bson.M{{"users": userID}, {"Users": userID}},
+	}
+	cursor, err := db.Client.Database("testdb").Collection("tasks").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	// Naziv projekta je samo dopuna, pa nedostupan projekat ne ruši listu
+	titles := map[string]string{}
+	overdue := []models.OverdueTask{}
+	for _, task := range tasks {
+		title, ok := titles[task.Project_ID]
+		if !ok {
+			project, err := fetchProject(task.Project_ID)
+			if err != nil {
+				log.Printf("Overdue: fetching project %s failed: %v", task.Project_ID, err)
+			} else {
+				title = project.Title
+			}
+			titles[task.Project_ID] = title
+		}
+		overdue = append(overdue, models.OverdueTask{
+			TaskID:       task.ID.Hex(),
+			Name:         task.Name,
+			Status:       task.Status,
+			ProjectID:    task.Project_ID,
+			ProjectTitle: title,
+			DueDate:      task.DueDate,
+		})
+	}
+	return overdue, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"contracts"
+	"task-service/db"
+	"task-service/models"
+	"task-service/outbox"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func settingsCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("task_project_settings")
+}
+
+// GetProjectTaskSettings vraća podešavanja projekta; projekat bez sačuvanih
+// podešavanja ima sve isključeno.
+func GetProjectTaskSettings(ctx context.Context, projectID string) (*models.ProjectTaskSettings, error) {
+	settings := models.ProjectTaskSettings{ProjectID: projectID}
+	err := settingsCollection().FindOne(ctx, bson.M{"_id": projectID}).Decode(&settings)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveProjectTaskSettings čuva podešavanja projekta.
+func SaveProjectTaskSettings(ctx context.Context, settings *models.ProjectTaskSettings) error {
+	_, err := settingsCollection().ReplaceOne(ctx, bson.M{"_id": settings.ProjectID}, settings, options.Replace().SetUpsert(true))
+	return err
+}
+
+// propagateDependencyStatus ažurira oznake zadataka koji zavise od task
+// posle promene njegovog statusa, u istoj transakciji kao i promena:
+//   - kada su sve zavisnosti zadatka na čekanju završene, zadatak postaje
+//     ready i njegovi članovi dobijaju notifikaciju;
+//   - kada se završena zavisnost ponovo otvori, zadaci u radu postaju
+//     atRisk, a ready oznaka se skida;
+//   - kada se zavisnost ponovo završi, atRisk se skida ako su sve gotove.
+func propagateDependencyStatus(sessCtx mongo.SessionContext, task *models.Task, previousStatus, status string) error {
+	collection := db.Client.Database("testdb").Collection("tasks")
+
+	finished := status == "done" && previousStatus != "done"
+	reopened := previousStatus == "done" && status != "done"
+	if !finished && !reopened {
+		return nil
+	}
+
+	cursor, err := collection.Find(sessCtx, bson.M{"dependsOn": task.ID, "deleting": bson.M{"$ne": true}})
+	if err != nil {
+		return err
+	}
+	var dependents []models.Task
+	if err := cursor.All(sessCtx, &dependents); err != nil {
+		return err
+	}
+
+	for i := range dependents {
+		dependent := &dependents[i]
+		ready, atRisk := dependent.Ready, dependent.AtRisk
+
+		if finished {
+			// Status task-a je već upisan u ovoj transakciji
+			unfinished, err := collection.CountDocuments(sessCtx, bson.M{"_id": bson.M{"$in": dependent.DependsOn}, "status": bson.M{"$ne": "done"}})
+			if err != nil {
+				return err
+			}
+			if unfinished == 0 {
+				ready = ready || dependent.Status == "pending"
+				atRisk = false
+			}
+		} else {
+			ready = false
+			atRisk = atRisk || dependent.Status == "work in progress"
+		}
+
+		if err := setDependencyFlags(sessCtx, dependent, ready, atRisk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshDependencyFlags ponovo računa oznake zadatka posle izmene njegovih
+// zavisnosti: ready kada zadatak čeka a sve zavisnosti su završene, atRisk
+// kada je zadatak u radu a neka zavisnost nije završena.
+func refreshDependencyFlags(sessCtx mongo.SessionContext, task *models.Task, dependsOn []primitive.ObjectID) error {
+	ready, atRisk := false, false
+	if len(dependsOn) > 0 {
+		collection := db.Client.Database("testdb").Collection("tasks")
+		unfinished, err := collection.CountDocuments(sessCtx, bson.M{"_id": bson.M{"$in": dependsOn}, "status": bson.M{"$ne": "done"}})
+		if err != nil {
+			return err
+		}
+		ready = unfinished == 0 && task.Status == "pending"
+		atRisk = unfinished > 0 && task.Status == "work in progress"
+	}
+	return setDependencyFlags(sessCtx, task, ready, atRisk)
+}
+
+// clearOwnDependencyFlags skida oznake koje više ne važe za novi status
+// zadatka: ready važi samo dok zadatak čeka, a atRisk samo dok je u radu.
+func clearOwnDependencyFlags(sessCtx mongo.SessionContext, task *models.Task, status string) error {
+	ready := task.Ready && status == "pending"
+	atRisk := task.AtRisk && status == "work in progress"
+	return setDependencyFlags(sessCtx, task, ready, atRisk)
+}
+
+// setDependencyFlags upisuje oznake i beleži svaku promenu kao Task Updated
+// događaj. Članovi dobijaju notifikaciju kada zadatak postane ready.
+func setDependencyFlags(sessCtx mongo.SessionContext, task *models.Task, ready, atRisk bool) error {
+	if task.Ready == ready && task.AtRisk == atRisk {
+		return nil
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	var messages []outbox.Message
+	if ready != task.Ready {
+		if ready {
+			set["ready"] = true
+			messages = append(messages, outbox.Contract(&contracts.TaskDependenciesReady{TaskMembers: taskMembers(task, task.Users)}))
+		} else {
+			unset["ready"] = ""
+		}
+		messages = append(messages, outbox.Event(taskUpdatedEvent(task, "ready", task.Ready, ready, automationActor)))
+	}
+	if atRisk != task.AtRisk {
+		if atRisk {
+			set["atRisk"] = true
+		} else {
+			unset["atRisk"] = ""
+		}
+		messages = append(messages, outbox.Event(taskUpdatedEvent(task, "atRisk", task.AtRisk, atRisk, automationActor)))
+	}
+
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	collection := db.Client.Database("testdb").Collection("tasks")
+	if _, err := collection.UpdateOne(sessCtx, bson.M{"_id": task.ID}, update); err != nil {
+		return err
+	}
+	if err := outbox.Enqueue(sessCtx, messages...); err != nil {
+		return err
+	}
+	field := "ready"
+	if ready == task.Ready {
+		field = "atRisk"
+	}
+	return enqueueBoardEvent(sessCtx, BoardTaskUpdated, task.Project_ID, task.ID, field, automationActor)
+}
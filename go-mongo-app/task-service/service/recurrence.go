@@ -0,0 +1,210 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"task-service/models"
+	"time"
+)
+
+// Učestalosti pravila ponavljanja.
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+// maxRecurrenceInterval je najveći dozvoljeni INTERVAL.
+const maxRecurrenceInterval = 366
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRRule čita pravilo zapisano kao RRULE, npr. "FREQ=WEEKLY;BYDAY=MO,TH".
+func ParseRRule(rrule string) (models.RecurrenceRule, error) {
+	var rule models.RecurrenceRule
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return rule, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+		case "BYDAY":
+			rule.ByDay = strings.Split(strings.ToUpper(value), ",")
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = strconv.Atoi(value)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+		case "UNTIL":
+			var until time.Time
+			until, err = time.Parse("20060102T150405Z", value)
+			if err != nil {
+				until, err = time.Parse("20060102", value)
+			}
+			rule.Until = &until
+		default:
+			return rule, fmt.Errorf("unsupported RRULE part %s", key)
+		}
+		if err != nil {
+			return rule, fmt.Errorf("invalid RRULE %s: %v", key, err)
+		}
+	}
+	return rule, ValidateRecurrenceRule(&rule)
+}
+
+// ValidateRecurrenceRule proverava pravilo i postavlja podrazumevani interval.
+func ValidateRecurrenceRule(rule *models.RecurrenceRule) error {
+	switch rule.Freq {
+	case FreqDaily, FreqWeekly, FreqMonthly:
+	default:
+		return fmt.Errorf("freq must be DAILY, WEEKLY or MONTHLY, got %q", rule.Freq)
+	}
+	if rule.Interval < 0 || rule.Count < 0 {
+		return errors.New("interval and count cannot be negative")
+	}
+	if rule.Interval > maxRecurrenceInterval {
+		return fmt.Errorf("interval cannot be greater than %d", maxRecurrenceInterval)
+	}
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if len(rule.ByDay) > 0 && rule.Freq != FreqWeekly {
+		return errors.New("byDay is only supported with WEEKLY")
+	}
+	for _, day := range rule.ByDay {
+		if _, ok := weekdays[day]; !ok {
+			return fmt.Errorf("invalid weekday %q, expected MO, TU, WE, TH, FR, SA or SU", day)
+		}
+	}
+	if rule.ByMonthDay != 0 && rule.Freq != FreqMonthly {
+		return errors.New("byMonthDay is only supported with MONTHLY")
+	}
+	if rule.ByMonthDay < -31 || rule.ByMonthDay > 31 {
+		return errors.New("byMonthDay must be between -31 and 31")
+	}
+	return nil
+}
+
+// maxRecurrenceSearch ograničava koliko perioda (dana, nedelja ili meseci
+// po intervalu) unapred se traži sledeće ponavljanje. Mesečno pravilo za dan
+// koji ne postoji ni u jednom od tih meseci nema ponavljanja.
+const maxRecurrenceSearch = 48
+
+// nextOccurrence vraća prvo ponavljanje posle after. Ponavljanja su u vreme
+// dana iz start i nikad pre njega. Vraća false ako ponavljanja više nema.
+// Broj ponavljanja (COUNT) proverava pozivalac.
+func nextOccurrence(rule models.RecurrenceRule, start, after time.Time) (time.Time, bool) {
+	start = start.UTC()
+	after = after.UTC()
+	if after.Before(start) {
+		after = start.Add(-time.Nanosecond)
+	}
+	interval := rule.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	// Traži se od perioda u kome je after, i to samo u periodima pravila
+	first := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, time.UTC)
+	var periodStart func(n int) time.Time
+	var periodDays func(from time.Time) int
+	var elapsed int
+	switch rule.Freq {
+	case FreqDaily:
+		periodStart = func(n int) time.Time { return first.AddDate(0, 0, n) }
+		periodDays = func(time.Time) int { return 1 }
+		elapsed = daysBetween(first, after)
+	case FreqWeekly:
+		monday := mondayOf(first)
+		periodStart = func(n int) time.Time { return monday.AddDate(0, 0, 7*n) }
+		periodDays = func(time.Time) int { return 7 }
+		elapsed = daysBetween(monday, after) / 7
+	case FreqMonthly:
+		month := time.Date(first.Year(), first.Month(), 1, first.Hour(), first.Minute(), first.Second(), 0, time.UTC)
+		periodStart = func(n int) time.Time { return month.AddDate(0, n, 0) }
+		periodDays = func(from time.Time) int { return from.AddDate(0, 1, -1).Day() }
+		elapsed = (after.Year()-first.Year())*12 + int(after.Month()) - int(first.Month())
+	default:
+		return time.Time{}, false
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	for n := elapsed - elapsed%interval; n <= elapsed+maxRecurrenceSearch*interval; n += interval {
+		from := periodStart(n)
+		for i := 0; i < periodDays(from); i++ {
+			candidate := from.AddDate(0, 0, i)
+			if rule.Until != nil && candidate.After(*rule.Until) {
+				return time.Time{}, false
+			}
+			if candidate.After(after) && occursOn(rule, interval, start, candidate) {
+				return candidate, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// occursOn proverava da li pravilo pada na dan candidate.
+func occursOn(rule models.RecurrenceRule, interval int, start, candidate time.Time) bool {
+	switch rule.Freq {
+	case FreqDaily:
+		return daysBetween(start, candidate)%interval == 0
+	case FreqWeekly:
+		if len(rule.ByDay) == 0 {
+			if candidate.Weekday() != start.Weekday() {
+				return false
+			}
+		} else {
+			found := false
+			for _, day := range rule.ByDay {
+				found = found || weekdays[day] == candidate.Weekday()
+			}
+			if !found {
+				return false
+			}
+		}
+		// Nedelje počinju ponedeljkom, kao podrazumevani WKST
+		return (daysBetween(mondayOf(start), mondayOf(candidate))/7)%interval == 0
+	case FreqMonthly:
+		months := (candidate.Year()-start.Year())*12 + int(candidate.Month()) - int(start.Month())
+		if months%interval != 0 {
+			return false
+		}
+		target := rule.ByMonthDay
+		if target == 0 {
+			target = start.Day()
+		}
+		lastDay := time.Date(candidate.Year(), candidate.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		if target < 0 {
+			target = lastDay + target + 1
+		}
+		// Mesec bez tog dana se preskače, kao u RFC 5545
+		return candidate.Day() == target
+	}
+	return false
+}
+
+func daysBetween(a, b time.Time) int {
+	dayA := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	dayB := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(dayB.Sub(dayA).Hours() / 24)
+}
+
+func mondayOf(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
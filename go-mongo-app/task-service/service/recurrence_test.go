@@ -0,0 +1,145 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecurrenceExpansion(t *testing.T) {
+	const layout = "2006-01-02 15:04"
+	tests := []struct {
+		name  string
+		rrule string
+		start string
+		after string // prazno: od početka serije
+		n     int
+		want  []string
+	}{
+		{
+			name:  "daily every other day",
+			rrule: "FREQ=DAILY;INTERVAL=2",
+			start: "2024-01-30 09:00",
+			n:     3,
+			want:  []string{"2024-01-30 09:00", "2024-02-01 09:00", "2024-02-03 09:00"},
+		},
+		{
+			name:  "daily resumes in step with the start",
+			rrule: "FREQ=DAILY;INTERVAL=3",
+			start: "2024-01-01 09:00",
+			after: "2024-03-01 12:00",
+			n:     2,
+			want:  []string{"2024-03-04 09:00", "2024-03-07 09:00"},
+		},
+		{
+			name:  "weekly on the start weekday",
+			rrule: "FREQ=WEEKLY",
+			start: "2024-01-03 10:00",
+			n:     3,
+			want:  []string{"2024-01-03 10:00", "2024-01-10 10:00", "2024-01-17 10:00"},
+		},
+		{
+			name:  "weekly by day never before start",
+			rrule: "RRULE:FREQ=WEEKLY;BYDAY=MO,TH",
+			start: "2024-01-03 10:00",
+			n:     4,
+			want:  []string{"2024-01-04 10:00", "2024-01-08 10:00", "2024-01-11 10:00", "2024-01-15 10:00"},
+		},
+		{
+			name:  "every second week by day",
+			rrule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			start: "2024-01-01 08:30",
+			n:     4,
+			want:  []string{"2024-01-01 08:30", "2024-01-05 08:30", "2024-01-15 08:30", "2024-01-19 08:30"},
+		},
+		{
+			name:  "monthly skips months without the day",
+			rrule: "FREQ=MONTHLY",
+			start: "2024-01-31 09:00",
+			n:     4,
+			want:  []string{"2024-01-31 09:00", "2024-03-31 09:00", "2024-05-31 09:00", "2024-07-31 09:00"},
+		},
+		{
+			name:  "monthly on the last day",
+			rrule: "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start: "2024-01-15 09:00",
+			n:     3,
+			want:  []string{"2024-01-31 09:00", "2024-02-29 09:00", "2024-03-31 09:00"},
+		},
+		{
+			name:  "quarterly",
+			rrule: "FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=15",
+			start: "2024-01-15 09:00",
+			n:     3,
+			want:  []string{"2024-01-15 09:00", "2024-04-15 09:00", "2024-07-15 09:00"},
+		},
+		{
+			name:  "until stops the series",
+			rrule: "FREQ=DAILY;UNTIL=20240105",
+			start: "2024-01-01 09:00",
+			n:     10,
+			want:  []string{"2024-01-01 09:00", "2024-01-02 09:00", "2024-01-03 09:00", "2024-01-04 09:00"},
+		},
+		{
+			name:  "day that never occurs",
+			rrule: "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30",
+			start: "2024-02-10 09:00",
+			n:     1,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRRule(tt.rrule)
+			if err != nil {
+				t.Fatalf("ParseRRule(%q): %v", tt.rrule, err)
+			}
+			start, _ := time.Parse(layout, tt.start)
+			after := start.Add(-time.Nanosecond)
+			if tt.after != "" {
+				after, _ = time.Parse(layout, tt.after)
+			}
+
+			got := []string{}
+			for len(got) < tt.n {
+				next, ok := nextOccurrence(rule, start, after)
+				if !ok {
+					break
+				}
+				got = append(got, next.Format(layout))
+				after = next
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("occurrences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rrule string
+	}{
+		{"missing freq", "INTERVAL=2"},
+		{"unknown freq", "FREQ=YEARLY"},
+		{"negative interval", "FREQ=DAILY;INTERVAL=-1"},
+		{"interval too large", "FREQ=DAILY;INTERVAL=367"},
+		{"by day without weekly", "FREQ=DAILY;BYDAY=MO"},
+		{"invalid weekday", "FREQ=WEEKLY;BYDAY=XX"},
+		{"by month day without monthly", "FREQ=WEEKLY;BYMONTHDAY=3"},
+		{"by month day out of range", "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{"unsupported part", "FREQ=DAILY;BYHOUR=9"},
+		{"part without value", "FREQ=DAILY;COUNT"},
+		{"invalid until", "FREQ=DAILY;UNTIL=tomorrow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseRRule(tt.rrule); err == nil {
+				t.Errorf("ParseRRule(%q) succeeded, expected an error", tt.rrule)
+			}
+		})
+	}
+}
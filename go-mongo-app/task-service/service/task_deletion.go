@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"shared/saga"
+	"shared/security"
+	"strings"
+	"task-service/db"
+	"task-service/models"
+	"task-service/outbox"
+	"time"
+
+	"github.com/colinmarc/hdfs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrTaskNotFound     = errors.New("task not found")
+	ErrTaskBeingDeleted = errors.New("task is being deleted")
+	taskDeletionSteps   = []string{"mark_deleting", "delete_workflow", "delete_files", "unlink_from_project", "delete_task"}
+	deletionActions     = map[string]saga.Action{
+		// Dok je zadatak samo označen, brisanje može da se poništi
+		"mark_deleting":       {Run: markTaskDeleting, Compensate: unmarkTaskDeleting},
+		"delete_workflow":     {Run: deleteTaskWorkflow},
+		"delete_files":        {Run: deleteTaskFiles},
+		"unlink_from_project": {Run: unlinkTaskFromProject},
+		"delete_task":         {Run: deleteTaskDocument},
+	}
+)
+
+// StartTaskDeletion pokreće sagu brisanja zadatka, ili vraća sagu koja ga već briše.
+func StartTaskDeletion(ctx context.Context, taskID string, requestedBy string) (*saga.DeletionSaga, error) {
+	objID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid taskID format: %v", err)
+	}
+
+	collection := db.Client.Database("testdb").Collection("tasks")
+	var task models.Task
+	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		// Saga koja je upravo obrisala dokument možda još nije zatvorena
+		if deletion, err := sagas.Active(ctx, taskID); err == nil {
+			return deletion, nil
+		}
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not find task with ID %v: %v", taskID, err)
+	}
+
+	return sagas.Start(ctx, saga.NewDeletion(taskID, task.Project_ID, requestedBy, taskDeletionSteps...))
+}
+
+func markTaskDeleting(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	return setTaskDeleting(ctx, deletion.ResourceID, true)
+}
+
+func unmarkTaskDeleting(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	return setTaskDeleting(ctx, deletion.ResourceID, false)
+}
+
+func setTaskDeleting(ctx context.Context, taskID string, deleting bool) error {
+	objID, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid taskID format: %v", saga.ErrPermanent, err)
+	}
+
+	update := bson.M{"$set": bson.M{"deleting": true}}
+	if !deleting {
+		update = bson.M{"$unset": bson.M{"deleting": ""}}
+	}
+	_, err = db.Client.Database("testdb").Collection("tasks").UpdateOne(ctx, bson.M{"_id": objID}, update)
+	return err
+}
+
+// deleteTaskWorkflow briše čvor zadatka i njegove zavisnosti u workflow-service.
+func deleteTaskWorkflow(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	url := fmt.Sprintf("http://workflow-service:8080/workflow/delete/%s", deletion.ResourceID)
+	return callServiceStep(ctx, "DELETE", url)
+}
+
+// deleteTaskFiles briše direktorijum sa dokumentima zadatka na HDFS-u.
+func deleteTaskFiles(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	hdfsNamenodeAddress := os.Getenv("HDFS_NAMENODE_ADDRESS")
+	if hdfsNamenodeAddress == "" {
+		return fmt.Errorf("HDFS_NAMENODE_ADDRESS is not set in .env file")
+	}
+
+	client, err := hdfs.NewClient(hdfs.ClientOptions{
+		Addresses: []string{hdfsNamenodeAddress},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to connect to HDFS: %v", err)
+	}
+	defer client.Close()
+
+	err = client.Remove(fmt.Sprintf("/user/hdfs/tasks/%s", deletion.ResourceID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete task files: %v", err)
+	}
+	return nil
+}
+
+// unlinkTaskFromProject uklanja zadatak iz liste zadataka projekta.
+func unlinkTaskFromProject(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	if deletion.ProjectID == "" {
+		return nil
+	}
+	url := fmt.Sprintf("http://project-service:8080/projects/%s/tasks/%s", deletion.ProjectID, deletion.ResourceID)
+	return callServiceStep(ctx, "DELETE", url)
+}
+
+// deleteTaskDocument briše zadatak i uklanja ga iz zavisnosti ostalih zadataka.
+func deleteTaskDocument(ctx context.Context, deletion *saga.DeletionSaga, _ string) error {
+	objID, err := primitive.ObjectIDFromHex(deletion.ResourceID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid taskID format: %v", saga.ErrPermanent, err)
+	}
+	collection := db.Client.Database("testdb").Collection("tasks")
+
+	return db.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		result, err := collection.DeleteOne(sessCtx, bson.M{"_id": objID})
+		if err != nil {
+			return fmt.Errorf("failed to delete task: %v", err)
+		}
+		if result.DeletedCount == 0 {
+			// Već obrisano u prethodnom pokušaju
+			return nil
+		}
+
+		_, err = collection.UpdateMany(sessCtx,
+			bson.M{"dependsOn": objID},
+			bson.M{"$pull": bson.M{"dependsOn": objID}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to remove task from dependencies: %v", err)
+		}
+
+		if err := outbox.Enqueue(sessCtx, outbox.Event(taskDeletedEvent(deletion))); err != nil {
+			return err
+		}
+		return enqueueBoardEvent(sessCtx, BoardTaskDeleted, deletion.ProjectID, objID, "", deletion.RequestedBy)
+	})
+}
+
+// callServiceStep poziva drugi servis u ime sage. Ne postojeći resurs se
+// smatra već obrisanim, pa se korak može bezbedno ponavljati.
+func callServiceStep(ctx context.Context, method, url string) error {
+	token, err := security.ServiceToken("task-service")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	message := strings.TrimSpace(string(body))
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	case http.StatusAccepted:
+		return saga.ErrInProgress
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusConflict:
+		return fmt.Errorf("%w: %s %s: %s", saga.ErrPermanent, resp.Status, url, message)
+	default:
+		return fmt.Errorf("%s %s: %s", resp.Status, url, message)
+	}
+}
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"shared/security"
+	"task-service/db"
+	"task-service/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrSeriesNotFound = errors.New("task series not found")
+	ErrSeriesInactive = errors.New("task series is not active")
+)
+
+const (
+	seriesInterval = time.Minute
+	seriesLease    = 2 * time.Minute
+)
+
+func seriesCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("task_series")
+}
+
+// EnsureSeriesIndexes pravi indekse koje koristi planer serija.
+func EnsureSeriesIndexes(ctx context.Context) error {
+	if _, err := seriesCollection().Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "mode", Value: 1}, {Key: "nextRunAt", Value: 1}},
+	}); err != nil {
+		return err
+	}
+	_, err := db.Client.Database("testdb").Collection("tasks").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "seriesId", Value: 1}, {Key: "occurrenceAt", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"seriesId": bson.M{"$exists": true}}),
+	})
+	return err
+}
+
+// ValidateTaskSeries proverava šablon, način i pravilo serije.
+func ValidateTaskSeries(series *models.TaskSeries) error {
+	if series.Name == "" {
+		return errors.New("series name cannot be empty")
+	}
+	if series.Mode == "" {
+		series.Mode = models.SeriesOnSchedule
+	}
+	if series.Mode != models.SeriesOnSchedule && series.Mode != models.SeriesAfterDone {
+		return fmt.Errorf("mode must be %s or %s", models.SeriesOnSchedule, models.SeriesAfterDone)
+	}
+	return ValidateRecurrenceRule(&series.Rule)
+}
+
+// CreateTaskSeries čuva novu seriju. Prvi zadatak pravi planer.
+func CreateTaskSeries(ctx context.Context, series *models.TaskSeries) error {
+	if err := ValidateTaskSeries(series); err != nil {
+		return err
+	}
+	if _, err := primitive.ObjectIDFromHex(series.ProjectID); err != nil {
+		return errors.New("invalid project ID format")
+	}
+	series.ID = primitive.NewObjectID()
+	series.Status = models.SeriesActive
+	series.CreatedAt = time.Now()
+	if series.StartAt.IsZero() {
+		series.StartAt = series.CreatedAt
+	}
+	series.StartAt = series.StartAt.UTC()
+	series.Occurrences = 0
+
+	next, ok := nextOccurrence(series.Rule, series.StartAt, series.StartAt.Add(-time.Nanosecond))
+	if !ok {
+		return errors.New("recurrence rule has no occurrences")
+	}
+	series.NextRunAt = &next
+
+	_, err := seriesCollection().InsertOne(ctx, series)
+	return err
+}
+
+// GetTaskSeries vraća serije projekta.
+func GetTaskSeries(ctx context.Context, projectID string) ([]models.TaskSeries, error) {
+	cursor, err := seriesCollection().Find(ctx, bson.M{"projectId": projectID}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	series := []models.TaskSeries{}
+	if err := cursor.All(ctx, &series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// GetTaskSeriesByID vraća jednu seriju.
+func GetTaskSeriesByID(ctx context.Context, seriesID string) (*models.TaskSeries, error) {
+	return getTaskSeries(ctx, seriesID)
+}
+
+func getTaskSeries(ctx context.Context, seriesID string) (*models.TaskSeries, error) {
+	id, err := primitive.ObjectIDFromHex(seriesID)
+	if err != nil {
+		return nil, ErrSeriesNotFound
+	}
+	var series models.TaskSeries
+	err = seriesCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&series)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrSeriesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// UpdateTaskSeries menja šablon, način i pravilo aktivne serije. Već
+// napravljeni zadaci se ne menjaju, a sledeće ponavljanje se računa od
+// kasnijeg od poslednjeg ponavljanja i sadašnjeg trenutka.
+func UpdateTaskSeries(ctx context.Context, seriesID string, changes *models.TaskSeries) (*models.TaskSeries, error) {
+	if err := ValidateTaskSeries(changes); err != nil {
+		return nil, err
+	}
+	series, err := getTaskSeries(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != models.SeriesActive {
+		return nil, ErrSeriesInactive
+	}
+
+	series.Name = changes.Name
+	series.Description = changes.Description
+	series.Mode = changes.Mode
+	series.Rule = changes.Rule
+	if !changes.StartAt.IsZero() {
+		series.StartAt = changes.StartAt.UTC()
+	}
+
+	after := time.Now()
+	if series.LastOccurrenceAt != nil && series.LastOccurrenceAt.After(after) {
+		after = *series.LastOccurrenceAt
+	}
+	next, ok := nextOccurrence(series.Rule, series.StartAt, after)
+	if ok {
+		series.NextRunAt = &next
+	} else {
+		series.NextRunAt = nil
+		series.Status = models.SeriesCompleted
+	}
+
+	_, err = seriesCollection().UpdateOne(ctx, bson.M{"_id": series.ID, "status": models.SeriesActive}, bson.M{"$set": bson.M{
+		"name":        series.Name,
+		"description": series.Description,
+		"mode":        series.Mode,
+		"rule":        series.Rule,
+		"startAt":     series.StartAt,
+		"nextRunAt":   series.NextRunAt,
+		"status":      series.Status,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// StopTaskSeries zaustavlja seriju; napravljeni zadaci ostaju.
+func StopTaskSeries(ctx context.Context, seriesID string) (*models.TaskSeries, error) {
+	series, err := getTaskSeries(ctx, seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != models.SeriesActive {
+		return nil, ErrSeriesInactive
+	}
+	_, err = seriesCollection().UpdateOne(ctx, bson.M{"_id": series.ID}, bson.M{
+		"$set":   bson.M{"status": models.SeriesStopped},
+		"$unset": bson.M{"nextRunAt": ""},
+	})
+	if err != nil {
+		return nil, err
+	}
+	series.Status = models.SeriesStopped
+	series.NextRunAt = nil
+	return series, nil
+}
+
+// RunSeriesScheduler pravi zadatke serija dok se ctx ne otkaže. Serija
+// on_schedule pravi zadatak kada dođe nextRunAt; propuštena ponavljanja, npr.
+// dok servis nije radio, se preskaču i pravi se samo poslednje, a preskočena
+// se računaju u COUNT. Serija after_done pravi prvi zadatak u startAt, a
+// svaki sledeći kada je prethodni završen ili obrisan.
+func RunSeriesScheduler(ctx context.Context, logger *log.Logger) {
+	ticker := time.NewTicker(seriesInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			series, err := claimDueSeries(ctx)
+			if err != nil {
+				logger.Printf("Series: claiming due series failed: %v", err)
+				break
+			}
+			if series == nil {
+				break
+			}
+			if err := materializeSeries(ctx, series); err != nil {
+				logger.Printf("Series: creating task for series %s failed: %v", series.ID.Hex(), err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// claimDueSeries zakupljuje jednu seriju kojoj je vreme za novi zadatak.
+func claimDueSeries(ctx context.Context) (*models.TaskSeries, error) {
+	now := time.Now()
+	notLocked := bson.M{"$or": []bson.M{{"lockedUntil": bson.M{"$lt": now}}, {"lockedUntil": bson.M{"$exists": false}}}}
+
+	var series models.TaskSeries
+	err := seriesCollection().FindOneAndUpdate(ctx,
+		bson.M{"$and": []bson.M{
+			{"status": models.SeriesActive, "mode": models.SeriesOnSchedule, "nextRunAt": bson.M{"$lte": now}},
+			notLocked,
+		}},
+		bson.M{"$set": bson.M{"lockedUntil": now.Add(seriesLease)}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&series)
+	if err == nil {
+		return &series, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	// Serije after_done se proveravaju jedna po jedna po poslednjem zadatku
+	cursor, err := seriesCollection().Find(ctx, bson.M{"$and": []bson.M{
+		{"status": models.SeriesActive, "mode": models.SeriesAfterDone},
+		{"$or": []bson.M{{"lastTaskId": bson.M{"$exists": true}}, {"nextRunAt": bson.M{"$lte": now}}}},
+		notLocked,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	var candidates []models.TaskSeries
+	if err := cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		done, err := previousOccurrenceDone(ctx, &candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			continue
+		}
+		err = seriesCollection().FindOneAndUpdate(ctx,
+			bson.M{"_id": candidate.ID, "lockedUntil": candidate.LockedUntil},
+			bson.M{"$set": bson.M{"lockedUntil": now.Add(seriesLease)}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&series)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &series, nil
+	}
+	return nil, nil
+}
+
+func previousOccurrenceDone(ctx context.Context, series *models.TaskSeries) (bool, error) {
+	if series.LastTaskID == "" {
+		return true, nil
+	}
+	taskID, err := primitive.ObjectIDFromHex(series.LastTaskID)
+	if err != nil {
+		return true, nil
+	}
+	var task models.Task
+	err = db.Client.Database("testdb").Collection("tasks").FindOne(ctx, bson.M{"_id": taskID}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return task.Status == "done", nil
+}
+
+// materializeSeries pravi zadatak za sledeće ponavljanje i pomera seriju na
+// ponavljanje posle njega. Zadatak sa istim seriesId i occurrenceAt se ne
+// pravi ponovo, pa prekinut pokušaj može bezbedno da se ponovi.
+func materializeSeries(ctx context.Context, series *models.TaskSeries) error {
+	now := time.Now().UTC()
+	occurrence := now
+	if series.NextRunAt != nil {
+		occurrence = series.NextRunAt.UTC()
+	}
+	skipped := 0
+	if series.Mode == models.SeriesOnSchedule {
+		// Preskoči propuštena ponavljanja do poslednjeg koje je već došlo, ali
+		// ne preko broja ponavljanja iz pravila
+		for series.Rule.Count == 0 || series.Occurrences+skipped+1 < series.Rule.Count {
+			next, ok := nextOccurrence(series.Rule, series.StartAt, occurrence)
+			if !ok || next.After(now) {
+				break
+			}
+			occurrence = next
+			skipped++
+		}
+	} else if occurrence.Before(now) && series.LastOccurrenceAt != nil {
+		// Posle završetka prethodnog zadatka sledi prvo ponavljanje od sada
+		next, ok := nextOccurrence(series.Rule, series.StartAt, now)
+		if !ok {
+			return completeSeries(ctx, series)
+		}
+		occurrence = next
+	}
+
+	tasks := db.Client.Database("testdb").Collection("tasks")
+	var task models.Task
+	err := tasks.FindOne(ctx, bson.M{"seriesId": series.ID.Hex(), "occurrenceAt": occurrence}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		token, err := security.ServiceToken("task-service")
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s %s", series.Name, occurrence.Format("2006-01-02"))
+		created, err := createTask(series.ProjectID, name, series.Description, nil, token, func(t *models.Task) {
+			t.SeriesID = series.ID.Hex()
+			t.OccurrenceAt = &occurrence
+		})
+		if err != nil {
+			return err
+		}
+		task = *created
+	} else if err != nil {
+		return err
+	}
+
+	occurrences := series.Occurrences + skipped + 1
+	set := bson.M{
+		"lastTaskId":       task.ID.Hex(),
+		"lastOccurrenceAt": occurrence,
+		"occurrences":      occurrences,
+		"skipped":          series.Skipped + skipped,
+		"lockedUntil":      time.Time{},
+	}
+	next, ok := nextOccurrence(series.Rule, series.StartAt, occurrence)
+	if !ok || (series.Rule.Count > 0 && occurrences >= series.Rule.Count) {
+		set["status"] = models.SeriesCompleted
+		set["nextRunAt"] = nil
+	} else {
+		set["nextRunAt"] = next
+	}
+	_, err = seriesCollection().UpdateOne(ctx, bson.M{"_id": series.ID}, bson.M{"$set": set})
+	return err
+}
+
+func completeSeries(ctx context.Context, series *models.TaskSeries) error {
+	_, err := seriesCollection().UpdateOne(ctx, bson.M{"_id": series.ID}, bson.M{"$set": bson.M{
+		"status":      models.SeriesCompleted,
+		"nextRunAt":   nil,
+		"lockedUntil": time.Time{},
+	}})
+	return err
+}
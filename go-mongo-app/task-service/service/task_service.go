@@ -3,6 +3,7 @@ package service
 import (
 	"bytes"
 	"context"
+	"contracts"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,6 +25,7 @@ import (
 	"strings"
 	"task-service/db"
 	"task-service/models"
+	"task-service/outbox"
 	"time"
 )
 
@@ -44,7 +46,12 @@ func EscapeHTML(input string) string {
 	return strings.ReplaceAll(input, "<", "&lt;")
 }
 
-// UpdateTaskStatus ažurira status zadatka u bazi podataka
+// ErrTaskStatusConflict znači da je status zadatka promenjen između čitanja i upisa.
+var ErrTaskStatusConflict = errors.New("task status was changed by another request")
+
+// UpdateTaskStatus ažurira status zadatka u bazi podataka. Upis uspeva samo ako
+// je status i dalje onaj koji je pročitan, pa se provera zavisnosti i poruke o
+// promeni odnose na stvarni prethodni status.
 func UpdateTaskStatus(taskID, status string, token string) (*models.Task, error) {
 	// Validacija i konverzija taskID-a u ObjectID
 	taskObjectID, err := primitive.ObjectIDFromHex(taskID)
@@ -74,6 +81,9 @@ func UpdateTaskStatus(taskID, status string, token string) (*models.Task, error)
 		}
 		return nil, fmt.Errorf("error finding task: %w", err)
 	}
+	if task.Deleting {
+		return nil, ErrTaskBeingDeleted
+	}
 
 	// Provera zavisnosti
 	dependencies, err := GetDependenciesFromWorkflowService(taskID, token)
@@ -81,59 +91,95 @@ func UpdateTaskStatus(taskID, status string, token string) (*models.Task, error)
 		return nil, fmt.Errorf("error fetching dependencies: %w", err)
 	}
 
-	// Provera statusa zavisnih zadataka
-	for _, dependencyTaskID := range dependencies.DependencyTasks {
-		depTaskObjectID, err := primitive.ObjectIDFromHex(dependencyTaskID)
+	// Provera statusa zavisnih zadataka prema vrsti zavisnosti
+	now := time.Now()
+	for _, link := range dependencies.Links() {
+		depTaskObjectID, err := primitive.ObjectIDFromHex(link.TaskID)
 		if err != nil {
-			return nil, fmt.Errorf("invalid dependency task ID: %s", dependencyTaskID)
+			return nil, fmt.Errorf("invalid dependency task ID: %s", link.TaskID)
 		}
 
 		var dependentTask models.Task
 		err = collection.FindOne(context.TODO(), bson.M{"_id": depTaskObjectID}).Decode(&dependentTask)
 		if err != nil {
 			if err == mongo.ErrNoDocuments {
-				return nil, fmt.Errorf("dependency task not found: %s", dependencyTaskID)
+				return nil, fmt.Errorf("dependency task not found: %s", link.TaskID)
 			}
 			return nil, fmt.Errorf("error fetching dependency task: %w", err)
 		}
 
-		// Ako je neki zavisni zadatak "pending", ne možeš promeniti status osim ako ne vraćaš u "pending"
-		if dependentTask.Status == "pending" && status != "pending" {
-			return nil, fmt.Errorf("cannot change status: dependency task %s is pending", dependentTask.ID.Hex())
+		if err := checkDependencyLink(link, &dependentTask, status, now); err != nil {
+			return nil, err
 		}
+	}
 
-		// Ako je neki zavisni zadatak u "work in progress", možeš preći samo u "work in progress" ili "pending"
-		if dependentTask.Status == "work in progress" {
-			if status != "work in progress" && status != "pending" {
-				return nil, fmt.Errorf("cannot change status: dependency task %s is in progress", dependentTask.ID.Hex())
-			}
-		}
+	// Početak i završetak se pamte zbog kašnjenja (lag) zavisnih zadataka
+	set := bson.M{"status": status}
+	unset := bson.M{}
+	if status == "pending" {
+		unset["startedAt"] = ""
+	} else if task.StartedAt == nil {
+		set["startedAt"] = now
+	}
+	if status != "done" {
+		unset["finishedAt"] = ""
+	} else if task.Status != "done" {
+		set["finishedAt"] = now
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
 
-		// Ako su svi zavisni zadaci u "done", možeš preći u bilo koji status
-		if status == "done" && dependentTask.Status != "done" {
-			return nil, fmt.Errorf("cannot change status to 'done': dependency task %s is not done", dependentTask.ID.Hex())
-		}
+	// Oznake zavisnih zadataka se menjaju samo u projektima koji su ih uključili
+	settings, err := GetProjectTaskSettings(context.TODO(), task.Project_ID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching project settings: %w", err)
 	}
 
-	// Ažuriranje statusa zadatka u bazi
-	updateResult, err := collection.UpdateOne(
-		context.TODO(),
-		bson.M{"_id": taskObjectID},
-		bson.M{"$set": bson.M{"status": status}},
-	)
+	// Ažuriranje statusa zadatka u bazi, zajedno sa porukama za analytics, event store i NATS
+	previousStatus := task.Status
+	err = db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		updateResult, err := collection.UpdateOne(
+			sessCtx,
+			bson.M{"_id": taskObjectID, "status": previousStatus},
+			update,
+		)
+		if err != nil {
+			return fmt.Errorf("error updating task status: %w", err)
+		}
+		if updateResult.MatchedCount == 0 {
+			return ErrTaskStatusConflict
+		}
 
-	sendToAnalyticsService(map[string]interface{}{
-		"task_id":         taskID,
-		"previous_status": task.Status,
-		"new_status":      status,
-		"timestamp":       time.Now().UTC().Format(time.RFC3339),
-	}, token)
+		err = outbox.Enqueue(sessCtx,
+			outbox.Analytics(map[string]interface{}{
+				"task_id":         taskID,
+				"previous_status": previousStatus,
+				"new_status":      status,
+				"timestamp":       time.Now().UTC().Format(time.RFC3339),
+			}),
+			outbox.Event(taskStatusChangedEvent(&task, previousStatus, status)),
+			outbox.Contract(&contracts.TaskStatusChanged{TaskMembers: taskMembers(&task, task.Users), TaskStatus: status}),
+			outbox.NATS(TaskEventsSubject, taskEventMessage(TaskEventStatusChanged, &task, previousStatus, status, "")),
+		)
+		if err != nil {
+			return err
+		}
 
+		if err := clearOwnDependencyFlags(sessCtx, &task, status); err != nil {
+			return err
+		}
+		if err := enqueueBoardEvent(sessCtx, BoardTaskUpdated, task.Project_ID, taskObjectID, "status", ""); err != nil {
+			return err
+		}
+		if !settings.DependencyPropagation {
+			return nil
+		}
+		return propagateDependencyStatus(sessCtx, &task, previousStatus, status)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error updating task status: %w", err)
-	}
-	if updateResult.MatchedCount == 0 {
-		return nil, errors.New("task not found during update")
+		return nil, err
 	}
 
 	// Ponovno čitanje ažuriranog zadatka
@@ -267,7 +313,53 @@ func SanitizeInput(input string) string {
 	return input
 }
 
-func CreateTask(projectID, name, description string, dependsOn []string, token string) (*models.Task, error) {
+func CreateTask(projectID, name, description, dueDate string, dependsOn []string, token string) (*models.Task, error) {
+	if err := validateDueDate(dueDate); err != nil {
+		return nil, err
+	}
+	return createTask(projectID, name, description, dependsOn, token, func(t *models.Task) {
+		t.DueDate = dueDate
+	})
+}
+
+// validateDueDate proverava rok zadatka; prazan rok znači da zadatak nema rok.
+func validateDueDate(dueDate string) error {
+	if dueDate == "" {
+		return nil
+	}
+	if _, err := time.Parse(dateLayout, dueDate); err != nil {
+		return errors.New("dueDate must be in the format YYYY-MM-DD")
+	}
+	return nil
+}
+
+// SetTaskDueDate postavlja rok zadatka, ili ga briše kada je dueDate prazan.
+func SetTaskDueDate(ctx context.Context, taskID, dueDate string) (*models.Task, error) {
+	if err := validateDueDate(dueDate); err != nil {
+		return nil, err
+	}
+	id, err := primitive.ObjectIDFromHex(taskID)
+	if err != nil {
+		return nil, errors.New("invalid task ID format")
+	}
+	update := bson.M{"$set": bson.M{"dueDate": dueDate}}
+	if dueDate == "" {
+		update = bson.M{"$unset": bson.M{"dueDate": ""}}
+	}
+	var task models.Task
+	err = db.Client.Database("testdb").Collection("tasks").FindOneAndUpdate(ctx, bson.M{"_id": id}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("task not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// createTask pravi zadatak; prepare, ako je zadat, dopunjuje zadatak pre upisa.
+func createTask(projectID, name, description string, dependsOn []string, token string, prepare func(*models.Task)) (*models.Task, error) {
 	// Sanitize inputs
 	projectID = SanitizeInput(projectID)
 	name = SanitizeInput(name)
@@ -336,11 +428,10 @@ func CreateTask(projectID, name, description string, dependsOn []string, token s
 		FilePaths:   []string{},
 		Position:    position, // Dodato position polje
 	}
-
-	// Insert the new task into the database
-	_, err = collection.InsertOne(context.TODO(), task)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create task: %v", err)
+	createdAt := time.Now()
+	task.UnassignedSince = &createdAt
+	if prepare != nil {
+		prepare(&task)
 	}
 
 	// Notify project-service about the new task
@@ -378,6 +469,31 @@ func CreateTask(projectID, name, description string, dependsOn []string, token s
 		return nil, fmt.Errorf("project-service failed to update with new task details: %s", string(body))
 	}
 
+	// Insert the new task into the database together with its Task Created event.
+	// Project-service is notified first so a task is never stored for a project
+	// that refused it (e.g. one being deleted).
+	err = db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		if _, err := collection.InsertOne(sessCtx, task); err != nil {
+			return fmt.Errorf("failed to create task: %v", err)
+		}
+		err := outbox.Enqueue(sessCtx,
+			outbox.Event(taskCreatedEvent(&task)),
+			outbox.NATS(TaskEventsSubject, taskEventMessage(TaskEventCreated, &task, "", task.Status, "")),
+		)
+		if err != nil {
+			return err
+		}
+		return enqueueBoardEvent(sessCtx, BoardTaskCreated, task.Project_ID, task.ID, "", "")
+	})
+	if err != nil {
+		// Poništi dodavanje zadatka u projekat
+		unlinkURL := fmt.Sprintf("http://project-service:8080/projects/%s/tasks/%s", task.Project_ID, task.ID.Hex())
+		if unlinkErr := callServiceStep(context.TODO(), "DELETE", unlinkURL); unlinkErr != nil {
+			log.Printf("Failed to remove task %s from project %s: %v", task.ID.Hex(), task.Project_ID, unlinkErr)
+		}
+		return nil, err
+	}
+
 	// Return the created task
 	return &task, nil
 }
@@ -412,18 +528,31 @@ func AddUserToTask(taskID string, userID string, token string) error {
 	} else if err != nil {
 		return err
 	}
+	if task.Deleting {
+		return ErrTaskBeingDeleted
+	}
 
 	// Dodavanje korisnika u zadatak
-	_, err = collection.UpdateOne(
-		context.TODO(),
-		bson.M{"_id": taskObjectID},
-		bson.M{"$addToSet": bson.M{"Users": userID}},
-	)
-	if err != nil {
-		return err
-	}
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		_, err := collection.UpdateOne(
+			sessCtx,
+			bson.M{"_id": taskObjectID},
+			bson.M{"$addToSet": bson.M{"Users": userID}, "$unset": bson.M{"unassignedSince": ""}},
+		)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		err = outbox.Enqueue(sessCtx,
+			outbox.Event(taskMemberEvent("Member Added to Task", &task, userID)),
+			outbox.Contract(&contracts.TaskMemberAdded{TaskMember: taskMember(userID, &task)}),
+			outbox.NATS(TaskEventsSubject, taskEventMessage(TaskEventMemberAdded, &task, "", task.Status, userID)),
+		)
+		if err != nil {
+			return err
+		}
+		return enqueueBoardEvent(sessCtx, BoardTaskUpdated, task.Project_ID, taskObjectID, "users", "")
+	})
 }
 
 func RemoveUserFromTask(taskID string, userID string, token string) error {
@@ -467,17 +596,31 @@ func RemoveUserFromTask(taskID string, userID string, token string) error {
 		return errors.New("user is not a member of this task")
 	}
 
-	// Uklanjanje korisnika sa zadatka
-	_, err = collection.UpdateOne(
-		context.TODO(),
-		bson.M{"_id": taskObjectID},
-		bson.M{"$pull": bson.M{"Users": userID}},
-	)
-	if err != nil {
-		return err
+	// Uklanjanje korisnika sa zadatka; ako je bio poslednji, zadatak ostaje bez člana
+	update := bson.M{"$pull": bson.M{"Users": userID}}
+	if len(task.Users) == 1 {
+		update["$set"] = bson.M{"unassignedSince": time.Now()}
 	}
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		_, err := collection.UpdateOne(
+			sessCtx,
+			bson.M{"_id": taskObjectID},
+			update,
+		)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		err = outbox.Enqueue(sessCtx,
+			outbox.Event(taskMemberEvent("Member Removed from Task", &task, userID)),
+			outbox.Contract(&contracts.TaskMemberRemoved{TaskMember: taskMember(userID, &task)}),
+			outbox.NATS(TaskEventsSubject, taskEventMessage(TaskEventMemberRemoved, &task, "", task.Status, userID)),
+		)
+		if err != nil {
+			return err
+		}
+		return enqueueBoardEvent(sessCtx, BoardTaskUpdated, task.Project_ID, taskObjectID, "users", "")
+	})
 }
 
 func GetUsersForTask(taskID string, token string) ([]models.User, error) {
@@ -572,7 +715,6 @@ func IsUserInTask(taskID string, userID string, token string) (bool, error) {
 	// Sanitize input
 	taskID = SanitizeInput(taskID)
 	userID = SanitizeInput(userID)
-	fmt.Sprintf(userID)
 
 	// Construct the URL for the user-service
 	url := fmt.Sprintf("http://user-service:8080/users/%s", userID)
@@ -672,7 +814,7 @@ func FileExistsInHDFS(hdfsFilePath string) (bool, error) {
 	return true, nil
 }
 
-func AddDependencyToTask(taskIDStr, dependencyIDStr string) error {
+func AddDependencyToTask(taskIDStr, dependencyIDStr string, link models.DependencyLink, memberID string) error {
 	// Logovanje vrednosti ID-ova
 	fmt.Println("Task ID:", taskIDStr)
 	fmt.Println("Dependency ID:", dependencyIDStr)
@@ -713,18 +855,117 @@ func AddDependencyToTask(taskIDStr, dependencyIDStr string) error {
 		}
 	}
 
+	// Vrsta i kašnjenje zavisnosti čuvaju se u workflow-service, koji odbija i cikluse
+	link.TaskID = dependencyID.Hex()
+	if err := registerDependencyLink(&task, link); err != nil {
+		return err
+	}
+
 	// Dodavanje nove zavisnosti
-	task.DependsOn = append(task.DependsOn, dependencyID)
+	previous := task.DependsOn
+	dependsOn := append(append([]primitive.ObjectID{}, previous...), dependencyID)
+
+	settings, err := GetProjectTaskSettings(context.TODO(), task.Project_ID)
+	if err != nil {
+		return fmt.Errorf("error fetching project settings: %w", err)
+	}
 
 	// Ažuriranje taska u bazi sa novom zavisnošću
-	update := bson.M{"$set": bson.M{"dependsOn": task.DependsOn}}
-	_, err = collection.UpdateOne(context.TODO(), bson.M{"_id": taskID}, update)
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		update := bson.M{"$set": bson.M{"dependsOn": dependsOn}}
+		_, err := collection.UpdateOne(sessCtx, bson.M{"_id": taskID}, update)
+		if err != nil {
+			return fmt.Errorf("failed to update task with new dependency: %v", err)
+		}
+		if err := outbox.Enqueue(sessCtx, outbox.Event(taskUpdatedEvent(&task, "dependsOn", previous, dependsOn, memberID))); err != nil {
+			return err
+		}
+		if settings.DependencyPropagation {
+			if err := refreshDependencyFlags(sessCtx, &task, dependsOn); err != nil {
+				return err
+			}
+		}
+		return enqueueBoardEvent(sessCtx, BoardTaskUpdated, task.Project_ID, taskID, "dependsOn", memberID)
+	})
+}
+
+// SetTaskDependencies prepisuje Task.DependsOn listom koju je workflow-service
+// već upisao u graf zavisnosti i beleži izmenu kao događaj.
+func SetTaskDependencies(taskIDStr string, dependencyIDs []string, memberID string) error {
+	taskID, err := primitive.ObjectIDFromHex(taskIDStr)
 	if err != nil {
-		return fmt.Errorf("failed to update task with new dependency: %v", err)
+		return fmt.Errorf("invalid task ID format: %v", err)
 	}
 
-	return nil
+	dependsOn := []primitive.ObjectID{}
+	for _, idStr := range dependencyIDs {
+		dependencyID, err := primitive.ObjectIDFromHex(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid dependency ID format: %v", err)
+		}
+		dependsOn = append(dependsOn, dependencyID)
+	}
+
+	collection := db.Client.Database("testdb").Collection("tasks")
+	var task models.Task
+	err = collection.FindOne(context.TODO(), bson.M{"_id": taskID}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("failed to find task: %v", err)
+	}
+	if task.Deleting {
+		return ErrTaskBeingDeleted
+	}
+
+	// Ista lista ne pravi događaj, pa ponovljen poziv ne duplira istoriju
+	previous := task.DependsOn
+	if sameObjectIDs(previous, dependsOn) {
+		return nil
+	}
+
+	settings, err := GetProjectTaskSettings(context.TODO(), task.Project_ID)
+	if err != nil {
+		return fmt.Errorf("error fetching project settings: %w", err)
+	}
+
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		update := bson.M{"$set": bson.M{"dependsOn": dependsOn}}
+		_, err := collection.UpdateOne(sessCtx, bson.M{"_id": taskID}, update)
+		if err != nil {
+			return fmt.Errorf("failed to update task dependencies: %v", err)
+		}
+		if err := outbox.Enqueue(sessCtx, outbox.Event(taskUpdatedEvent(&task, "dependsOn", previous, dependsOn, memberID))); err != nil {
+			return err
+		}
+		if settings.DependencyPropagation {
+			if err := refreshDependencyFlags(sessCtx, &task, dependsOn); err != nil {
+				return err
+			}
+		}
+		return enqueueBoardEvent(sessCtx, BoardTaskUpdated, task.Project_ID, taskID, "dependsOn", memberID)
+	})
 }
+
+// sameObjectIDs poredi dve liste ID-eva bez obzira na redosled.
+func sameObjectIDs(a, b []primitive.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[primitive.ObjectID]int, len(a))
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+	return true
+}
+
 func GetTaskIDsForProject(projectID string, token string) ([]string, error) {
 	// URL for the project-service endpoint
 	url := fmt.Sprintf("http://project-service:8080/projects/%s", projectID)
@@ -978,164 +1219,78 @@ func TaskExists(taskID string) (bool, error) {
 	return true, nil
 }
 
-func sendToAnalyticsService(payload map[string]interface{}, token string) {
-	url := "http://analytics-service:8080/analytics/status-change"
-
-	// Konvertuj podatke u JSON
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		log.Printf("Failed to marshal analytics payload: %v", err)
-		return
-	}
-
-	// Kreiraj HTTP POST zahtev
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		log.Printf("Failed to create analytics request: %v", err)
-		return
-	}
-
-	// Postavi Authorization header sa Bearer tokenom
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	req.Header.Set("Content-Type", "application/json")
-
-	// Kreiraj HTTP klijent i pošalji zahtev
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func UpdateTaskPosition(taskID string, position int, memberID string, token string) error {
+	// Validate taskID format
+	taskObjectID, err := primitive.ObjectIDFromHex(taskID)
 	if err != nil {
-		log.Printf("Failed to send analytics data: %v", err)
-		return
+		return errors.New("invalid task ID format")
 	}
-	defer resp.Body.Close()
 
-	// Proveri statusni kod odgovora
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("Analytics service returned non-OK status: %v", resp.StatusCode)
-	}
+	// Update the task position
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		return setTaskPosition(sessCtx, taskObjectID, position, memberID)
+	})
 }
 
-func DeleteTaskByID(taskID string, token string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Poveži se na kolekciju "tasks" u MongoDB-u
+// setTaskPosition changes the task position inside the sessCtx transaction,
+// so callers can combine it with their own writes.
+func setTaskPosition(sessCtx mongo.SessionContext, taskObjectID primitive.ObjectID, position int, memberID string) error {
 	collection := db.Client.Database("testdb").Collection("tasks")
 
-	// Pokušaj da parsiraš taskID kao ObjectID
-	objID, err := primitive.ObjectIDFromHex(taskID)
-	if err != nil {
-		return fmt.Errorf("invalid taskID format: %v", err)
-	}
-
-	// Log za taskID
-	fmt.Println("Attempting to delete task with ObjectID:", objID)
-
-	// Dohvati task iz baze kako bismo dobili task podataka
 	var task models.Task
-	err = collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&task)
-	if err != nil {
-		return fmt.Errorf("could not find task with ID %v: %v", taskID, err)
-	}
-
-	// 1. Pozovi brisanje workflow-a vezanog za ovaj task
-	err = deleteWorkflow(taskID, token)
-	if err != nil {
-		return fmt.Errorf("failed to delete workflow for task with ID %s: %v", taskID, err)
-	}
-
-	// 2. Obriši task iz MongoDB-a
-	filter := bson.M{"_id": objID}
-	result, err := collection.DeleteOne(ctx, filter)
-	if err != nil {
-		return fmt.Errorf("failed to delete task: %v", err)
-	}
-
-	// Proveri rezultat
-	if result.DeletedCount == 0 {
-		return fmt.Errorf("no task found with taskID: %s", taskID)
-	}
-
-	// Uspešno obrisano
-	fmt.Println("Task deleted successfully")
-	return nil
-}
-
-// deleteWorkflow šalje HTTP DELETE zahtev za brisanje workflow-a na workflow-service
-func deleteWorkflow(taskID string, token string) error {
-	// URL za GET zahtev da proverimo da li workflow postoji za ovaj task
-	url := fmt.Sprintf("http://workflow-service:8080/check/%s", taskID)
-
-	// Napravimo GET zahtev da proverimo da li workflow postoji
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
-	}
-
-	// Dodaj token u Authorization header
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	// Setuj HTTP klijent sa timeout-om
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	// Pošaljemo zahtev
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
+	err := collection.FindOne(sessCtx, bson.M{"_id": taskObjectID}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return errors.New("task not found")
+	} else if err != nil {
+		return err
 	}
-	defer resp.Body.Close()
-
-	// Ako je status kod 404, znači da workflow za taj task ne postoji
-	if resp.StatusCode == http.StatusNotFound {
-		// Ako workflow ne postoji, ne radimo ništa i vraćamo nil (nema greške)
-		fmt.Printf("No workflow found for task %s, skipping workflow deletion\n", taskID)
+	if task.Position == position {
 		return nil
 	}
 
-	// Ako je workflow pronađen, sada možemo da pošaljemo DELETE zahtev za brisanje workflow-a
-	deleteUrl := fmt.Sprintf("http://workflow-service:8080/delete/%s", taskID)
-	reqDelete, err := http.NewRequest("DELETE", deleteUrl, nil)
-	if err != nil {
-		return fmt.Errorf("failed to create delete request: %v", err)
-	}
-
-	// Dodaj token u Authorization header za DELETE zahtev
-	reqDelete.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	// Pošaljemo DELETE zahtev za brisanje workflow-a
-	respDelete, err := client.Do(reqDelete)
+	_, err = collection.UpdateOne(
+		sessCtx,
+		bson.M{"_id": taskObjectID},
+		bson.M{"$set": bson.M{"position": position}},
+	)
 	if err != nil {
-		return fmt.Errorf("failed to send delete request: %v", err)
+		return fmt.Errorf("failed to update task position: %v", err)
 	}
-	defer respDelete.Body.Close()
-
-	// Proveri status kod odgovora DELETE zahteva
-	if respDelete.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to delete workflow, received status: %s", respDelete.Status)
+	if err := outbox.Enqueue(sessCtx, outbox.Event(taskUpdatedEvent(&task, "position", task.Position, position, memberID))); err != nil {
+		return err
 	}
-
-	// Workflow uspešno obrisan
-	fmt.Printf("Workflow for task %s deleted successfully\n", taskID)
-	return nil
+	return enqueueBoardEvent(sessCtx, BoardTaskMoved, task.Project_ID, taskObjectID, "position", memberID)
 }
-func UpdateTaskPosition(taskID string, position int, token string) error {
-	// Validate taskID format
+
+// AddFilesToTask stores the HDFS paths of newly uploaded files on the task.
+func AddFilesToTask(taskID string, filePaths []string) error {
 	taskObjectID, err := primitive.ObjectIDFromHex(taskID)
 	if err != nil {
-		return errors.New("invalid task ID format")
+		return fmt.Errorf("invalid task ID: %v", err)
 	}
 
-	// Connect to the MongoDB collection
 	collection := db.Client.Database("testdb").Collection("tasks")
 
-	// Update the task position
-	_, err = collection.UpdateOne(
-		context.TODO(),
-		bson.M{"_id": taskObjectID},
-		bson.M{"$set": bson.M{"position": position}},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to update task position: %v", err)
+	var task models.Task
+	err = collection.FindOne(context.TODO(), bson.M{"_id": taskObjectID}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		return errors.New("task not found")
+	} else if err != nil {
+		return err
 	}
 
-	return nil
+	return db.WithTransaction(context.TODO(), func(sessCtx mongo.SessionContext) error {
+		_, err := collection.UpdateOne(
+			sessCtx,
+			bson.M{"_id": taskObjectID},
+			bson.M{"$push": bson.M{"filePaths": bson.M{"$each": filePaths}}},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update task in MongoDB: %v", err)
+		}
+		if err := outbox.Enqueue(sessCtx, outbox.Event(documentAddedEvent(&task, filePaths))); err != nil {
+			return err
+		}
+		return enqueueBoardEvent(sessCtx, BoardTaskUpdated, task.Project_ID, taskObjectID, "filePaths", "")
+	})
 }
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"task-service/db"
+	"task-service/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// dateLayout je format datuma unosa vremena i perioda izveštaja.
+	dateLayout = "2006-01-02"
+	// maxEntryMinutes je najduže trajanje jednog unosa vremena.
+	maxEntryMinutes = 24 * 60
+)
+
+var (
+	ErrNotTaskMember       = errors.New("only members of the task can log time")
+	ErrTimerRunning        = errors.New("a timer is already running")
+	ErrNoRunningTimer      = errors.New("no timer is running for this task")
+	ErrTimeEntryNotFound   = errors.New("time entry not found")
+	ErrTimeEntryNotAllowed = errors.New("time entries can only be deleted by their author")
+)
+
+func timeEntriesCollection() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("time_entries")
+}
+
+// EnsureTimeEntryIndexes pravi indekse za izveštaje i jedinstven indeks koji
+// dozvoljava samo jedan pokrenut tajmer po korisniku.
+func EnsureTimeEntryIndexes(ctx context.Context) error {
+	_, err := timeEntriesCollection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "date", Value: 1}}},
+		{Keys: bson.D{{Key: "projectId", Value: 1}, {Key: "date", Value: 1}}},
+		{Keys: bson.D{{Key: "taskId", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"running": true}).SetName("one_running_timer"),
+		},
+	})
+	return err
+}
+
+// memberTask vraća zadatak ako je userID njegov član.
+func memberTask(taskID, userID string) (*models.Task, error) {
+	task, err := GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range task.Users {
+		if member == userID {
+			return task, nil
+		}
+	}
+	return nil, ErrNotTaskMember
+}
+
+// StartTimer pokreće tajmer člana na zadatku.
+func StartTimer(ctx context.Context, taskID, userID string) (*models.TimeEntry, error) {
+	task, err := memberTask(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	entry := models.TimeEntry{
+		ID:        primitive.NewObjectID(),
+		TaskID:    task.ID.Hex(),
+		ProjectID: task.Project_ID,
+		UserID:    userID,
+		Date:      now.Format(dateLayout),
+		Running:   true,
+		StartedAt: &now,
+		CreatedAt: now,
+	}
+	_, err = timeEntriesCollection().InsertOne(ctx, entry)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrTimerRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// StopTimer zaustavlja tajmer člana na zadatku i upisuje trajanje
+// zaokruženo na minut. Zaboravljen tajmer se upisuje sa najviše
+// maxEntryMinutes, kao i ručno uneto vreme.
+func StopTimer(ctx context.Context, taskID, userID, note string) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	err := timeEntriesCollection().FindOne(ctx, bson.M{"taskId": taskID, "userId": userID, "running": true}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNoRunningTimer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	minutes := int(math.Round(now.Sub(*entry.StartedAt).Minutes()))
+	if minutes > maxEntryMinutes {
+		minutes = maxEntryMinutes
+	}
+	set := bson.M{"endedAt": now, "durationMinutes": minutes}
+	if note != "" {
+		set["note"] = SanitizeInput(note)
+	}
+	err = timeEntriesCollection().FindOneAndUpdate(ctx,
+		bson.M{"_id": entry.ID, "running": true},
+		bson.M{"$set": set, "$unset": bson.M{"running": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNoRunningTimer
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// LogTime upisuje vreme koje je član proveo na zadatku na dan date.
+func LogTime(ctx context.Context, taskID, userID string, durationMinutes int, note, date string) (*models.TimeEntry, error) {
+	if durationMinutes <= 0 || durationMinutes > maxEntryMinutes {
+		return nil, fmt.Errorf("durationMinutes must be between 1 and %d", maxEntryMinutes)
+	}
+	if date == "" {
+		date = time.Now().UTC().Format(dateLayout)
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return nil, errors.New("date must be in the format YYYY-MM-DD")
+	}
+	task, err := memberTask(taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := models.TimeEntry{
+		ID:              primitive.NewObjectID(),
+		TaskID:          task.ID.Hex(),
+		ProjectID:       task.Project_ID,
+		UserID:          userID,
+		Date:            date,
+		DurationMinutes: durationMinutes,
+		Note:            SanitizeInput(note),
+		CreatedAt:       time.Now().UTC(),
+	}
+	if _, err := timeEntriesCollection().InsertOne(ctx, entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteTimeEntry briše unos; autor može da obriše samo svoj unos.
+func DeleteTimeEntry(ctx context.Context, entryID, userID string) error {
+	id, err := primitive.ObjectIDFromHex(entryID)
+	if err != nil {
+		return ErrTimeEntryNotFound
+	}
+	var entry models.TimeEntry
+	err = timeEntriesCollection().FindOne(ctx, bson.M{"_id": id}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return ErrTimeEntryNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if entry.UserID != userID {
+		return ErrTimeEntryNotAllowed
+	}
+	_, err = timeEntriesCollection().DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// GetTaskTimeEntries vraća unose vremena zadatka, najnovije prve.
+func GetTaskTimeEntries(ctx context.Context, taskID string) ([]models.TimeEntry, error) {
+	return findTimeEntries(ctx, bson.M{"taskId": taskID})
+}
+
+// GetTimesheet sabira završene unose korisnika (userID) ili projekta
+// (projectID) u periodu od from do to, oba uključena i opciona.
+func GetTimesheet(ctx context.Context, userID, projectID, from, to string) (*models.Timesheet, error) {
+	filter := bson.M{"running": bson.M{"$ne": true}}
+	if userID != "" {
+		filter["userId"] = userID
+	}
+	if projectID != "" {
+		filter["projectId"] = projectID
+	}
+	dateFilter := bson.M{}
+	for op, value := range map[string]string{"$gte": from, "$lte": to} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return nil, errors.New("from and to must be in the format YYYY-MM-DD")
+		}
+		dateFilter[op] = value
+	}
+	if len(dateFilter) > 0 {
+		filter["date"] = dateFilter
+	}
+
+	entries, err := findTimeEntries(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return newTimesheet(userID, projectID, from, to, entries), nil
+}
+
+// KeepManagedProjects ostavlja u izveštaju samo unose sa projekata kojima je
+// managerID menadžer, i ponovo računa zbirove.
+func KeepManagedProjects(timesheet *models.Timesheet, managerID string) (*models.Timesheet, error) {
+	managed := map[string]bool{}
+	entries := []models.TimeEntry{}
+	for _, entry := range timesheet.Entries {
+		isManager, checked := managed[entry.ProjectID]
+		if !checked {
+			var err error
+			if isManager, err = IsProjectManager(entry.ProjectID, managerID); err != nil {
+				return nil, err
+			}
+			managed[entry.ProjectID] = isManager
+		}
+		if isManager {
+			entries = append(entries, entry)
+		}
+	}
+	return newTimesheet(timesheet.UserID, timesheet.ProjectID, timesheet.From, timesheet.To, entries), nil
+}
+
+func newTimesheet(userID, projectID, from, to string, entries []models.TimeEntry) *models.Timesheet {
+	timesheet := &models.Timesheet{
+		UserID:    userID,
+		ProjectID: projectID,
+		From:      from,
+		To:        to,
+		ByTask:    map[string]int{},
+		ByUser:    map[string]int{},
+		ByWeek:    map[string]int{},
+		Entries:   entries,
+	}
+	for _, entry := range entries {
+		timesheet.TotalMinutes += entry.DurationMinutes
+		timesheet.ByTask[entry.TaskID] += entry.DurationMinutes
+		timesheet.ByUser[entry.UserID] += entry.DurationMinutes
+		timesheet.ByWeek[isoWeek(entry.Date)] += entry.DurationMinutes
+	}
+	return timesheet
+}
+
+func findTimeEntries(ctx context.Context, filter bson.M) ([]models.TimeEntry, error) {
+	cursor, err := timeEntriesCollection().Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	entries := []models.TimeEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// isoWeek vraća ISO nedelju datuma, npr. "2026-W42".
+func isoWeek(date string) string {
+	parsed, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return date
+	}
+	year, week := parsed.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// TimesheetCSV zapisuje unose izveštaja kao CSV, jedan unos po redu.
+func TimesheetCSV(timesheet *models.Timesheet) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write([]string{"date", "week", "project_id", "task_id", "user_id", "duration_minutes", "note"})
+	for _, entry := range timesheet.Entries {
+		writer.Write([]string{
+			entry.Date,
+			isoWeek(entry.Date),
+			csvCell(entry.ProjectID),
+			csvCell(entry.TaskID),
+			csvCell(entry.UserID),
+			strconv.Itoa(entry.DurationMinutes),
+			csvCell(entry.Note),
+		})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// csvCell sprečava da tabelarni program ćeliju izvrši kao formulu: vrednost
+// koja počinje sa =, +, -, @, tabom ili CR znakom dobija apostrof ispred.
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
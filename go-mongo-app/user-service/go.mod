@@ -8,12 +8,12 @@ require (
 	github.com/rs/cors v1.11.1
 	go.mongodb.org/mongo-driver v1.17.1
 	golang.org/x/crypto v0.26.0
-
+	shared v0.0.0
 )
 
 require (
 	github.com/golang/snappy v0.0.4 // indirect
-	github.com/klauspost/compress v1.13.6 // indirect
+	github.com/klauspost/compress v1.17.2 // indirect
 	github.com/montanaflynn/stats v0.7.1 // indirect
 	github.com/xdg-go/pbkdf2 v1.0.0 // indirect
 	github.com/xdg-go/scram v1.1.2 // indirect
@@ -22,3 +22,7 @@ require (
 	golang.org/x/sync v0.8.0 // indirect
 	golang.org/x/text v0.17.0 // indirect
 )
+
+replace shared => ../shared
+
+replace contracts => ../contracts
@@ -13,6 +13,7 @@ import (
 	"time"
 	"user-service/db"
 	"user-service/models"
+	"user-service/notification"
 	"user-service/security"
 	"user-service/service"
 
@@ -110,6 +111,11 @@ func RegisterUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Bez izabranog jezika koristi se jezik pregledača
+	if user.Locale == "" {
+		user.Locale = r.Header.Get("Accept-Language")
+	}
+
 	// Poziv na RegisterUser iz servisa koji vrši registraciju korisnika
 	message, err := service.RegisterUser(user)
 	if err != nil {
@@ -958,7 +964,7 @@ func (h *UserHandler) HandleVerifyPassword(w http.ResponseWriter, r *http.Reques
         <body>
             <div class="container">
                 <h1>Password Reset Successful</h1>
-                <p>Your password has been successfully reset. You can now <a href="http://localhost:4200/login">log in</a> with your new password.</p>
+                <p>Your password has been successfully reset. You can now <a href="` + notification.AppURL("/login") + `">log in</a> with your new password.</p>
             </div>
         </body>
         </html>
@@ -1119,7 +1125,7 @@ func (h *UserHandler) SendMagicLinkHandler(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Slanje magic link-a putem email-a
-	err = service.SendMagicLinkEmail(email, magicLink)
+	err = service.SendMagicLinkEmail(userData, magicLink)
 	if err != nil {
 		http.Error(w, "Error sending email", http.StatusInternalServerError)
 		log.Printf("Error sending magic link to email %s: %v", email, err)
@@ -1190,6 +1196,36 @@ func (h *UserHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "User deactivated successfully"})
 }
 
+// UpdateLocale menja jezik email poruka; korisnik može da menja samo svoj.
+func (h *UserHandler) UpdateLocale(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if accountID, _ := r.Context().Value(KeyAccount{}).(string); accountID != userID {
+		http.Error(w, "You can only change your own language", http.StatusForbidden)
+		return
+	}
+
+	var requestBody struct {
+		Locale string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		return
+	}
+
+	err := service.UpdateLocale(userID, requestBody.Locale)
+	if err != nil {
+		if err.Error() == "user not found" {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"locale": requestBody.Locale})
+}
+
 func (uh *UserHandler) MiddlewareExtractUserFromHeader(next func(http.ResponseWriter, *http.Request)) http.HandlerFunc {
 	return func(rw http.ResponseWriter, h *http.Request) {
 		// Retrieve the token from the Authorization header
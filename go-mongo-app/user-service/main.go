@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"user-service/bootstrap"
 	"user-service/db"
 	"user-service/handlers"
+	"user-service/notification"
 	"user-service/service"
 
 	"github.com/gorilla/mux"
@@ -24,6 +26,7 @@ func main() {
 	defer db.DisconnectMongo()
 	db.CreateTTLIndex()
 	db.CreateTTLIndex2()
+	notification.CreateMailQueueIndexes()
 
 	bootstrap.ClearUsers()
 	bootstrap.InsertInitialUsers()
@@ -31,6 +34,9 @@ func main() {
 	logger := log.New(os.Stdout, "[user-api] ", log.LstdFlags)
 	mongoInstance := db.New(db.Client, logger)
 
+	// Email poruke se šalju iz reda, sa ponovnim pokušajima
+	go service.RunMailQueue(context.Background())
+
 	userService := service.NewUserService(mongoInstance, logger)
 
 	userHandler := handlers.NewUserHandler(logger, userService)
@@ -44,6 +50,7 @@ func main() {
 	router.HandleFunc("/users/{id}", userHandler.GetUserByID).Methods("GET", "OPTIONS")
 	router.HandleFunc("/reset-password", userHandler.HandleResetPassword).Methods("POST", "GET", "OPTIONS")
 	router.HandleFunc("/verify-password", userHandler.HandleVerifyPassword).Methods("GET", "POST", "OPTIONS")
+	router.HandleFunc("/users/{id}/locale", userHandler.MiddlewareExtractUserFromHeader(userHandler.RoleRequired(userHandler.UpdateLocale, "Manager", "Member"))).Methods("PUT", "OPTIONS")
 	router.HandleFunc("/users/{id}/change-password", userHandler.MiddlewareExtractUserFromHeader(userHandler.RoleRequired(userHandler.ChangePassword, "Manager", "Member"))).Methods("POST", "OPTIONS")
 
 	// Other routes without the middleware
@@ -58,7 +65,7 @@ func main() {
 	router.HandleFunc("/verify-magic-link", userHandler.VerifyMagicLinkHandler).Methods("GET", "OPTIONS")
 
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"http://localhost:4200"},
+		AllowedOrigins:   []string{notification.AppBaseURL()},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
 		AllowCredentials: true,
@@ -11,6 +11,7 @@ type User struct {
 	Surname  string             `bson:"surname" json:"surname"`
 	Email    string             `bson:"email" json:"email"`
 	IsActive bool               `bson:"isActive" json:"isActive"`
+	Locale   string             `bson:"locale,omitempty" json:"locale,omitempty"`
 }
 
 func NewUser(username, password, role, name, surname, email string) User {
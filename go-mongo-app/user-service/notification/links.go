@@ -0,0 +1,28 @@
+package notification
+
+import (
+	"os"
+	"strings"
+)
+
+// PublicURL vraća apsolutni link ka API-ju iza nginx-a (PUBLIC_BASE_URL).
+func PublicURL(path string) string {
+	return baseURL("PUBLIC_BASE_URL", "https://localhost/taskio") + path
+}
+
+// AppURL vraća apsolutni link ka frontend aplikaciji (APP_BASE_URL).
+func AppURL(path string) string {
+	return AppBaseURL() + path
+}
+
+func AppBaseURL() string {
+	return baseURL("APP_BASE_URL", "http://localhost:4200")
+}
+
+func baseURL(key, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		value = fallback
+	}
+	return strings.TrimRight(value, "/")
+}
@@ -0,0 +1,52 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"shared/mail"
+	"shared/mailqueue"
+	"user-service/db"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Mailer pravi poruke iz šablona i šalje ih preko zajedničkog reda u bazi.
+type Mailer struct {
+	queue *mailqueue.Queue
+	from  string
+}
+
+func NewMailer(transport mail.Transport, from string) *Mailer {
+	return &Mailer{queue: mailqueue.New(mailQueue, transport), from: from}
+}
+
+func mailQueue() *mongo.Collection {
+	return db.Client.Database("testdb").Collection("mail_queue")
+}
+
+// CreateMailQueueIndexes pravi indekse reda poruka.
+func CreateMailQueueIndexes() {
+	if err := mailqueue.New(mailQueue, nil).CreateIndexes(context.Background()); err != nil {
+		log.Fatal("Failed to create mail queue indexes:", err)
+	}
+}
+
+// Enqueue popunjava šablon i stavlja poruku u red za slanje.
+func (m *Mailer) Enqueue(ctx context.Context, to, template string, data TemplateData) error {
+	subject, text, html, err := Render(template, data)
+	if err != nil {
+		return err
+	}
+	return m.queue.Enqueue(ctx, template, NormalizeLocale(data.Locale), &mail.Message{
+		From:    m.from,
+		To:      to,
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	})
+}
+
+// Run šalje poruke iz reda dok se ctx ne završi.
+func (m *Mailer) Run(ctx context.Context) {
+	m.queue.Run(ctx)
+}
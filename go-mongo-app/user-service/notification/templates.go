@@ -0,0 +1,109 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Svaki šablon je jedan fajl po jeziku (templates/<jezik>/<ime>.tmpl) sa
+// blokovima "subject", "text" i "html". HTML se umeće u zajednički layout.
+//
+//go:embed templates
+var templateFS embed.FS
+
+const DefaultLocale = "en"
+
+// Locales su jezici za koje postoje šabloni.
+var Locales = []string{"en", "sr"}
+
+// Šabloni transakcionih poruka.
+const (
+	TemplateConfirmAccount = "confirm_account"
+	TemplatePasswordReset  = "password_reset"
+	TemplateMagicLink      = "magic_link"
+)
+
+// TemplateData su podaci koje šabloni koriste.
+type TemplateData struct {
+	Locale           string
+	Name             string
+	Link             string
+	ExpiresInMinutes int
+}
+
+type localizedTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var templates = loadTemplates()
+
+func loadTemplates() map[string]*localizedTemplate {
+	out := map[string]*localizedTemplate{}
+	for _, locale := range Locales {
+		for _, name := range []string{TemplateConfirmAccount, TemplatePasswordReset, TemplateMagicLink} {
+			file := fmt.Sprintf("templates/%s/%s.tmpl", locale, name)
+			out[locale+"/"+name] = &localizedTemplate{
+				text: texttemplate.Must(texttemplate.ParseFS(templateFS, file)),
+				html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.tmpl", file)),
+			}
+		}
+	}
+	return out
+}
+
+// Render popunjava šablon name na jeziku data.Locale; nepoznat jezik koristi engleski.
+func Render(name string, data TemplateData) (subject, text, html string, err error) {
+	data.Locale = NormalizeLocale(data.Locale)
+	tmpl, ok := templates[data.Locale+"/"+name]
+	if !ok {
+		return "", "", "", fmt.Errorf("unknown email template: %s", name)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := tmpl.text.ExecuteTemplate(&buf, "text", data); err != nil {
+		return "", "", "", err
+	}
+	text = strings.TrimSpace(buf.String()) + "\n"
+
+	buf.Reset()
+	if err := tmpl.html.ExecuteTemplate(&buf, "layout", data); err != nil {
+		return "", "", "", err
+	}
+	return subject, text, buf.String(), nil
+}
+
+// NormalizeLocale vraća prvi podržani jezik iz vrednosti kao što je
+// "sr-Latn-RS" ili Accept-Language zaglavlja ("sr;q=0.9,en;q=0.8").
+func NormalizeLocale(value string) string {
+	for _, part := range strings.Split(value, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		language := strings.ToLower(strings.SplitN(strings.ReplaceAll(tag, "_", "-"), "-", 2)[0])
+		for _, locale := range Locales {
+			if language == locale {
+				return locale
+			}
+		}
+	}
+	return DefaultLocale
+}
+
+// IsSupportedLocale proverava da li je locale tačno jedan od podržanih jezika.
+func IsSupportedLocale(locale string) bool {
+	for _, l := range Locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 	"user-service/models"
+	"user-service/notification"
 )
 
 type User struct {
@@ -44,7 +45,7 @@ func GenerateMagicLink(user models.User) (string, error) {
 	}
 
 	// Generišemo magic link sa tokenom
-	magicLink := fmt.Sprintf("http://localhost:4200/verify-magic-link?token=%s", token)
+	magicLink := notification.AppURL("/verify-magic-link?token=" + token)
 	return magicLink, nil
 }
 
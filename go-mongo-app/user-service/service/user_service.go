@@ -9,8 +9,10 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
+	"shared/mail"
 	"strings"
 	"time"
 	"user-service/db"
@@ -91,11 +93,24 @@ func sanitizeEmail(email string) string {
 	return "" // Ako email sadrži nedozvoljene karaktere, vraćamo praznu vrednost
 }
 
-var emailConfig = notification.EmailConfig{
-	From:     os.Getenv("EMAIL_FROM"),
-	Password: os.Getenv("EMAIL_PASSWORD"),
-	SMTPHost: os.Getenv("SMTP_HOST"),
-	SMTPPort: os.Getenv("SMTP_PORT"),
+var emailConfig = mail.ConfigFromEnv()
+
+var mailer = notification.NewMailer(mail.TransportFromEnv(emailConfig), emailConfig.From)
+
+// mailData priprema podatke za šablon; ime je u bazi HTML-escape-ovano,
+// a šabloni ga escape-uju sami.
+func mailData(user models.User, link string, expiresInMinutes int) notification.TemplateData {
+	return notification.TemplateData{
+		Locale:           user.Locale,
+		Name:             html.UnescapeString(user.Name),
+		Link:             link,
+		ExpiresInMinutes: expiresInMinutes,
+	}
+}
+
+// RunMailQueue šalje poruke iz reda dok se ctx ne završi.
+func RunMailQueue(ctx context.Context) {
+	mailer.Run(ctx)
 }
 
 func GetActiveUsers() ([]models.User, error) {
@@ -268,6 +283,7 @@ func RegisterUser(user models.User) (string, error) {
 	user.Email = sanitizeInput(user.Email)
 	user.Name = sanitizeInput(user.Name)
 	user.Surname = sanitizeInput(user.Surname)
+	user.Locale = notification.NormalizeLocale(user.Locale)
 
 	// Validacija korisničkog imena
 	sanitizedUsername, err := validateUsername(user.Username)
@@ -326,10 +342,10 @@ func RegisterUser(user models.User) (string, error) {
 	}
 
 	// Slanje emaila sa linkom za potvrdu
-	subject := "Thanks for registering"
-	body := fmt.Sprintf("Your registration is successful! Click the following link to activate your account: https://localhost/taskio/confirm?email=%s&token=%s", user.Email, token)
-	err = notification.SendEmail(user.Email, subject, body, emailConfig)
+	link := notification.PublicURL("/confirm?" + url.Values{"email": {user.Email}, "token": {token}}.Encode())
+	err = mailer.Enqueue(ctx, user.Email, notification.TemplateConfirmAccount, mailData(user, link, 3))
 	if err != nil {
+		log.Println("Error queueing confirmation email:", err)
 		return "Registration successful, but failed to send confirmation email", nil
 	}
 
@@ -483,7 +499,7 @@ func ResetPassword(email string, method string) (string, error) {
 			return "", fmt.Errorf("Failed to store password reset token")
 		}
 
-		err = SendPasswordResetEmail(email, token)
+		err = SendPasswordResetEmail(user, token)
 		if err != nil {
 			return "", fmt.Errorf("Error sending password reset email: %v", err)
 		}
@@ -501,16 +517,14 @@ func generateToken() string {
 	return fmt.Sprintf("%x", b) // Vraća token kao heksadecimalni string
 }
 
-func SendPasswordResetEmail(email string, token string) error {
-	subject := "Password reset"
-	body := "Click the following link to reset your password: http://localhost/taskio/reset-password?email=" + email + "&token=" + token
-
+func SendPasswordResetEmail(user models.User, token string) error {
 	// Logovanje pre slanja emaila
-	log.Println("Sending password reset email to:", email)
+	log.Println("Sending password reset email to:", user.Email)
 
-	err := notification.SendEmail(email, subject, body, emailConfig)
+	link := notification.PublicURL("/reset-password?" + url.Values{"email": {user.Email}, "token": {token}}.Encode())
+	err := mailer.Enqueue(context.Background(), user.Email, notification.TemplatePasswordReset, mailData(user, link, 3))
 	if err != nil {
-		log.Println("Error sending email:", err)
+		log.Println("Error queueing email:", err)
 	}
 	return err
 }
@@ -576,12 +590,31 @@ func ChangePassword(userID, oldPassword, newPassword string) error {
 
 	return nil // Uspešno promenjena lozinka
 }
-func SendMagicLinkEmail(email, magicLink string) error {
-	subject := "Magic Link for Login"
-	body := "Click the following link to login: " + magicLink
-	err := notification.SendEmail(email, subject, body, emailConfig)
-	return err
+func SendMagicLinkEmail(user models.User, magicLink string) error {
+	return mailer.Enqueue(context.Background(), user.Email, notification.TemplateMagicLink, mailData(user, magicLink, 60))
+}
+
+// UpdateLocale menja jezik na kom korisnik dobija email poruke.
+func UpdateLocale(userID, locale string) error {
+	if !notification.IsSupportedLocale(locale) {
+		return fmt.Errorf("unsupported locale: %s", locale)
+	}
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID format")
+	}
+
+	collection := db.Client.Database("testdb").Collection("users")
+	result, err := collection.UpdateOne(context.TODO(), bson.M{"_id": objID}, bson.M{"$set": bson.M{"locale": locale}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
 }
+
 func DeactivateUser(userID string) error {
 	collection := db.Client.Database("testdb").Collection("users")
 
@@ -0,0 +1,206 @@
+package graphexport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Node is a task in the exported graph. Name and Status are empty when the
+// task could not be fetched.
+type Node struct {
+	ID     string
+	Name   string
+	Status string
+}
+
+// Edge points from the predecessor From to the task To that depends on it.
+type Edge struct {
+	From    string
+	To      string
+	Type    string
+	LagDays int
+}
+
+// Graph is the dependency graph of one project.
+type Graph struct {
+	ProjectID string
+	Nodes     []Node
+	Edges     []Edge
+}
+
+// sorted returns the nodes and edges ordered by ID so every export of the
+// same graph is byte-for-byte identical.
+func (g Graph) sorted() ([]Node, []Edge) {
+	nodes := append([]Node{}, g.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	edges := append([]Edge{}, g.Edges...)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	return nodes, edges
+}
+
+// label is the text shown for a node: its name and status, or the ID when
+// the name is unknown.
+func (n Node) label() string {
+	label := n.Name
+	if label == "" {
+		label = n.ID
+	}
+	if n.Status != "" {
+		label += "\n(" + n.Status + ")"
+	}
+	return label
+}
+
+// edgeLabel omits the common case of finish-to-start without lag.
+func (e Edge) edgeLabel() string {
+	edgeType := e.Type
+	if edgeType == "" {
+		edgeType = "FS"
+	}
+	if edgeType == "FS" && e.LagDays == 0 {
+		return ""
+	}
+	if e.LagDays == 0 {
+		return edgeType
+	}
+	return fmt.Sprintf("%s%+dd", edgeType, e.LagDays)
+}
+
+// statusColor maps task-service statuses to fill colors.
+func statusColor(status string) string {
+	switch status {
+	case "done":
+		return "#b7e1a1"
+	case "work in progress":
+		return "#ffe08a"
+	case "pending":
+		return "#e0e0e0"
+	default:
+		return "#ffffff"
+	}
+}
+
+// DOT renders the graph in the Graphviz DOT language.
+func DOT(g Graph) string {
+	nodes, edges := g.sorted()
+	quote := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph \"%s\" {\n", quote.Replace("project "+g.ProjectID))
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "  \"%s\" [label=\"%s\", fillcolor=\"%s\"];\n", quote.Replace(n.ID), quote.Replace(n.label()), statusColor(n.Status))
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&b, "  \"%s\" -> \"%s\"", quote.Replace(e.From), quote.Replace(e.To))
+		if label := e.edgeLabel(); label != "" {
+			fmt.Fprintf(&b, " [label=\"%s\"]", label)
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Task IDs are replaced by
+// short node names because Mermaid restricts which characters an ID may use.
+func Mermaid(g Graph) string {
+	nodes, edges := g.sorted()
+	quote := strings.NewReplacer(`"`, "#quot;", "\n", "<br/>")
+
+	names := make(map[string]string, len(nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range nodes {
+		names[n.ID] = fmt.Sprintf("t%d", i)
+		fmt.Fprintf(&b, "  %s[\"%s\"]\n", names[n.ID], quote.Replace(n.label()))
+	}
+	for _, e := range edges {
+		if label := e.edgeLabel(); label != "" {
+			fmt.Fprintf(&b, "  %s -->|%s| %s\n", names[e.From], label, names[e.To])
+		} else {
+			fmt.Fprintf(&b, "  %s --> %s\n", names[e.From], names[e.To])
+		}
+	}
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "  style %s fill:%s\n", names[n.ID], statusColor(n.Status))
+	}
+	return b.String()
+}
+
+// JSONGraph renders the graph in JSON Graph Format (version 2).
+func JSONGraph(g Graph) ([]byte, error) {
+	nodes, edges := g.sorted()
+
+	type jgfNode struct {
+		Label    string            `json:"label,omitempty"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+	}
+	type jgfEdge struct {
+		Source   string                 `json:"source"`
+		Target   string                 `json:"target"`
+		Relation string                 `json:"relation"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+
+	jgfNodes := make(map[string]jgfNode, len(nodes))
+	for _, n := range nodes {
+		node := jgfNode{Label: n.Name}
+		if n.Status != "" {
+			node.Metadata = map[string]string{"status": n.Status}
+		}
+		jgfNodes[n.ID] = node
+	}
+	jgfEdges := []jgfEdge{}
+	for _, e := range edges {
+		jgfEdges = append(jgfEdges, jgfEdge{
+			Source:   e.From,
+			Target:   e.To,
+			Relation: "precedes",
+			Metadata: map[string]interface{}{"type": e.Type, "lag_days": e.LagDays},
+		})
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"graph": map[string]interface{}{
+			"id":       g.ProjectID,
+			"type":     "workflow",
+			"directed": true,
+			"metadata": map[string]string{"project_id": g.ProjectID},
+			"nodes":    jgfNodes,
+			"edges":    jgfEdges,
+		},
+	}, "", "  ")
+}
+
+// ErrRendererMissing is returned by SVG when Graphviz is not installed.
+var ErrRendererMissing = errors.New("graphviz dot executable not found")
+
+// SVG renders the DOT export with the Graphviz dot executable.
+func SVG(ctx context.Context, g Graph) ([]byte, error) {
+	if _, err := exec.LookPath("dot"); err != nil {
+		return nil, ErrRendererMissing
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "dot", "-Tsvg")
+	cmd.Stdin = strings.NewReader(DOT(g))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("rendering SVG: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
@@ -10,12 +11,18 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
+	"workflow-service/graphexport"
 	"workflow-service/models"
 	"workflow-service/repoWorkflow"
+	"workflow-service/schedule"
 )
 
 type KeyAccount struct{}
 
+// dateLayout je format datuma koji koristi project-service za expected_end_date.
+const dateLayout = "2006-01-02"
+
 type KeyRole struct{}
 type WorkflowHandler struct {
 	logger *log.Logger
@@ -28,9 +35,10 @@ func NewWorkflowHandler(repo *repoWorkflow.WorkflowRepository, logger *log.Logge
 
 func (h *WorkflowHandler) CreateWorkflow(w http.ResponseWriter, r *http.Request) {
 	var workflowRequest struct {
-		TaskID         string   `json:"task_id"`
-		DependencyTask []string `json:"dependency_task"`
-		ProjectID      string   `json:"project_id"`
+		TaskID         string              `json:"task_id"`
+		DependencyTask []string            `json:"dependency_task"`
+		Dependencies   []models.Dependency `json:"dependencies"`
+		ProjectID      string              `json:"project_id"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&workflowRequest); err != nil {
@@ -46,20 +54,47 @@ func (h *WorkflowHandler) CreateWorkflow(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "project_id cannot be empty", http.StatusBadRequest)
 		return
 	}
-	if len(workflowRequest.DependencyTask) == 0 {
+
+	// dependency_task su finish-to-start zavisnosti bez kašnjenja
+	dependencies := workflowRequest.Dependencies
+	for _, dep := range workflowRequest.DependencyTask {
+		dependencies = append(dependencies, models.Dependency{TaskID: dep, Type: models.FinishToStart})
+	}
+	if len(dependencies) == 0 {
 		http.Error(w, "dependency_task cannot be empty", http.StatusBadRequest)
 		return
 	}
 
-	err := h.repo.CheckForCycle(r.Context(), workflowRequest.TaskID, workflowRequest.DependencyTask)
-	if err != nil {
+	var dependencyIDs []string
+	for i, dep := range dependencies {
+		if dep.TaskID == "" {
+			http.Error(w, "dependency task_id cannot be empty", http.StatusBadRequest)
+			return
+		}
+		if dep.Type == "" {
+			dependencies[i].Type = models.FinishToStart
+		} else if !models.IsValidDependencyType(dep.Type) {
+			http.Error(w, fmt.Sprintf("invalid dependency type %q, expected FS, SS, FF or SF", dep.Type), http.StatusBadRequest)
+			return
+		}
+		dependencyIDs = append(dependencyIDs, dep.TaskID)
+	}
+
+	err := h.repo.CheckForCycle(r.Context(), workflowRequest.TaskID, dependencyIDs)
+	var cycleErr *repoWorkflow.CycleError
+	if errors.As(err, &cycleErr) {
 		http.Error(w, fmt.Sprintf("Cycle detected: %v", err), http.StatusBadRequest)
 		return
 	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error checking for cycle: %v", err), http.StatusInternalServerError)
+		return
+	}
 
 	workflow := models.Workflow{
 		TaskID:         workflowRequest.TaskID,
-		DependencyTask: workflowRequest.DependencyTask,
+		DependencyTask: dependencyIDs,
+		Dependencies:   dependencies,
 		ProjectID:      workflowRequest.ProjectID,
 		IsActive:       true,
 	}
@@ -83,6 +118,10 @@ func (h *WorkflowHandler) CreateWorkflow(w http.ResponseWriter, r *http.Request)
 	}
 
 	err = h.repo.CreateWorkflow(r.Context(), workflow, token)
+	if errors.As(err, &cycleErr) {
+		http.Error(w, fmt.Sprintf("Cycle detected: %v", err), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error creating workflow: %v", err), http.StatusInternalServerError)
 		return
@@ -187,14 +226,160 @@ func (h *WorkflowHandler) GetTaskDependenciesHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	dependencyTasks := []string{}
+	for _, dep := range dependencies {
+		dependencyTasks = append(dependencyTasks, dep.TaskID)
+	}
+
 	// Vraćanje zavisnih taskova kao JSON odgovor
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"task_id":          taskID,
-		"dependency_tasks": dependencies,
+		"dependency_tasks": dependencyTasks,
+		"dependencies":     dependencies,
+	})
+}
+
+// GetDependencyPathHandler proverava da li task (posredno) zavisi od drugog taska.
+func (h *WorkflowHandler) GetDependencyPathHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	taskID := vars["task_id"]
+	otherTaskID := vars["other_task_id"]
+
+	path, err := h.repo.DependencyPath(r.Context(), taskID, otherTaskID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error checking dependency path: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id":       taskID,
+		"other_task_id": otherTaskID,
+		"depends_on":    path != nil,
+		"path":          path,
+	})
+}
+
+// SetTaskDurationHandler postavlja procenu trajanja zadatka u danima.
+func (h *WorkflowHandler) SetTaskDurationHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["task_id"]
+
+	var request struct {
+		ProjectID    string `json:"project_id"`
+		DurationDays int    `json:"duration_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Error decoding request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.ProjectID == "" {
+		http.Error(w, "project_id cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if request.DurationDays < 0 {
+		http.Error(w, "duration_days cannot be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.SetTaskDuration(r.Context(), taskID, request.ProjectID, request.DurationDays); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id":       taskID,
+		"duration_days": request.DurationDays,
 	})
 }
+
+// GetProjectScheduleHandler računa kritični put projekta i proverava da li
+// projekat može da se završi do očekivanog datuma.
+func (h *WorkflowHandler) GetProjectScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
+		h.logger.Println("No Authorization header:", authHeader)
+		return
+	}
+
+	// Expect the format "Bearer <token>"
+	token := ""
+
+	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
+		token = authHeader[7:]
+	} else {
+		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+		h.logger.Println("Invalid Authorization header format:", authHeader)
+		return
+	}
+
+	// Raspored počinje danas, osim ako nije zadat drugi početak
+	start := time.Now().Truncate(24 * time.Hour)
+	if startParam := r.URL.Query().Get("start"); startParam != "" {
+		parsed, err := time.Parse(dateLayout, startParam)
+		if err != nil {
+			http.Error(w, "start must be a date in the format YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		start = parsed
+	}
+
+	tasks, edges, unestimated, err := h.repo.GetProjectNetwork(r.Context(), projectID)
+	if err != nil {
+		http.Error(w, "Error fetching workflows: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result, err := schedule.CriticalPath(tasks, edges)
+	if err != nil {
+		http.Error(w, "Error computing schedule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	projectSchedule := models.ProjectSchedule{
+		ProjectID:    projectID,
+		StartDate:    start.Format(dateLayout),
+		FinishDate:   start.AddDate(0, 0, result.DurationDays).Format(dateLayout),
+		DurationDays: result.DurationDays,
+		CriticalPath: result.CriticalPath,
+		Tasks:        []models.ScheduledTask{},
+		Unestimated:  unestimated,
+	}
+	for _, task := range result.Tasks {
+		projectSchedule.Tasks = append(projectSchedule.Tasks, models.ScheduledTask{
+			TaskSchedule: task,
+			StartDate:    start.AddDate(0, 0, task.EarliestStart).Format(dateLayout),
+			FinishDate:   start.AddDate(0, 0, task.EarliestFinish).Format(dateLayout),
+		})
+	}
+
+	expectedEndDate, err := repoWorkflow.GetProjectExpectedEndDate(projectID, token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if expectedEnd, err := time.Parse(dateLayout, expectedEndDate); err == nil {
+		projectSchedule.ExpectedEndDate = expectedEndDate
+		finish := start.AddDate(0, 0, result.DurationDays)
+		if finish.After(expectedEnd) {
+			projectSchedule.ExceedsExpectedEnd = true
+			projectSchedule.OverrunDays = int(finish.Sub(expectedEnd).Hours() / 24)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(projectSchedule); err != nil {
+		http.Error(w, "Error encoding response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (h *WorkflowHandler) GetFlowByProjectIDHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	projectID, ok := vars["project_id"]
@@ -216,6 +401,379 @@ func (h *WorkflowHandler) GetFlowByProjectIDHandler(w http.ResponseWriter, r *ht
 	}
 }
 
+// RemoveDependencyHandler uklanja jednu zavisnost zadatka.
+func (h *WorkflowHandler) RemoveDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	edit := repoWorkflow.DependencyEdit{Remove: []string{vars["dependency_id"]}}
+	h.editDependencies(w, r, vars["task_id"], edit)
+}
+
+// ReplaceDependencyHandler zamenjuje jednu zavisnost zadatka drugom, ili joj
+// menja vrstu i kašnjenje ako je task_id u telu isti ili prazan.
+func (h *WorkflowHandler) ReplaceDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var dependency models.Dependency
+	if err := json.NewDecoder(r.Body).Decode(&dependency); err != nil {
+		http.Error(w, fmt.Sprintf("Error decoding request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if dependency.TaskID == "" {
+		dependency.TaskID = vars["dependency_id"]
+	}
+
+	edit := repoWorkflow.DependencyEdit{
+		Add:    []models.Dependency{dependency},
+		Remove: []string{vars["dependency_id"]},
+	}
+	h.editDependencies(w, r, vars["task_id"], edit)
+}
+
+// EditDependenciesHandler dodaje, menja i uklanja više zavisnosti zadatka odjednom.
+func (h *WorkflowHandler) EditDependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	var edit repoWorkflow.DependencyEdit
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+		http.Error(w, fmt.Sprintf("Error decoding request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(edit.Add) == 0 && len(edit.Remove) == 0 {
+		http.Error(w, "add and remove cannot both be empty", http.StatusBadRequest)
+		return
+	}
+	h.editDependencies(w, r, mux.Vars(r)["task_id"], edit)
+}
+
+// editDependencies primenjuje izmenu u grafu i prepisuje rezultat u
+// task-service, koji ga čuva u Task.DependsOn i beleži događaj. Ako
+// prepisivanje ne uspe, graf ostaje nepromenjen.
+func (h *WorkflowHandler) editDependencies(w http.ResponseWriter, r *http.Request, taskID string, edit repoWorkflow.DependencyEdit) {
+	for i, dep := range edit.Add {
+		if dep.TaskID == "" {
+			http.Error(w, "dependency task_id cannot be empty", http.StatusBadRequest)
+			return
+		}
+		if dep.TaskID == taskID {
+			http.Error(w, "task cannot depend on itself", http.StatusBadRequest)
+			return
+		}
+		if dep.Type == "" {
+			edit.Add[i].Type = models.FinishToStart
+		} else if !models.IsValidDependencyType(dep.Type) {
+			http.Error(w, fmt.Sprintf("invalid dependency type %q, expected FS, SS, FF or SF", dep.Type), http.StatusBadRequest)
+			return
+		}
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if len(authHeader) <= 7 || strings.ToLower(authHeader[:7]) != "bearer " {
+		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+		h.logger.Println("Invalid Authorization header format:", authHeader)
+		return
+	}
+	token := authHeader[7:]
+
+	dependencies, err := h.repo.EditDependencies(r.Context(), taskID, edit, token)
+	var cycleErr *repoWorkflow.CycleError
+	switch {
+	case errors.As(err, &cycleErr):
+		http.Error(w, fmt.Sprintf("Cycle detected: %v", err), http.StatusBadRequest)
+		return
+	case errors.Is(err, repoWorkflow.ErrWorkflowNotFound), errors.Is(err, repoWorkflow.ErrDependencyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, repoWorkflow.ErrTaskSyncFailed):
+		// Izmena grafa je poništena, pa su graf i task-service i dalje usklađeni
+		h.logger.Printf("Dependencies of task %s were not changed, task-service sync failed: %v", taskID, err)
+		http.Error(w, fmt.Sprintf("Dependencies were not changed: %v", err), http.StatusBadGateway)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("Error editing dependencies: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id":      taskID,
+		"dependencies": dependencies,
+	})
+}
+
+// ExportProjectGraphHandler izvozi graf zavisnosti projekta sa imenima i
+// statusima zadataka. Format se bira parametrom format: dot, mermaid, json
+// (JSON Graph Format) ili svg.
+func (h *WorkflowHandler) ExportProjectGraphHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	switch format {
+	case "dot", "mermaid", "json", "svg":
+	default:
+		http.Error(w, "format must be one of dot, mermaid, json or svg", http.StatusBadRequest)
+		return
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
+		h.logger.Println("No Authorization header:", authHeader)
+		return
+	}
+
+	// Expect the format "Bearer <token>"
+	token := ""
+
+	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
+		token = authHeader[7:]
+	} else {
+		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+		h.logger.Println("Invalid Authorization header format:", authHeader)
+		return
+	}
+
+	workflows, err := h.repo.GetAllWorkflowsByProjectID(r.Context(), projectID)
+	if err != nil {
+		http.Error(w, "Error fetching workflows: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Zadaci projekta se dohvataju jednim zahtevom; zadatak koji task-service
+	// ne vrati ostaje u grafu samo sa ID-em
+	tasks, err := repoWorkflow.GetProjectTasks(projectID, token)
+	if err != nil {
+		h.logger.Printf("Graph export: %v", err)
+	}
+
+	graph := graphexport.Graph{ProjectID: projectID}
+	seen := map[string]bool{}
+	addNode := func(taskID string) {
+		if seen[taskID] {
+			return
+		}
+		seen[taskID] = true
+		node := graphexport.Node{ID: taskID}
+		if task, ok := tasks[taskID]; ok {
+			node.Name = task.Name
+			node.Status = task.Status
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	for _, workflow := range workflows {
+		addNode(workflow.TaskID)
+		for _, dep := range workflow.Dependencies {
+			addNode(dep.TaskID)
+			graph.Edges = append(graph.Edges, graphexport.Edge{From: dep.TaskID, To: workflow.TaskID, Type: dep.Type, LagDays: dep.LagDays})
+		}
+	}
+
+	switch format {
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		w.Write([]byte(graphexport.DOT(graph)))
+	case "mermaid":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(graphexport.Mermaid(graph)))
+	case "json":
+		body, err := graphexport.JSONGraph(graph)
+		if err != nil {
+			http.Error(w, "Error encoding graph: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.jgf+json")
+		w.Write(body)
+	case "svg":
+		body, err := graphexport.SVG(r.Context(), graph)
+		if errors.Is(err, graphexport.ErrRendererMissing) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write(body)
+	}
+}
+
+// GetTaskImpactHandler vraća sve zadatke koji posredno ili direktno zavise od
+// zadatka, njihove članove i koliko dana mogu da kasne pre nego što pomere
+// završetak projekta.
+func (h *WorkflowHandler) GetTaskImpactHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["task_id"]
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
+		h.logger.Println("No Authorization header:", authHeader)
+		return
+	}
+
+	// Expect the format "Bearer <token>"
+	token := ""
+
+	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
+		token = authHeader[7:]
+	} else {
+		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+		h.logger.Println("Invalid Authorization header format:", authHeader)
+		return
+	}
+
+	projectID, downstream, err := h.repo.GetDownstreamTasks(r.Context(), taskID)
+	if errors.Is(err, repoWorkflow.ErrWorkflowNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error fetching downstream tasks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Slack se računa nad celim projektom; zadaci bez procene trajanja ga nemaju
+	tasks, edges, unestimated, err := h.repo.GetProjectNetwork(r.Context(), projectID)
+	if err != nil {
+		http.Error(w, "Error fetching workflows: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result, err := schedule.CriticalPath(tasks, edges)
+	if err != nil {
+		http.Error(w, "Error computing schedule: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	slack := map[string]int{}
+	for _, task := range result.Tasks {
+		slack[task.TaskID] = task.Slack
+	}
+	for _, id := range unestimated {
+		delete(slack, id)
+	}
+	slackOf := func(id string) *int {
+		if days, ok := slack[id]; ok {
+			return &days
+		}
+		return nil
+	}
+
+	for i := range downstream {
+		downstream[i].SlackDays = slackOf(downstream[i].TaskID)
+	}
+	unresolved := h.annotateRelatedTasks(projectID, downstream, token)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id":    taskID,
+		"project_id": projectID,
+		"slack_days": slackOf(taskID),
+		"downstream": downstream,
+		"unresolved": unresolved,
+	})
+}
+
+// GetTaskBlockersHandler objašnjava zašto je zadatak blokiran: vraća
+// nezavršene zadatke od kojih posredno ili direktno zavisi i njihove članove.
+func (h *WorkflowHandler) GetTaskBlockersHandler(w http.ResponseWriter, r *http.Request) {
+	taskID := mux.Vars(r)["task_id"]
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		http.Error(w, "No Authorization header found", http.StatusUnauthorized)
+		h.logger.Println("No Authorization header:", authHeader)
+		return
+	}
+
+	// Expect the format "Bearer <token>"
+	token := ""
+
+	if len(authHeader) > 7 && strings.ToLower(authHeader[:7]) == "bearer " {
+		token = authHeader[7:]
+	} else {
+		http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+		h.logger.Println("Invalid Authorization header format:", authHeader)
+		return
+	}
+
+	projectID, upstream, err := h.repo.GetUpstreamTasks(r.Context(), taskID)
+	if errors.Is(err, repoWorkflow.ErrWorkflowNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error fetching upstream tasks: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	unresolved := h.annotateRelatedTasks(projectID, upstream, token)
+
+	// Zadatak čiji status nije poznat ne računa se kao blokada, već se
+	// prijavljuje posebno u unresolved
+	blockers := []models.RelatedTask{}
+	for _, task := range upstream {
+		if task.Status != "" && task.Status != "done" {
+			blockers = append(blockers, task)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"task_id":    taskID,
+		"project_id": projectID,
+		"blocked":    len(blockers) > 0,
+		"blockers":   blockers,
+		"unresolved": unresolved,
+	})
+}
+
+// annotateRelatedTasks dopunjuje zadatke imenom, statusom i članovima. Zadaci
+// projekta se dohvataju jednim zahtevom ka task-service, a svaki član jednom.
+// Vraća ID-eve zadataka koje task-service nije vratio; oni ostaju samo sa ID-em.
+func (h *WorkflowHandler) annotateRelatedTasks(projectID string, tasks []models.RelatedTask, token string) []string {
+	unresolved := []string{}
+	if len(tasks) == 0 {
+		return unresolved
+	}
+	projectTasks, err := repoWorkflow.GetProjectTasks(projectID, token)
+	if err != nil {
+		h.logger.Println(err)
+	}
+
+	userIDs := []string{}
+	seen := map[string]bool{}
+	for _, related := range tasks {
+		for _, userID := range projectTasks[related.TaskID].Users {
+			if !seen[userID] {
+				seen[userID] = true
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+	members, errs := repoWorkflow.GetMembers(userIDs, token)
+	for _, err := range errs {
+		h.logger.Println(err)
+	}
+
+	for i := range tasks {
+		task, ok := projectTasks[tasks[i].TaskID]
+		if !ok {
+			unresolved = append(unresolved, tasks[i].TaskID)
+			continue
+		}
+		tasks[i].Name = task.Name
+		tasks[i].Status = task.Status
+		for _, userID := range task.Users {
+			// Član kog user-service ne vrati ostaje samo sa ID-em
+			member, ok := members[userID]
+			if !ok {
+				member = models.Member{ID: userID}
+			}
+			tasks[i].Members = append(tasks[i].Members, member)
+		}
+	}
+	return unresolved
+}
+
 // DeleteWorkflowByTaskIDHandler je HTTP handler za brisanje workflow-a na osnovu taskID-a iz URL-a.
 func (h *WorkflowHandler) DeleteWorkflowByTaskIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Dohvati taskID iz URL parametara
@@ -39,9 +39,9 @@ func main() {
 	repo := repoWorkflow.NewWorkflowRepository(driver)
 	workflowHandler := handler.NewWorkflowHandler(repo, logger)
 
-	log.Println("Clearing database...")
-	if err := repo.ClearDatabase(context.Background()); err != nil {
-		log.Fatalf("Error clearing database: %v", err)
+	// Zavisnosti iz starijih verzija su bile nizovi na čvoru
+	if err := repo.MigrateDependencyGraph(context.Background()); err != nil {
+		log.Fatalf("Error migrating dependency graph: %v", err)
 	}
 
 	r := mux.NewRouter()
@@ -52,14 +52,23 @@ func main() {
 	r.HandleFunc("/workflow/getTaskById/{id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetTaskByIDHandler, "Manager", "Member"))).Methods("GET")
 	r.HandleFunc("/workflow/check-dependency/{task_id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.CheckDependencyHandler, "Manager"))).Methods("GET")
 	r.HandleFunc("/workflow/{task_id}/dependencies", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetTaskDependenciesHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/workflow/{task_id}/dependencies", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.EditDependenciesHandler, "Manager"))).Methods("PATCH")
+	r.HandleFunc("/workflow/{task_id}/dependencies/{dependency_id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.ReplaceDependencyHandler, "Manager"))).Methods("PUT")
+	r.HandleFunc("/workflow/{task_id}/dependencies/{dependency_id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.RemoveDependencyHandler, "Manager"))).Methods("DELETE")
+	r.HandleFunc("/workflow/{task_id}/depends-on/{other_task_id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetDependencyPathHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/workflow/{task_id}/impact", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetTaskImpactHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/workflow/{task_id}/blockers", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetTaskBlockersHandler, "Manager", "Member"))).Methods("GET")
 	r.HandleFunc("/workflow/project/{project_id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetFlowByProjectIDHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/workflow/project/{project_id}/graph", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.ExportProjectGraphHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/workflow/project/{project_id}/schedule", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetProjectScheduleHandler, "Manager", "Member"))).Methods("GET")
+	r.HandleFunc("/workflow/{task_id}/duration", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.SetTaskDurationHandler, "Manager"))).Methods("PUT")
 	r.HandleFunc("/workflow/delete/{task_id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.DeleteWorkflowByTaskIDHandler, "Manager"))).Methods("DELETE")
 	r.HandleFunc("/workflow/check/{task_id}", workflowHandler.MiddlewareExtractUserFromHeader(workflowHandler.RoleRequired(workflowHandler.GetWorkflowByTaskIDHandler, "Manager", "Member"))).Methods("GET")
 
 	// Konfiguracija CORS-a
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:4200"}, // URL frontend aplikacije
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Content-Type", "Authorization"},
 		AllowCredentials: true,
 		Debug:            true, // Pomaže u debagovanju CORS problema
@@ -2,10 +2,36 @@ package models
 
 import "github.com/google/uuid"
 
+// Vrste zavisnosti između zadataka.
+const (
+	FinishToStart  = "FS"
+	StartToStart   = "SS"
+	FinishToFinish = "FF"
+	StartToFinish  = "SF"
+)
+
 type Workflow struct {
-	ID             uuid.UUID `json:"id"`
-	TaskID         string    `json:"task_id"`
-	ProjectID      string    `json:"project_id"`
-	DependencyTask []string  `json:"dependency_task"`
-	IsActive       bool      `json:"is_active"`
+	ID             uuid.UUID    `json:"id"`
+	TaskID         string       `json:"task_id"`
+	ProjectID      string       `json:"project_id"`
+	DependencyTask []string     `json:"dependency_task"`
+	Dependencies   []Dependency `json:"dependencies,omitempty"`
+	IsActive       bool         `json:"is_active"`
+}
+
+// Dependency je veza ka zadatku od kog zadatak zavisi. LagDays pomera
+// ograničenje za dati broj dana; negativna vrednost je prednost (lead).
+type Dependency struct {
+	TaskID  string `json:"task_id"`
+	Type    string `json:"type"`
+	LagDays int    `json:"lag_days"`
+}
+
+// IsValidDependencyType proverava da li je vrsta zavisnosti podržana.
+func IsValidDependencyType(dependencyType string) bool {
+	switch dependencyType {
+	case FinishToStart, StartToStart, FinishToFinish, StartToFinish:
+		return true
+	}
+	return false
 }
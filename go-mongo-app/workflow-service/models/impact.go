@@ -0,0 +1,22 @@
+package models
+
+// Member je korisnik dodeljen zadatku, kako ga vraća task-service.
+type Member struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Surname  string `json:"surname"`
+}
+
+// RelatedTask je zadatak koji posredno zavisi od datog zadatka, ili od kog
+// dati zadatak zavisi. Depth je broj veza na najkraćem putu između njih, a
+// Dependency je popunjen samo za direktne veze (Depth 1).
+type RelatedTask struct {
+	TaskID     string      `json:"task_id"`
+	Name       string      `json:"name,omitempty"`
+	Status     string      `json:"status,omitempty"`
+	Depth      int         `json:"depth"`
+	Dependency *Dependency `json:"dependency,omitempty"`
+	SlackDays  *int        `json:"slack_days,omitempty"`
+	Members    []Member    `json:"members"`
+}
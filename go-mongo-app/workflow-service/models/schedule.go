@@ -0,0 +1,24 @@
+package models
+
+import "workflow-service/schedule"
+
+// ProjectSchedule je raspored projekta izračunat metodom kritičnog puta.
+type ProjectSchedule struct {
+	ProjectID          string          `json:"project_id"`
+	StartDate          string          `json:"start_date"`
+	FinishDate         string          `json:"finish_date"`
+	DurationDays       int             `json:"duration_days"`
+	ExpectedEndDate    string          `json:"expected_end_date,omitempty"`
+	ExceedsExpectedEnd bool            `json:"exceeds_expected_end"`
+	OverrunDays        int             `json:"overrun_days,omitempty"`
+	CriticalPath       []string        `json:"critical_path"`
+	Tasks              []ScheduledTask `json:"tasks"`
+	Unestimated        []string        `json:"unestimated"`
+}
+
+// ScheduledTask je zadatak iz rasporeda sa najranijim datumima početka i završetka.
+type ScheduledTask struct {
+	schedule.TaskSchedule
+	StartDate  string `json:"start_date"`
+	FinishDate string `json:"finish_date"`
+}
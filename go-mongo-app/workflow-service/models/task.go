@@ -1,8 +1,9 @@
 package models
 
 type Task struct {
-	ID          string `bson:"_id,omitempty" json:"id"`
-	Name        string `bson:"name" json:"name"`
-	Description string `bson:"description" json:"description"`
-	Status      string `bson:"status" json:"status"`
+	ID          string   `bson:"_id,omitempty" json:"id"`
+	Name        string   `bson:"name" json:"name"`
+	Description string   `bson:"description" json:"description"`
+	Status      string   `bson:"status" json:"status"`
+	Users       []string `bson:"users" json:"users"`
 }
@@ -0,0 +1,205 @@
+package repoWorkflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"workflow-service/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+var (
+	ErrWorkflowNotFound   = errors.New("workflow not found")
+	ErrDependencyNotFound = errors.New("dependency not found")
+	ErrTaskSyncFailed     = errors.New("task-service was not updated")
+)
+
+// DependencyEdit je izmena zavisnosti jednog zadatka. Prvo se uklanjaju veze
+// iz Remove, pa se dodaju (ili menjaju) veze iz Add, tako da zamena veze ne
+// prijavljuje lažni ciklus. ProjectID je potreban samo ako zadatak još nema čvor.
+type DependencyEdit struct {
+	ProjectID string              `json:"project_id"`
+	Add       []models.Dependency `json:"add"`
+	Remove    []string            `json:"remove"`
+}
+
+// EditDependencies primenjuje izmenu u jednoj transakciji i vraća zavisnosti
+// zadatka posle izmene. Nove zavisnosti se prepisuju u task-service pre
+// potvrde transakcije, pa neuspelo prepisivanje poništava izmenu grafa, a
+// neuspela potvrda vraća task-service na prethodne zavisnosti.
+func (r *WorkflowRepository) EditDependencies(ctx context.Context, taskID string, edit DependencyEdit, token string) ([]models.Dependency, error) {
+	for _, dep := range edit.Add {
+		exists, err := taskExists(dep.TaskID, token)
+		if err != nil {
+			return nil, fmt.Errorf("error checking if dependency task exists: %v", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("dependency task with task_id %s does not exist", dep.TaskID)
+		}
+	}
+
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	var previous []models.Dependency
+	synced := false
+	dependencies, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		projectID, err := workflowProjectID(tx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		previous, err = readDependencies(tx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if projectID == "" {
+			if len(edit.Add) == 0 || edit.ProjectID == "" {
+				return nil, ErrWorkflowNotFound
+			}
+			projectID = edit.ProjectID
+		}
+
+		for _, depID := range edit.Remove {
+			result, err := tx.Run(
+				`MATCH (:Workflow {task_id: $task_id})-[rel:DEPENDS_ON]->(:Workflow {task_id: $dep_id})
+				 DELETE rel
+				 RETURN count(rel) AS removed`,
+				map[string]interface{}{
+					"task_id": taskID,
+					"dep_id":  depID,
+				},
+			)
+			if err != nil {
+				return nil, fmt.Errorf("error removing dependency %s: %v", depID, err)
+			}
+			record, err := result.Single()
+			if err != nil {
+				return nil, err
+			}
+			if removed, _ := record.Get("removed"); removed.(int64) == 0 {
+				return nil, fmt.Errorf("%w: %s -> %s", ErrDependencyNotFound, taskID, depID)
+			}
+		}
+
+		if len(edit.Add) > 0 {
+			if err := mergeTaskNode(tx, taskID, projectID, true); err != nil {
+				return nil, err
+			}
+		}
+		for _, dep := range edit.Add {
+			if err := checkNewDependency(tx, taskID, dep.TaskID); err != nil {
+				return nil, err
+			}
+			if err := mergeTaskNode(tx, dep.TaskID, projectID, true); err != nil {
+				return nil, err
+			}
+			_, err := tx.Run(
+				`MATCH (w:Workflow {task_id: $task_id}), (d:Workflow {task_id: $dep_id})
+				 MERGE (w)-[rel:DEPENDS_ON]->(d)
+				 SET rel.type = $type, rel.lag_days = $lag_days`,
+				map[string]interface{}{
+					"task_id":  taskID,
+					"dep_id":   dep.TaskID,
+					"type":     dep.Type,
+					"lag_days": dep.LagDays,
+				},
+			)
+			if err != nil {
+				return nil, fmt.Errorf("error adding dependency %s: %v", dep.TaskID, err)
+			}
+		}
+
+		dependencies, err := readDependencies(tx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if err := SyncTaskDependsOn(taskID, dependencies, token); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrTaskSyncFailed, err)
+		}
+		synced = true
+		return dependencies, nil
+	})
+	if err != nil {
+		if synced {
+			if syncErr := SyncTaskDependsOn(taskID, previous, token); syncErr != nil {
+				return nil, fmt.Errorf("%v; restoring task-service dependencies failed: %v", err, syncErr)
+			}
+		}
+		return nil, err
+	}
+	return dependencies.([]models.Dependency), nil
+}
+
+// readDependencies vraća trenutne zavisnosti zadatka unutar transakcije.
+func readDependencies(tx neo4j.Transaction, taskID string) ([]models.Dependency, error) {
+	result, err := tx.Run(
+		`MATCH (w:Workflow {task_id: $task_id})-[rel:DEPENDS_ON]->(d:Workflow)
+		 RETURN collect(`+dependencyFields+`) AS dependencies`,
+		map[string]interface{}{"task_id": taskID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error reading dependencies: %v", err)
+	}
+	record, err := result.Single()
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := record.Get("dependencies")
+	return toDependencies(raw), nil
+}
+
+// workflowProjectID vraća projekat čvora zadatka, ili prazan string ako čvor ne postoji.
+func workflowProjectID(tx neo4j.Transaction, taskID string) (string, error) {
+	result, err := tx.Run(
+		`MATCH (w:Workflow {task_id: $task_id}) RETURN w.project_id AS project_id`,
+		map[string]interface{}{"task_id": taskID},
+	)
+	if err != nil {
+		return "", fmt.Errorf("error finding workflow: %v", err)
+	}
+	if !result.Next() {
+		return "", result.Err()
+	}
+	raw, _ := result.Record().Get("project_id")
+	projectID, _ := raw.(string)
+	return projectID, nil
+}
+
+// SyncTaskDependsOn prepisuje zavisnosti zadatka u task-service, koji ih
+// čuva u Task.DependsOn i beleži izmenu kao događaj.
+func SyncTaskDependsOn(taskID string, dependencies []models.Dependency, token string) error {
+	dependsOn := []string{}
+	for _, dep := range dependencies {
+		dependsOn = append(dependsOn, dep.TaskID)
+	}
+	jsonData, err := json.Marshal(map[string]interface{}{"dependsOn": dependsOn})
+	if err != nil {
+		return fmt.Errorf("failed to serialize dependencies: %v", err)
+	}
+
+	url := fmt.Sprintf("http://task-service:8080/tasks/%s/dependencies", taskID)
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to task_service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("task-service failed to update dependencies: %s", bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package repoWorkflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"workflow-service/models"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Upiti za zadatke ispod (koji zavise od zadatka) i iznad (od kojih zadatak
+// zavisi). Jedan obilazak grafa nalazi sve puteve do zadatka, a dubina je
+// dužina najkraćeg od njih; rel je direktna veza ako postoji. Graf nema
+// cikluse, pa je broj puteva konačan.
+const (
+	downstreamQuery = `MATCH p = (d:Workflow)-[:DEPENDS_ON*1..]->(t)
+		 WHERE d <> t
+		 WITH t, d, min(length(p)) AS depth
+		 OPTIONAL MATCH (d)-[rel:DEPENDS_ON]->(t)
+		 RETURN d.task_id AS task_id, depth, rel.type AS type, rel.lag_days AS lag_days
+		 ORDER BY depth, task_id`
+	upstreamQuery = `MATCH p = (t)-[:DEPENDS_ON*1..]->(d:Workflow)
+		 WHERE d <> t
+		 WITH t, d, min(length(p)) AS depth
+		 OPTIONAL MATCH (t)-[rel:DEPENDS_ON]->(d)
+		 RETURN d.task_id AS task_id, depth, rel.type AS type, rel.lag_days AS lag_days
+		 ORDER BY depth, task_id`
+)
+
+// memberLookups ograničava broj istovremenih zahteva ka user-service.
+const memberLookups = 8
+
+// GetDownstreamTasks vraća projekat zadatka i sve zadatke koji od njega
+// posredno ili direktno zavise.
+func (r *WorkflowRepository) GetDownstreamTasks(ctx context.Context, taskID string) (string, []models.RelatedTask, error) {
+	return r.relatedTasks(taskID, downstreamQuery)
+}
+
+// GetUpstreamTasks vraća projekat zadatka i sve zadatke od kojih on posredno
+// ili direktno zavisi.
+func (r *WorkflowRepository) GetUpstreamTasks(ctx context.Context, taskID string) (string, []models.RelatedTask, error) {
+	return r.relatedTasks(taskID, upstreamQuery)
+}
+
+func (r *WorkflowRepository) relatedTasks(taskID, query string) (string, []models.RelatedTask, error) {
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	var projectID string
+	related, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		var err error
+		projectID, err = workflowProjectID(tx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if projectID == "" {
+			return nil, ErrWorkflowNotFound
+		}
+
+		result, err := tx.Run(
+			`MATCH (t:Workflow {task_id: $task_id}) `+query,
+			map[string]interface{}{"task_id": taskID},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error running the query: %v", err)
+		}
+
+		tasks := []models.RelatedTask{}
+		for result.Next() {
+			record := result.Record()
+			idRaw, _ := record.Get("task_id")
+			depthRaw, _ := record.Get("depth")
+			typeRaw, _ := record.Get("type")
+			lagRaw, _ := record.Get("lag_days")
+
+			id, _ := idRaw.(string)
+			depth, _ := depthRaw.(int64)
+			task := models.RelatedTask{TaskID: id, Depth: int(depth), Members: []models.Member{}}
+			if depth == 1 {
+				// Veze bez vrste potiču iz vremena pre tipova i važe kao FS
+				dependency := models.Dependency{TaskID: id, Type: models.FinishToStart}
+				if depType, ok := typeRaw.(string); ok && depType != "" {
+					dependency.Type = depType
+				}
+				if lag, ok := lagRaw.(int64); ok {
+					dependency.LagDays = int(lag)
+				}
+				task.Dependency = &dependency
+			}
+			tasks = append(tasks, task)
+		}
+		if err := result.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating over the result: %v", err)
+		}
+		return tasks, nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return projectID, related.([]models.RelatedTask), nil
+}
+
+// GetProjectTasks dohvata sve zadatke projekta iz task-service jednim
+// zahtevom, mapirane po ID-u zadatka.
+func GetProjectTasks(projectID string, token string) (map[string]models.Task, error) {
+	url := fmt.Sprintf("http://task-service:8080/tasks/projects/%s/board", projectID)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request to task-service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tasks of project %s not available, received status %v from task-service", projectID, resp.StatusCode)
+	}
+
+	var board struct {
+		Tasks []models.Task `json:"tasks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&board); err != nil {
+		return nil, fmt.Errorf("error decoding project tasks: %v", err)
+	}
+	tasks := make(map[string]models.Task, len(board.Tasks))
+	for _, task := range board.Tasks {
+		tasks[task.ID] = task
+	}
+	return tasks, nil
+}
+
+// GetMembers dohvata korisnike iz user-service, najviše memberLookups
+// istovremeno. Korisnik koji se ne može dohvatiti izostaje iz rezultata, a
+// greška se vraća u listi grešaka.
+func GetMembers(userIDs []string, token string) (map[string]models.Member, []error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		members = map[string]models.Member{}
+		errs    []error
+		slots   = make(chan struct{}, memberLookups)
+	)
+	for _, userID := range userIDs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(userID string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			member, err := getMember(userID, token)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			members[userID] = *member
+		}(userID)
+	}
+	wg.Wait()
+	return members, errs
+}
+
+func getMember(userID string, token string) (*models.Member, error) {
+	url := fmt.Sprintf("http://user-service:8080/users/%s", userID)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making request to user-service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user %s not available, received status %v from user-service", userID, resp.StatusCode)
+	}
+
+	var member models.Member
+	if err := json.NewDecoder(resp.Body).Decode(&member); err != nil {
+		return nil, fmt.Errorf("error decoding user %s: %v", userID, err)
+	}
+	return &member, nil
+}
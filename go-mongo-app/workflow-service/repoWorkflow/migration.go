@@ -0,0 +1,58 @@
+package repoWorkflow
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// MigrateDependencyGraph pretvara zavisnosti sačuvane kao dependency_task
+// niz na Workflow čvoru u DEPENDS_ON veze i postavlja ograničenje da svaki
+// zadatak ima jedan čvor. Može se bezbedno pokretati pri svakom startu.
+func (r *WorkflowRepository) MigrateDependencyGraph(ctx context.Context) error {
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	migrated, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(
+			`MATCH (w:Workflow)
+			 WHERE w.dependency_task IS NOT NULL
+			 UNWIND w.dependency_task AS dep_id
+			 MERGE (d:Workflow {task_id: dep_id})
+			   ON CREATE SET d.id = randomUUID(), d.project_id = w.project_id, d.is_active = true
+			 MERGE (w)-[:DEPENDS_ON]->(d)
+			 RETURN count(*) AS migrated`,
+			nil,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error converting dependency_task arrays: %v", err)
+		}
+		record, err := result.Single()
+		if err != nil {
+			return nil, err
+		}
+		count, _ := record.Get("migrated")
+
+		// Niz se briše tek kada su sve veze napravljene
+		if _, err := tx.Run(`MATCH (w:Workflow) WHERE w.dependency_task IS NOT NULL REMOVE w.dependency_task`, nil); err != nil {
+			return nil, fmt.Errorf("error removing dependency_task arrays: %v", err)
+		}
+		return count, nil
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Migrated %v array dependencies to DEPENDS_ON relationships", migrated)
+
+	// Šema se menja van transakcije sa podacima
+	_, err = session.Run(
+		`CREATE CONSTRAINT workflow_task_id IF NOT EXISTS FOR (w:Workflow) REQUIRE w.task_id IS UNIQUE`,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating workflow task_id constraint: %v", err)
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 	"workflow-service/models"
 )
@@ -44,150 +45,160 @@ func (r *WorkflowRepository) CreateWorkflow(ctx context.Context, workflow models
 		}
 	}
 
-	session := r.driver.NewSession(neo4j.SessionConfig{})
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close()
 
-	// Proveri postojeće zavisnosti
-	existingDeps := []string{}
-	result, err := session.Run(
-		`MATCH (w:Workflow {task_id: $task_id, project_id: $project_id}) 
-		 RETURN w.dependency_task AS dependency_task`,
-		map[string]interface{}{
-			"task_id":    workflow.TaskID,
-			"project_id": workflow.ProjectID,
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("error checking for existing workflow: %v", err)
-	}
-
-	if result.Next() {
-		if deps, ok := result.Record().Get("dependency_task"); ok {
-			if depList, valid := deps.([]interface{}); valid {
-				for _, dep := range depList {
-					if depStr, isString := dep.(string); isString {
-						existingDeps = append(existingDeps, depStr)
-					}
-				}
-			}
+	// Provera ciklusa i dodavanje veza su u istoj transakciji, pa dva
+	// istovremena zahteva ne mogu zajedno da naprave ciklus
+	_, err = session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		if err := mergeTaskNode(tx, workflow.TaskID, workflow.ProjectID, workflow.IsActive); err != nil {
+			return nil, err
 		}
-	}
 
-	// Kombinuj nove i postojeće zavisnosti
-	newDeps := []string{}
-	for _, dep := range workflow.DependencyTask {
-		if !contains(existingDeps, dep) {
-			newDeps = append(newDeps, dep)
-		}
-	}
-
-	// Simuliraj zavisnosti i proveri ciklus
-	simulatedDeps := append(existingDeps, newDeps...)
-	if err := r.CheckForCycle(ctx, workflow.TaskID, simulatedDeps); err != nil {
-		return fmt.Errorf("cycle detected: %v", err)
-	}
+		for _, dep := range workflow.Dependencies {
+			if err := checkNewDependency(tx, workflow.TaskID, dep.TaskID); err != nil {
+				return nil, err
+			}
+			if err := mergeTaskNode(tx, dep.TaskID, workflow.ProjectID, true); err != nil {
+				return nil, err
+			}
 
-	// Ako nema ciklusa, ažuriraj ili kreiraj čvor
-	if len(existingDeps) > 0 {
-		for _, dep := range newDeps {
-			_, err = session.Run(
-				`MATCH (w:Workflow {task_id: $task_id, project_id: $project_id})
-				 SET w.dependency_task = w.dependency_task + $new_dep`,
+			// Ponovno dodavanje postojeće zavisnosti menja njenu vrstu i kašnjenje
+			_, err := tx.Run(
+				`MATCH (w:Workflow {task_id: $task_id}), (d:Workflow {task_id: $dep_id})
+				 MERGE (w)-[rel:DEPENDS_ON]->(d)
+				 SET rel.type = $type, rel.lag_days = $lag_days`,
 				map[string]interface{}{
-					"task_id":    workflow.TaskID,
-					"project_id": workflow.ProjectID,
-					"new_dep":    dep,
+					"task_id":  workflow.TaskID,
+					"dep_id":   dep.TaskID,
+					"type":     dep.Type,
+					"lag_days": dep.LagDays,
 				},
 			)
 			if err != nil {
-				return fmt.Errorf("error adding dependency_task: %v", err)
+				return nil, fmt.Errorf("error adding dependency %s: %v", dep.TaskID, err)
 			}
 		}
-	} else {
-		_, err := session.Run(
-			`CREATE (w:Workflow {id: $id, task_id: $task_id, dependency_task: $dependency_task, project_id: $project_id, is_active: $is_active})`,
-			map[string]interface{}{
-				"id":              uuid.New().String(),
-				"task_id":         workflow.TaskID,
-				"dependency_task": workflow.DependencyTask,
-				"project_id":      workflow.ProjectID,
-				"is_active":       workflow.IsActive,
-			},
-		)
-		if err != nil {
-			return fmt.Errorf("error creating workflow: %v", err)
-		}
-	}
+		return nil, nil
+	})
+
+	return err
+}
 
+// mergeTaskNode pravi čvor zadatka ako još ne postoji.
+func mergeTaskNode(tx neo4j.Transaction, taskID, projectID string, isActive bool) error {
+	_, err := tx.Run(
+		`MERGE (w:Workflow {task_id: $task_id})
+		 ON CREATE SET w.id = $id, w.project_id = $project_id, w.is_active = $is_active`,
+		map[string]interface{}{
+			"id":         uuid.New().String(),
+			"task_id":    taskID,
+			"project_id": projectID,
+			"is_active":  isActive,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error creating workflow for task %s: %v", taskID, err)
+	}
 	return nil
 }
 
-// Pomoćna funkcija za proveru postojanja elementa u listi
-func contains(slice []string, item string) bool {
-	for _, v := range slice {
-		if v == item {
-			return true
-		}
+// CycleError je greška kada bi nova zavisnost zatvorila ciklus. Path počinje
+// i završava se istim zadatkom.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("cyclic dependency detected: %s", strings.Join(e.Path, " -> "))
+}
+
+// checkNewDependency proverava da li zavisnost taskID -> depID zatvara ciklus,
+// tj. da li depID već (posredno) zavisi od taskID.
+func checkNewDependency(tx neo4j.Transaction, taskID, depID string) error {
+	if taskID == depID {
+		return &CycleError{Path: []string{taskID, taskID}}
+	}
+
+	path, err := dependencyPath(tx, depID, taskID)
+	if err != nil {
+		return err
 	}
-	return false
+	if path != nil {
+		return &CycleError{Path: append([]string{taskID}, path...)}
+	}
+	return nil
 }
 
-// Funkcija za proveru ciklusa i postavljanje is_active na false ako je potrebno
-func (r *WorkflowRepository) checkAndHandleCycle(createdWorkflow models.Workflow) error {
-	session := r.driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
+// dependencyPath vraća najkraći lanac zavisnosti od fromTaskID do toTaskID,
+// ili nil ako fromTaskID ne zavisi od toTaskID.
+func dependencyPath(tx neo4j.Transaction, fromTaskID, toTaskID string) ([]string, error) {
+	result, err := tx.Run(
+		`MATCH (from:Workflow {task_id: $from}), (to:Workflow {task_id: $to})
+		 MATCH path = shortestPath((from)-[:DEPENDS_ON*]->(to))
+		 RETURN [n IN nodes(path) | n.task_id] AS path`,
+		map[string]interface{}{
+			"from": fromTaskID,
+			"to":   toTaskID,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error checking dependency path: %v", err)
+	}
 
-	// Prolazimo kroz sve zavisnosti trenutnog workflow-a
-	for _, depTaskID := range createdWorkflow.DependencyTask {
-		// Proveravamo ciklus u zavisnostima
-		result, err := session.Run(
-			`MATCH (start:Workflow {task_id: $task_id}),
-					  (end:Workflow {task_id: $dep_task_id})
-			 MATCH path = (start)-[:DEPENDS_ON*]->(end)
-			 WHERE start <> end
-			 RETURN COUNT(path) AS path_count`,
-			map[string]interface{}{
-				"task_id":     createdWorkflow.TaskID,
-				"dep_task_id": depTaskID,
-			},
-		)
-		if err != nil {
-			return fmt.Errorf("error checking for cycle: %v", err)
-		}
+	if !result.Next() {
+		return nil, result.Err()
+	}
+	raw, _ := result.Record().Get("path")
+	return toStringSlice(raw), nil
+}
 
-		// Ako postoji ciklus, postavljamo is_active na false za oba workflow-a
-		if result.Next() {
-			pathCount, _ := result.Record().Get("path_count")
-			if pathCount.(int64) > 0 {
-				log.Printf("Cyclic dependency detected between task %s and %s", createdWorkflow.TaskID, depTaskID)
-
-				// Ako postoji ciklus, postavljamo is_active na false za oba workflow-a
-				_, err := session.Run(
-					`MATCH (w:Workflow {task_id: $task_id})
-					 SET w.is_active = false`,
-					map[string]interface{}{
-						"task_id": createdWorkflow.TaskID,
-					},
-				)
-				if err != nil {
-					return fmt.Errorf("error setting is_active=false for task %s: %v", createdWorkflow.TaskID, err)
-				}
+// dependencyFields vraća zavisnosti kao mape za collect(); veze iz starijih
+// verzija nemaju vrstu pa se tretiraju kao finish-to-start bez kašnjenja.
+const dependencyFields = `{task_id: d.task_id, type: coalesce(rel.type, 'FS'), lag_days: coalesce(rel.lag_days, 0)}`
 
-				return fmt.Errorf("Cyclic dependency detected, new workflow is deactivated")
-			}
+// toDependencies pretvara listu mapa iz Neo4j rezultata u zavisnosti.
+func toDependencies(raw interface{}) []models.Dependency {
+	list, _ := raw.([]interface{})
+	out := make([]models.Dependency, 0, len(list))
+	for _, item := range list {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
 		}
+		dep := models.Dependency{}
+		dep.TaskID, _ = fields["task_id"].(string)
+		dep.Type, _ = fields["type"].(string)
+		if lag, ok := fields["lag_days"].(int64); ok {
+			dep.LagDays = int(lag)
+		}
+		out = append(out, dep)
 	}
+	return out
+}
 
-	return nil
+// toStringSlice pretvara listu iz Neo4j rezultata u []string.
+func toStringSlice(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		if str, ok := item.(string); ok {
+			out = append(out, str)
+		}
+	}
+	return out
 }
 
 func (r *WorkflowRepository) GetAllWorkflows(ctx context.Context) ([]*models.Workflow, error) {
 	session := r.driver.NewSession(neo4j.SessionConfig{})
 	defer session.Close()
 
-	// Izmenjeni Cypher upit da vraća i project_id
+	// Zadaci bez zavisnosti se takođe vraćaju, sa praznom listom
 	result, err := session.Run(
-		"MATCH (w:Workflow) RETURN w.id AS id, w.task_id AS task_id, w.dependency_task AS dependency_task, w.is_active AS is_active, w.project_id AS project_id",
+		`MATCH (w:Workflow)
+		 OPTIONAL MATCH (w)-[rel:DEPENDS_ON]->(d:Workflow)
+		 RETURN w.id AS id, w.task_id AS task_id, collect(d.task_id) AS dependency_task,
+		        collect(CASE WHEN d IS NULL THEN NULL ELSE `+dependencyFields+` END) AS dependencies, w.is_active AS is_active, w.project_id AS project_id`,
 		nil, // Nema parametara jer želimo sve workflow-e
 	)
 	if err != nil {
@@ -258,10 +269,13 @@ func (r *WorkflowRepository) GetAllWorkflows(ctx context.Context) ([]*models.Wor
 			return nil, fmt.Errorf("invalid type for project_id: expected string, got %T", projectIDRaw)
 		}
 
+		dependenciesRaw, _ := record.Get("dependencies")
+
 		workflow := &models.Workflow{
 			ID:             uuid.MustParse(id), // Konvertujemo string u UUID
 			TaskID:         taskID,
 			DependencyTask: dependencyTask, // Niz zavisnih taskova
+			Dependencies:   toDependencies(dependenciesRaw),
 			IsActive:       isActive,
 			ProjectID:      projectID, // Dodajemo project_id
 		}
@@ -340,7 +354,9 @@ func (r *WorkflowRepository) CheckTaskDependency(ctx context.Context, taskID str
 
 	// Upit koji proverava da li postoji neki dependency task u workflow-u
 	result, err := session.Run(
-		"MATCH (w:Workflow) WHERE w.task_id = $task_id AND ANY(dep IN w.dependency_task WHERE dep <> $task_id) RETURN w.dependency_task AS dependency_tasks",
+		`MATCH (w:Workflow {task_id: $task_id})-[:DEPENDS_ON]->(d:Workflow)
+		 WHERE d.task_id <> $task_id
+		 RETURN collect(d.task_id) AS dependency_tasks`,
 		map[string]interface{}{"task_id": taskID},
 	)
 	if err != nil {
@@ -364,78 +380,43 @@ func (r *WorkflowRepository) CheckTaskDependency(ctx context.Context, taskID str
 	return false, nil
 }
 
+// CheckForCycle proverava da li bi nove zavisnosti zadatka napravile ciklus.
+// Vraća *CycleError sa putanjom ciklusa.
 func (r *WorkflowRepository) CheckForCycle(ctx context.Context, startTaskID string, dependencies []string) error {
-	// Kreiraj graf iz baze
-	graph := make(map[string][]string)
-
-	// Popuni graf zavisnostima iz baze
-	session := r.driver.NewSession(neo4j.SessionConfig{})
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close()
 
-	result, err := session.Run(`MATCH (w:Workflow) RETURN w.task_id AS task_id, w.dependency_task AS dependency_task`, nil)
-	if err != nil {
-		return fmt.Errorf("error fetching workflows: %v", err)
-	}
-
-	for result.Next() {
-		// Umesto GetByIndex, koristi Get
-		taskID, ok := result.Record().Get("task_id")
-		if !ok {
-			return fmt.Errorf("task_id not found in record")
-		}
-
-		deps, ok := result.Record().Get("dependency_task")
-		if !ok {
-			return fmt.Errorf("dependency_task not found in record")
-		}
-
-		// Pretpostavimo da je deps lista (ako nije, moraćeš da prilagodiš ovo)
-		if depList, valid := deps.([]interface{}); valid {
-			for _, dep := range depList {
-				if depStr, isString := dep.(string); isString {
-					graph[taskID.(string)] = append(graph[taskID.(string)], depStr)
-				}
+	_, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		for _, dep := range dependencies {
+			if err := checkNewDependency(tx, startTaskID, dep); err != nil {
+				return nil, err
 			}
 		}
-	}
-
-	// Dodaj novu zavisnost u graf za privremenu proveru
-	graph[startTaskID] = append(graph[startTaskID], dependencies...)
-
-	// Pokreni DFS
-	visited := make(map[string]bool)
-	stack := make(map[string]bool)
-
-	var dfs func(task string) bool
-	dfs = func(task string) bool {
-		if stack[task] {
-			return true // Ciklus pronađen
-		}
-		if visited[task] {
-			return false
-		}
-
-		visited[task] = true
-		stack[task] = true
+		return nil, nil
+	})
+	return err
+}
 
-		for _, neighbor := range graph[task] {
-			if dfs(neighbor) {
-				return true
-			}
-		}
+// DependencyPath vraća lanac zavisnosti kojim taskID (posredno) zavisi od
+// otherTaskID, ili nil ako ne zavisi.
+func (r *WorkflowRepository) DependencyPath(ctx context.Context, taskID, otherTaskID string) ([]string, error) {
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
 
-		stack[task] = false
-		return false
+	if taskID == otherTaskID {
+		return nil, nil
 	}
 
-	if dfs(startTaskID) {
-		return fmt.Errorf("cyclic dependency detected")
+	path, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		return dependencyPath(tx, taskID, otherTaskID)
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return nil
+	return path.([]string), nil
 }
 
-func (r *WorkflowRepository) GetTaskDependencies(ctx context.Context, taskID string) ([]string, error) {
+func (r *WorkflowRepository) GetTaskDependencies(ctx context.Context, taskID string) ([]models.Dependency, error) {
 	session := r.driver.NewSession(neo4j.SessionConfig{})
 	defer session.Close()
 
@@ -444,7 +425,8 @@ func (r *WorkflowRepository) GetTaskDependencies(ctx context.Context, taskID str
 
 	// Upit za pronalazak zavisnih taskova
 	result, err := session.Run(
-		"MATCH (w:Workflow) WHERE w.task_id = $task_id RETURN w.dependency_task AS dependency_tasks",
+		`MATCH (w:Workflow {task_id: $task_id})-[rel:DEPENDS_ON]->(d:Workflow)
+		 RETURN collect(`+dependencyFields+`) AS dependencies`,
 		map[string]interface{}{"task_id": taskID},
 	)
 	if err != nil {
@@ -452,20 +434,12 @@ func (r *WorkflowRepository) GetTaskDependencies(ctx context.Context, taskID str
 		return nil, fmt.Errorf("error running the query: %v", err)
 	}
 
-	var dependencies []string
+	dependencies := []models.Dependency{}
 
 	// Obrada rezultata
 	if result.Next() {
-		dependencyTasks, _ := result.Record().Get("dependency_tasks")
-		if dependencyTasks != nil {
-			if tasks, ok := dependencyTasks.([]interface{}); ok {
-				for _, task := range tasks {
-					if taskStr, ok := task.(string); ok {
-						dependencies = append(dependencies, taskStr)
-					}
-				}
-			}
-		}
+		raw, _ := result.Record().Get("dependencies")
+		dependencies = toDependencies(raw)
 	}
 
 	if err = result.Err(); err != nil {
@@ -476,17 +450,20 @@ func (r *WorkflowRepository) GetTaskDependencies(ctx context.Context, taskID str
 	log.Printf("Zavisni taskovi za task_id %s: %v", taskID, dependencies)
 	return dependencies, nil
 }
+
 func (r *WorkflowRepository) GetAllWorkflowsByProjectID(ctx context.Context, projectID string) ([]*models.Workflow, error) {
 	session := r.driver.NewSession(neo4j.SessionConfig{})
 	defer session.Close()
 
 	// Cypher upit za dohvaćanje svih workflow-e po project_id
 	result, err := session.Run(
-		`MATCH (w:Workflow {project_id: $project_id}) 
-         RETURN w.id AS id, 
-                w.task_id AS task_id, 
-                w.dependency_task AS dependency_task, 
-                w.project_id AS project_id, 
+		`MATCH (w:Workflow {project_id: $project_id})
+         OPTIONAL MATCH (w)-[rel:DEPENDS_ON]->(d:Workflow)
+         RETURN w.id AS id,
+                w.task_id AS task_id,
+                collect(d.task_id) AS dependency_task,
+                collect(CASE WHEN d IS NULL THEN NULL ELSE `+dependencyFields+` END) AS dependencies,
+                w.project_id AS project_id,
                 w.is_active AS is_active`,
 		map[string]interface{}{"project_id": projectID},
 	)
@@ -504,6 +481,7 @@ func (r *WorkflowRepository) GetAllWorkflowsByProjectID(ctx context.Context, pro
 		dependencyTaskRaw, _ := record.Get("dependency_task")
 		projectIDRaw, _ := record.Get("project_id")
 		isActiveRaw, _ := record.Get("is_active")
+		dependenciesRaw, _ := record.Get("dependencies")
 
 		id, _ := idRaw.(string)
 		taskID, _ := taskIDRaw.(string)
@@ -523,6 +501,7 @@ func (r *WorkflowRepository) GetAllWorkflowsByProjectID(ctx context.Context, pro
 			ID:             uuid.MustParse(id),
 			TaskID:         taskID,
 			DependencyTask: dependencyTask,
+			Dependencies:   toDependencies(dependenciesRaw),
 			ProjectID:      projectID,
 			IsActive:       isActive,
 		}
@@ -607,6 +586,7 @@ func (r *WorkflowRepository) DeleteWorkflowsByTaskID(taskID string) error {
 		params := map[string]interface{}{
 			"taskID": taskID,
 		}
+		// DETACH DELETE briše i DEPENDS_ON veze ka obrisanom zadatku
 		_, err := tx.Run(query, params)
 		return nil, err
 	})
@@ -0,0 +1,109 @@
+package repoWorkflow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"workflow-service/schedule"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// SetTaskDuration čuva procenu trajanja zadatka u danima.
+func (r *WorkflowRepository) SetTaskDuration(ctx context.Context, taskID, projectID string, durationDays int) error {
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close()
+
+	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+		if err := mergeTaskNode(tx, taskID, projectID, true); err != nil {
+			return nil, err
+		}
+		_, err := tx.Run(
+			`MATCH (w:Workflow {task_id: $task_id}) SET w.duration_days = $duration_days`,
+			map[string]interface{}{
+				"task_id":       taskID,
+				"duration_days": durationDays,
+			},
+		)
+		return nil, err
+	})
+	if err != nil {
+		return fmt.Errorf("error setting duration for task %s: %v", taskID, err)
+	}
+	return nil
+}
+
+// GetProjectNetwork vraća zadatke projekta sa trajanjem i zavisnosti među
+// njima. Zadaci bez procene trajanja vraćaju se i u listi unestimated.
+func (r *WorkflowRepository) GetProjectNetwork(ctx context.Context, projectID string) ([]schedule.Task, []schedule.Edge, []string, error) {
+	session := r.driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close()
+
+	result, err := session.Run(
+		`MATCH (w:Workflow {project_id: $project_id})
+		 OPTIONAL MATCH (w)-[rel:DEPENDS_ON]->(d:Workflow {project_id: $project_id})
+		 RETURN w.task_id AS task_id, w.duration_days AS duration_days,
+		        collect(CASE WHEN d IS NULL THEN NULL ELSE `+dependencyFields+` END) AS dependencies`,
+		map[string]interface{}{"project_id": projectID},
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error running the query: %v", err)
+	}
+
+	tasks := []schedule.Task{}
+	edges := []schedule.Edge{}
+	unestimated := []string{}
+	for result.Next() {
+		record := result.Record()
+		taskIDRaw, _ := record.Get("task_id")
+		durationRaw, _ := record.Get("duration_days")
+		depsRaw, _ := record.Get("dependencies")
+
+		taskID, _ := taskIDRaw.(string)
+		duration, ok := durationRaw.(int64)
+		if !ok {
+			unestimated = append(unestimated, taskID)
+		}
+		tasks = append(tasks, schedule.Task{ID: taskID, DurationDays: int(duration)})
+
+		for _, dep := range toDependencies(depsRaw) {
+			edges = append(edges, schedule.Edge{From: dep.TaskID, To: taskID, Type: dep.Type, LagDays: dep.LagDays})
+		}
+	}
+	if err := result.Err(); err != nil {
+		return nil, nil, nil, fmt.Errorf("error iterating over the result: %v", err)
+	}
+
+	return tasks, edges, unestimated, nil
+}
+
+// GetProjectExpectedEndDate dohvata očekivani datum završetka projekta iz project-service.
+func GetProjectExpectedEndDate(projectID string, token string) (string, error) {
+	url := fmt.Sprintf("http://project-service:8080/projects/%s", projectID)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error making request to project-service: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("project with id %s not found, received status %v from project-service", projectID, resp.StatusCode)
+	}
+
+	var project struct {
+		ExpectedEndDate string `json:"expected_end_date"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return "", fmt.Errorf("error decoding project data: %v", err)
+	}
+	return project.ExpectedEndDate, nil
+}
@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Task is a node of the project network with its estimated duration in days.
+type Task struct {
+	ID           string
+	DurationDays int
+}
+
+// Dependency types between two tasks, named after the events they connect.
+const (
+	FinishToStart  = "FS"
+	StartToStart   = "SS"
+	FinishToFinish = "FF"
+	StartToFinish  = "SF"
+)
+
+// Edge links predecessor From to successor To. Type says which event of From
+// constrains which event of To (finish-to-start when empty) and LagDays
+// delays the constraint; a negative lag is a lead.
+type Edge struct {
+	From    string
+	To      string
+	Type    string
+	LagDays int
+}
+
+// TaskSchedule holds the earliest and latest start and finish of a task as day
+// offsets from the project start, and how many days it can slip.
+type TaskSchedule struct {
+	TaskID         string `json:"task_id"`
+	DurationDays   int    `json:"duration_days"`
+	EarliestStart  int    `json:"earliest_start"`
+	EarliestFinish int    `json:"earliest_finish"`
+	LatestStart    int    `json:"latest_start"`
+	LatestFinish   int    `json:"latest_finish"`
+	Slack          int    `json:"slack"`
+	Critical       bool   `json:"critical"`
+}
+
+// Result is the outcome of the critical path method over a project.
+type Result struct {
+	DurationDays int            `json:"duration_days"`
+	CriticalPath []string       `json:"critical_path"`
+	Tasks        []TaskSchedule `json:"tasks"`
+}
+
+// CriticalPath runs the forward and backward pass over the dependency DAG.
+// Edges pointing at tasks that are not in tasks are ignored.
+func CriticalPath(tasks []Task, edges []Edge) (*Result, error) {
+	byID := make(map[string]*TaskSchedule, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = &TaskSchedule{TaskID: t.ID, DurationDays: t.DurationDays}
+	}
+
+	preds := make(map[string][]Edge)
+	succs := make(map[string][]Edge)
+	for _, e := range edges {
+		if byID[e.From] == nil || byID[e.To] == nil {
+			continue
+		}
+		preds[e.To] = append(preds[e.To], e)
+		succs[e.From] = append(succs[e.From], e)
+	}
+
+	order, err := topologicalOrder(byID, preds, succs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forward pass: a task starts as soon as every predecessor allows it
+	result := &Result{CriticalPath: []string{}, Tasks: []TaskSchedule{}}
+	for _, id := range order {
+		task := byID[id]
+		for _, e := range preds[id] {
+			if es := earliestStartAfter(e, byID[e.From], task.DurationDays); es > task.EarliestStart {
+				task.EarliestStart = es
+			}
+		}
+		task.EarliestFinish = task.EarliestStart + task.DurationDays
+		if task.EarliestFinish > result.DurationDays {
+			result.DurationDays = task.EarliestFinish
+		}
+	}
+
+	// Backward pass: a task finishes late enough that no successor is delayed
+	for i := len(order) - 1; i >= 0; i-- {
+		task := byID[order[i]]
+		task.LatestFinish = result.DurationDays
+		for _, e := range succs[task.TaskID] {
+			if lf := latestFinishBefore(e, byID[e.To], task.DurationDays); lf < task.LatestFinish {
+				task.LatestFinish = lf
+			}
+		}
+		task.LatestStart = task.LatestFinish - task.DurationDays
+		task.Slack = task.LatestStart - task.EarliestStart
+		task.Critical = task.Slack == 0
+	}
+
+	for _, id := range order {
+		result.Tasks = append(result.Tasks, *byID[id])
+	}
+	result.CriticalPath = criticalChain(order, byID, succs)
+	return result, nil
+}
+
+// earliestStartAfter is the earliest start of successor e.To allowed by
+// predecessor pred, whose forward pass is already done.
+func earliestStartAfter(e Edge, pred *TaskSchedule, duration int) int {
+	switch e.Type {
+	case StartToStart:
+		return pred.EarliestStart + e.LagDays
+	case FinishToFinish:
+		return pred.EarliestFinish + e.LagDays - duration
+	case StartToFinish:
+		return pred.EarliestStart + e.LagDays - duration
+	default:
+		return pred.EarliestFinish + e.LagDays
+	}
+}
+
+// latestFinishBefore is the latest finish of predecessor e.From that does not
+// delay successor succ, whose backward pass is already done.
+func latestFinishBefore(e Edge, succ *TaskSchedule, duration int) int {
+	switch e.Type {
+	case StartToStart:
+		return succ.LatestStart - e.LagDays + duration
+	case FinishToFinish:
+		return succ.LatestFinish - e.LagDays
+	case StartToFinish:
+		return succ.LatestFinish - e.LagDays + duration
+	default:
+		return succ.LatestStart - e.LagDays
+	}
+}
+
+// topologicalOrder sorts tasks so every task comes after its predecessors.
+// Ties are broken by ID so the result is stable between calls.
+func topologicalOrder(byID map[string]*TaskSchedule, preds, succs map[string][]Edge) ([]string, error) {
+	remaining := make(map[string]int, len(byID))
+	var ready []string
+	for id := range byID {
+		remaining[id] = len(preds[id])
+		if remaining[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := make([]string, 0, len(byID))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+
+		for _, e := range succs[id] {
+			remaining[e.To]--
+			if remaining[e.To] == 0 {
+				ready = append(ready, e.To)
+			}
+		}
+	}
+
+	if len(order) != len(byID) {
+		return nil, fmt.Errorf("dependency graph contains a cycle")
+	}
+	return order, nil
+}
+
+// criticalChain follows critical tasks from the start of the project along
+// the edges that drive their start. When several critical chains exist, the
+// first one in topological order is returned.
+func criticalChain(order []string, byID map[string]*TaskSchedule, succs map[string][]Edge) []string {
+	chain := []string{}
+	var current *TaskSchedule
+	for _, id := range order {
+		if t := byID[id]; t.Critical && t.EarliestStart == 0 {
+			current = t
+			break
+		}
+	}
+
+	for current != nil {
+		chain = append(chain, current.TaskID)
+		var next *TaskSchedule
+		for _, e := range succs[current.TaskID] {
+			t := byID[e.To]
+			if t.Critical && t.EarliestStart == earliestStartAfter(e, current, t.DurationDays) {
+				if next == nil || t.TaskID < next.TaskID {
+					next = t
+				}
+			}
+		}
+		current = next
+	}
+	return chain
+}
@@ -0,0 +1,133 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCriticalPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		tasks    []Task
+		edges    []Edge
+		wantErr  bool
+		duration int
+		path     []string
+		slack    map[string]int
+	}{
+		{
+			name:     "chain",
+			tasks:    []Task{{"a", 3}, {"b", 2}, {"c", 4}},
+			edges:    []Edge{{From: "a", To: "b"}, {From: "b", To: "c"}},
+			duration: 9,
+			path:     []string{"a", "b", "c"},
+			slack:    map[string]int{"a": 0, "b": 0, "c": 0},
+		},
+		{
+			name:     "parallel branch has slack",
+			tasks:    []Task{{"a", 2}, {"b", 5}, {"c", 1}, {"d", 1}},
+			edges:    []Edge{{From: "a", To: "b"}, {From: "a", To: "c"}, {From: "b", To: "d"}, {From: "c", To: "d"}},
+			duration: 8,
+			path:     []string{"a", "b", "d"},
+			slack:    map[string]int{"a": 0, "b": 0, "c": 4, "d": 0},
+		},
+		{
+			name:     "tie between critical branches picks lowest id",
+			tasks:    []Task{{"a", 2}, {"c", 3}, {"b", 3}, {"d", 1}},
+			edges:    []Edge{{From: "a", To: "c"}, {From: "a", To: "b"}, {From: "c", To: "d"}, {From: "b", To: "d"}},
+			duration: 6,
+			path:     []string{"a", "b", "d"},
+			slack:    map[string]int{"a": 0, "b": 0, "c": 0, "d": 0},
+		},
+		{
+			name:     "zero duration milestone",
+			tasks:    []Task{{"a", 2}, {"m", 0}, {"b", 3}, {"c", 1}},
+			edges:    []Edge{{From: "a", To: "m"}, {From: "m", To: "b"}},
+			duration: 5,
+			path:     []string{"a", "m", "b"},
+			slack:    map[string]int{"a": 0, "m": 0, "b": 0, "c": 4},
+		},
+		{
+			name:     "only zero duration tasks",
+			tasks:    []Task{{"a", 0}, {"b", 0}},
+			edges:    []Edge{{From: "a", To: "b"}},
+			duration: 0,
+			path:     []string{"a", "b"},
+			slack:    map[string]int{"a": 0, "b": 0},
+		},
+		{
+			name:     "start to start with lag",
+			tasks:    []Task{{"a", 4}, {"b", 2}},
+			edges:    []Edge{{From: "a", To: "b", Type: StartToStart, LagDays: 1}},
+			duration: 4,
+			path:     []string{"a"},
+			slack:    map[string]int{"a": 0, "b": 1},
+		},
+		{
+			name:     "finish to finish",
+			tasks:    []Task{{"a", 3}, {"b", 5}},
+			edges:    []Edge{{From: "a", To: "b", Type: FinishToFinish, LagDays: 4}},
+			duration: 7,
+			path:     []string{"a", "b"},
+			slack:    map[string]int{"a": 0, "b": 0},
+		},
+		{
+			name:     "edges to unknown tasks are ignored",
+			tasks:    []Task{{"a", 2}},
+			edges:    []Edge{{From: "a", To: "missing"}, {From: "missing", To: "a"}},
+			duration: 2,
+			path:     []string{"a"},
+			slack:    map[string]int{"a": 0},
+		},
+		{
+			name:    "cycle",
+			tasks:   []Task{{"a", 1}, {"b", 1}},
+			edges:   []Edge{{From: "a", To: "b"}, {From: "b", To: "a"}},
+			wantErr: true,
+		},
+		{
+			name:    "cycle behind a valid start",
+			tasks:   []Task{{"a", 1}, {"b", 1}, {"c", 1}},
+			edges:   []Edge{{From: "a", To: "b"}, {From: "b", To: "c"}, {From: "c", To: "b"}},
+			wantErr: true,
+		},
+		{
+			name:     "empty project",
+			duration: 0,
+			path:     []string{},
+			slack:    map[string]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CriticalPath(tt.tasks, tt.edges)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.DurationDays != tt.duration {
+				t.Errorf("duration = %d, want %d", result.DurationDays, tt.duration)
+			}
+			if !reflect.DeepEqual(result.CriticalPath, tt.path) {
+				t.Errorf("critical path = %v, want %v", result.CriticalPath, tt.path)
+			}
+			slack := map[string]int{}
+			for _, task := range result.Tasks {
+				slack[task.TaskID] = task.Slack
+				if task.Critical != (task.Slack == 0) {
+					t.Errorf("task %s: critical = %v with slack %d", task.TaskID, task.Critical, task.Slack)
+				}
+			}
+			if !reflect.DeepEqual(slack, tt.slack) {
+				t.Errorf("slack = %v, want %v", slack, tt.slack)
+			}
+		})
+	}
+}
@@ -86,6 +86,7 @@ export class HistoryComponent implements OnInit {
           if (event.event.memberId) {
             this.loadMemberUsername(event.event.memberId);
           }
+          (event.event.memberIds || []).forEach(memberId => this.loadMemberUsername(memberId));
           if (event.event.projectId) {
             this.loadProjectTitle(event.event.projectId);
           }